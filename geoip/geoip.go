@@ -0,0 +1,69 @@
+// Package geoip resolves IPs to a country and city from a local MaxMind
+// database (.mmdb), for environments where online lookups aren't possible.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DB is an open MaxMind database
+type DB struct {
+	r *maxminddb.Reader
+}
+
+// Location is what the database knows about an IP. Country-only databases
+// leave City empty.
+type Location struct {
+	Country     string
+	CountryCode string
+	City        string
+}
+
+// record picks the fields hstat uses out of a GeoIP2/GeoLite2 City or
+// Country record
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+// Open opens the database at path
+func Open(path string) (*DB, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{r: r}, nil
+}
+
+// Close releases the database
+func (db *DB) Close() error {
+	return db.r.Close()
+}
+
+// Lookup returns the location of ip. An IP the database has no entry for
+// returns found == false and no error.
+func (db *DB) Lookup(ip string) (loc Location, found bool, err error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, false, fmt.Errorf("invalid IP %q", ip)
+	}
+
+	var rec record
+	_, found, err = db.r.LookupNetwork(parsed, &rec)
+	if err != nil || !found {
+		return Location{}, false, err
+	}
+	return Location{
+		Country:     rec.Country.Names["en"],
+		CountryCode: rec.Country.ISOCode,
+		City:        rec.City.Names["en"],
+	}, true, nil
+}
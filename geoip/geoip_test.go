@@ -0,0 +1,99 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mmdb encoding helpers: a control byte holds the type in its top 3 bits
+// and the size (< 29) in the low 5
+func mmdbString(s string) []byte {
+	return append([]byte{2<<5 | byte(len(s))}, s...)
+}
+
+func mmdbUint16(v uint16) []byte {
+	return []byte{5<<5 | 2, byte(v >> 8), byte(v)}
+}
+
+func mmdbUint32(v uint32) []byte {
+	return []byte{6<<5 | 4, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func mmdbMap(pairs ...[]byte) []byte {
+	out := []byte{7<<5 | byte(len(pairs)/2)}
+	for _, p := range pairs {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// writeTestDB writes an IPv4 database with a single-node tree: addresses
+// whose first bit is 1 (128.0.0.0/1) are Sydney, the rest aren't found
+func writeTestDB(t *testing.T) string {
+	t.Helper()
+
+	const nodeCount = 1
+	var db []byte
+
+	// 24-bit records: left = node count (empty), right = data offset 0
+	left, right := nodeCount, nodeCount+16
+	db = append(db, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+	db = append(db, make([]byte, 16)...)
+
+	db = append(db, mmdbMap(
+		mmdbString("city"), mmdbMap(mmdbString("names"), mmdbMap(mmdbString("en"), mmdbString("Sydney"))),
+		mmdbString("country"), mmdbMap(
+			mmdbString("iso_code"), mmdbString("AU"),
+			mmdbString("names"), mmdbMap(mmdbString("en"), mmdbString("Australia")),
+		),
+	)...)
+
+	db = append(db, "\xAB\xCD\xEFMaxMind.com"...)
+	db = append(db, mmdbMap(
+		mmdbString("node_count"), mmdbUint32(nodeCount),
+		mmdbString("record_size"), mmdbUint16(24),
+		mmdbString("ip_version"), mmdbUint16(4),
+		mmdbString("binary_format_major_version"), mmdbUint16(2),
+	)...)
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, db, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLookup(t *testing.T) {
+	db, err := Open(writeTestDB(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	loc, found, err := db.Lookup("203.0.113.7")
+	if err != nil || !found {
+		t.Fatalf("expected a match, got found=%v err=%v", found, err)
+	}
+	want := Location{Country: "Australia", CountryCode: "AU", City: "Sydney"}
+	if loc != want {
+		t.Errorf("expected %+v, got %+v", want, loc)
+	}
+
+	if _, found, err := db.Lookup("10.0.0.1"); err != nil || found {
+		t.Errorf("expected no match for 10.0.0.1, got found=%v err=%v", found, err)
+	}
+	if _, _, err := db.Lookup("not-an-ip"); err == nil {
+		t.Error("expected error for an invalid IP")
+	}
+}
+
+func TestOpen_NotADatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bogus.mmdb")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("expected error opening a non-database file")
+	}
+}
@@ -2,33 +2,138 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/betternow/hstat/geoip"
+	"github.com/betternow/hstat/metrics"
 	"github.com/betternow/hstat/parser"
 	"github.com/betternow/hstat/store"
+	"github.com/betternow/hstat/syslog"
 	"github.com/betternow/hstat/ui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 const version = "0.1.0"
 
+// pathPatternFlag collects repeated --path-pattern values in order
+type pathPatternFlag []store.PathPattern
+
+func (f *pathPatternFlag) String() string {
+	return fmt.Sprintf("%d patterns", len(*f))
+}
+
+func (f *pathPatternFlag) Set(s string) error {
+	p, err := store.ParsePathPattern(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, p)
+	return nil
+}
+
+// excludeFlag collects repeated and/or comma-separated path values for
+// --exclude and --drop. It stays nil when the flag is never given, so the
+// --exclude defaults apply.
+type excludeFlag []string
+
+func (f *excludeFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *excludeFlag) Set(s string) error {
+	if *f == nil {
+		*f = []string{}
+	}
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			*f = append(*f, p)
+		}
+	}
+	return nil
+}
+
 func main() {
 	// Parse flags
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	showVersionShort := flag.Bool("v", false, "Show version and exit")
 	windowStr := flag.String("window", "10m", "Data window (e.g., 5m, 10m, 1h, or 'all'). Must be at least 10m for 5m trend.")
+	maxEntries := flag.Int("max-entries", store.DefaultMaxEntries, "Most entries to keep, oldest dropped first. Lower saves memory (roughly a few hundred bytes each) but on busy streams stats cover less than the window")
+	sample := flag.Bool("sample", false, "With -window all, keep a uniform random sample of timings so percentiles cover the whole stream once -max-entries is reached")
 	windowShort := flag.String("w", "", "Shorthand for -window")
 	refreshStr := flag.String("refresh", "1s", "Screen refresh interval")
 	refreshShort := flag.String("r", "", "Shorthand for -refresh")
+	staleAfterStr := flag.String("stale-after", ui.DefaultStaleAfter.String(), "Warn in the header after this long without log entries")
+	fileStr := flag.String("file", "", "Read logs from this file or fifo instead of stdin")
+	fileShort := flag.String("f", "", "Shorthand for -file")
+	gzipInput := flag.Bool("gzip", false, "Input is gzip-compressed (automatic for -file names ending in .gz)")
+	replay := flag.Bool("replay", false, "With a log file, play entries back at the pace of their timestamps")
+	replaySpeedStr := flag.String("replay-speed", "1x", "Playback speed multiplier for -replay (e.g., 4x, 0.5x)")
+	watchCodesStr := flag.String("watch-codes", "", "Comma-separated status codes to always show in the header (e.g., 429,499,502,504)")
+	formatStr := flag.String("format", "heroku", "Log format: heroku, nginx-combined, logfmt, or a regex with named groups (status, service, host, path, ip, bytes, method, ts)")
+	fromStr := flag.String("from", "", "Only ingest lines logged at or after this time of day (HH:MM[:SS])")
+	toStr := flag.String("to", "", "Only ingest lines logged at or before this time of day (HH:MM[:SS])")
+	colorStr := flag.String("color", "auto", "Color output: auto (detect terminal), always, or never")
+	noColor := flag.Bool("no-color", false, "Disable colors (same as -color never; NO_COLOR is also honored)")
+	noStatusSection := flag.Bool("no-status-section", false, "Hide the status codes section to give short terminals more data rows")
+	warnRate := flag.Float64("warn-rate", 0, "Dim table 4xx/5xx rates below this percentage (0 disables)")
+	critRate := flag.Float64("crit-rate", 0, "Show table 4xx/5xx rates at or above this percentage in bold red, and rows whose 5xx rate reaches it (0 disables)")
+	confirmQuit := flag.Bool("confirm-quit", false, "Require quit keys to be pressed twice within 2s")
+	debug := flag.Bool("debug", false, "Show a footer with store entry and label counts")
+	var pathPatterns pathPatternFlag
+	flag.Var(&pathPatterns, "path-pattern", "Map paths matching a regex to a route label, as regex=>label (repeatable, first match wins)")
+	var excludes excludeFlag
+	flag.Var(&excludes, "exclude", "Hide a path from the paths table: exact, or prefix* (repeatable or comma-separated; replaces the defaults, '' hides nothing)")
+	excludeFromTotals := flag.Bool("exclude-from-totals", false, "Leave -exclude paths out of totals, rates, and latency too, not just the paths table")
+	var drops excludeFlag
+	flag.Var(&drops, "drop", "Discard requests to a path before counting, e.g. health checks: exact, or prefix* (repeatable or comma-separated). Unlike -exclude, they never reach totals or latency")
+	normalizePaths := flag.Bool("normalize-paths", false, "Count paths with numeric or UUID segments together (/users/123 -> /users/:id)")
+	canonicalHosts := flag.Bool("canonical-hosts", false, "Count host variants together: percent-decode, lowercase, and strip :port")
+	keepHostPorts := flag.Bool("keep-host-ports", false, "With -canonical-hosts, keep :port so ports are counted separately")
+	trendThreshold := flag.Float64("trend-threshold", store.DefaultTrendThreshold*100, "Error-rate change, in percentage points, between periods that shows a trend arrow")
+	trendMinSamples := flag.Int("trend-min-samples", store.DefaultTrendMinSamples, "Requests each period needs before a trend arrow is shown")
+	weightedTrend := flag.Bool("weighted-trend", false, "Only show error-rate trends that are statistically significant for the request volume")
+	dedup := flag.Bool("dedup", false, "Skip lines whose request_id was already seen within the window (uses extra memory)")
+	syslogTCP := flag.String("syslog-tcp", "", "Listen for syslog log drain messages on this TCP address (e.g., :5140) instead of reading stdin")
+	listenAddr := flag.String("listen", "", "Serve Prometheus metrics at /metrics on this address (e.g., :9100)")
+	csvTimeseries := flag.String("csv-timeseries", "", "Write a per-bucket CSV time series of the input to this file and exit")
+	csvPath := flag.String("csv", "", "Export hosts, IPs, and paths as CSV to this file: after -json/-watch input ends, or on x in the UI")
+	bucketStr := flag.String("bucket", "1m", "Bucket size for -csv-timeseries")
+	healthScore := flag.Bool("health-score", false, "Show a 0-100 composite health score in the header")
+	apdexThresholdStr := flag.String("apdex-threshold", "", "Show an Apdex score in the header with this satisfied threshold T (e.g., 100ms); 4T is tolerated")
+	healthWeightsStr := flag.String("health-weights", "", "Health score weights, e.g. errors=50,latency=35,trend=15,p95=1000 (p95 target in ms)")
+	jsonOut := flag.Bool("json", false, "Skip the UI and write stats as JSON to stdout when the stream ends")
+	jsonIntervalStr := flag.String("json-interval", "0", "With -json, also write a document every interval (e.g., 10s); 0 = only at the end")
+	watchStr := flag.String("watch", "", "Skip the UI and print a compact text summary to stdout every interval (e.g., 10s)")
+	summary := flag.Bool("summary", false, "Print a plain-text summary to stdout on exit")
+	keymapPath := flag.String("keymap", "", "Key bindings file to use instead of ~/.config/hstat/keys.toml")
+	viewStateFile := flag.String("view-state-file", "", "Save the section and filter to this file and restore them on start")
+	geoipPath := flag.String("geoip", "", "MaxMind .mmdb database for offline IP location lookups (o key)")
+	ipinfoToken := flag.String("ipinfo-token", "", "ipinfo.io API token for the i lookup (default $IPINFO_TOKEN)")
+	stateFile := flag.String("state", "", "Load accumulated entries from this file on start and save them back on exit")
+	pathTruncateStr := flag.String("path-truncate", "end", "How to shorten long paths: end (keep prefix), start (keep suffix), or middle (keep both ends)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "hstat v%s\n\n", version)
-		fmt.Fprintf(os.Stderr, "Usage: heroku logs --tail -a myapp | hstat [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: heroku logs --tail -a myapp | hstat [options]\n")
+		fmt.Fprintf(os.Stderr, "   or: hstat [options] -file router.log\n\n")
 		fmt.Fprintf(os.Stderr, "Real-time Heroku router log monitor with interactive filtering.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
@@ -49,6 +154,9 @@ func main() {
 	if *refreshShort != "" {
 		refreshStr = refreshShort
 	}
+	if *fileShort != "" {
+		fileStr = fileShort
+	}
 
 	// Parse window duration
 	var window time.Duration
@@ -61,6 +169,15 @@ func main() {
 		}
 	}
 
+	if *maxEntries <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid max-entries: %d (must be positive)\n", *maxEntries)
+		os.Exit(1)
+	}
+	if *sample && window != 0 {
+		fmt.Fprintln(os.Stderr, "-sample needs -window all")
+		os.Exit(1)
+	}
+
 	// Parse refresh duration
 	refresh, err := time.ParseDuration(*refreshStr)
 	if err != nil {
@@ -68,30 +185,352 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Check if stdin is a terminal (we need piped input)
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) != 0 {
-		fmt.Fprintln(os.Stderr, "Error: hstat requires log input via stdin")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Usage: heroku logs --tail -a myapp | hstat")
-		fmt.Fprintln(os.Stderr, "   or: hstat < router.log")
+	staleAfter, err := time.ParseDuration(*staleAfterStr)
+	if err != nil || staleAfter <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid stale-after duration: %s\n", *staleAfterStr)
 		os.Exit(1)
 	}
 
-	// Create store and model
-	s := store.New(window)
-	m := ui.NewModel(s, refresh)
+	// Parse path truncation mode
+	pathTruncate, err := ui.ParseTruncateMode(*pathTruncateStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid path-truncate mode: %s\n", *pathTruncateStr)
+		os.Exit(1)
+	}
+
+	// Parse color mode
+	colorMode, err := ui.ParseColorMode(*colorStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid color mode: %s\n", *colorStr)
+		os.Exit(1)
+	}
+	if *noColor {
+		colorMode = ui.ColorNever
+	} else {
+		colorMode = ui.ColorModeWithEnv(colorMode, os.Getenv("NO_COLOR"))
+	}
 
-	// Open TTY for keyboard input (since stdin is the log pipe)
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	// Parse watched status codes
+	watchCodes, err := ui.ParseWatchCodes(*watchCodesStr)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening /dev/tty: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Invalid watch-codes: %v\n", err)
 		os.Exit(1)
 	}
-	defer tty.Close()
 
-	// Create program with explicit TTY input
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithInput(tty))
+	// Parse health score weights
+	healthWeights, err := ui.ParseHealthWeights(*healthWeightsStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid health-weights: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Select the log format before any line is parsed
+	logFormat, err := parser.ParseFormat(*formatStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid format: %v\n", err)
+		os.Exit(1)
+	}
+	parser.SetFormat(logFormat)
+
+	// Error rate thresholds are percentages
+	if *warnRate < 0 || *warnRate > 100 || *critRate < 0 || *critRate > 100 {
+		fmt.Fprintln(os.Stderr, "Error: -warn-rate and -crit-rate must be between 0 and 100")
+		os.Exit(1)
+	}
+	if *warnRate > 0 && *critRate > 0 && *critRate < *warnRate {
+		fmt.Fprintln(os.Stderr, "Error: -crit-rate must not be below -warn-rate")
+		os.Exit(1)
+	}
+
+	if *trendThreshold <= 0 || *trendThreshold > 100 {
+		fmt.Fprintln(os.Stderr, "Error: -trend-threshold must be above 0 and at most 100")
+		os.Exit(1)
+	}
+	if *trendMinSamples <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid trend-min-samples: %d (must be positive)\n", *trendMinSamples)
+		os.Exit(1)
+	}
+
+	// Parse Apdex threshold
+	var apdexThreshold time.Duration
+	if *apdexThresholdStr != "" {
+		apdexThreshold, err = time.ParseDuration(*apdexThresholdStr)
+		if err != nil || apdexThreshold < time.Millisecond {
+			fmt.Fprintf(os.Stderr, "Invalid apdex-threshold duration: %s\n", *apdexThresholdStr)
+			os.Exit(1)
+		}
+	}
+
+	// Parse ingest time slice
+	slice, err := parser.ParseTimeSlice(*fromStr, *toStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid time slice: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse JSON report interval
+	jsonInterval, err := time.ParseDuration(*jsonIntervalStr)
+	if err != nil || jsonInterval < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid json-interval duration: %s\n", *jsonIntervalStr)
+		os.Exit(1)
+	}
+	if *jsonOut && *syslogTCP != "" && jsonInterval == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -json with -syslog-tcp never reaches the end of the stream; set -json-interval")
+		os.Exit(1)
+	}
+
+	// Parse headless watch interval
+	var watch time.Duration
+	if *watchStr != "" {
+		watch, err = time.ParseDuration(*watchStr)
+		if err != nil || watch <= 0 {
+			fmt.Fprintf(os.Stderr, "Invalid watch duration: %s\n", *watchStr)
+			os.Exit(1)
+		}
+		if *jsonOut {
+			fmt.Fprintln(os.Stderr, "Error: use either -json or -watch, not both")
+			os.Exit(1)
+		}
+	}
+
+	// Parse time series bucket size
+	bucket, err := time.ParseDuration(*bucketStr)
+	if err != nil || bucket <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid bucket duration: %s\n", *bucketStr)
+		os.Exit(1)
+	}
+
+	// Load key bindings. An explicit -keymap must exist and parse; the
+	// default config falls back to the built-in keys on a bad file.
+	var keys ui.KeyMap
+	if *keymapPath != "" {
+		if _, err := os.Stat(*keymapPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid keymap: %v\n", err)
+			os.Exit(1)
+		}
+		if keys, err = ui.LoadKeyMap(*keymapPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid keymap: %v\n", err)
+			os.Exit(1)
+		}
+	} else if keys, err = ui.LoadKeyMap(ui.DefaultKeysPath()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring key bindings config: %v\n", err)
+	}
+
+	// Load saved view state
+	var viewState ui.ViewState
+	if *viewStateFile != "" {
+		viewState, err = ui.LoadViewState(*viewStateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid view-state-file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Read from a log file if given, otherwise from piped stdin
+	logPath := *fileStr
+	if flag.NArg() > 0 {
+		if logPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: give the log file as -file or as an argument, not both")
+			os.Exit(1)
+		}
+		logPath = flag.Arg(0)
+	}
+	var input io.Reader = os.Stdin
+	// A regular file (not a fifo or pipe) is an archive rather than a live
+	// stream, so its own timestamps are the clock
+	logClock := *syslogTCP == "" && isRegularFile(os.Stdin)
+	if logPath != "" {
+		f, err := os.Open(logPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		input = f
+		logClock = isRegularFile(f)
+	} else if *syslogTCP == "" {
+		// Check if stdin is a terminal (we need piped input)
+		if stdinIsTerminal() {
+			fmt.Fprintln(os.Stderr, "Error: hstat requires log input via stdin or a file argument")
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "Usage: heroku logs --tail -a myapp | hstat")
+			fmt.Fprintln(os.Stderr, "   or: hstat < router.log")
+			fmt.Fprintln(os.Stderr, "   or: hstat -file router.log")
+			os.Exit(1)
+		}
+	}
+	if *syslogTCP == "" && (*gzipInput || strings.HasSuffix(logPath, ".gz")) {
+		zr, err := gunzip(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading gzip input: %v\n", err)
+			os.Exit(1)
+		}
+		defer zr.Close()
+		input = zr
+	}
+
+	// Replay paces a file through the UI, so it needs both
+	var replaySpeed float64
+	if *replay {
+		replaySpeed, err = parseReplaySpeed(*replaySpeedStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid replay-speed: %v\n", err)
+			os.Exit(1)
+		}
+		if logPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: -replay needs a log file")
+			os.Exit(1)
+		}
+		if *jsonOut || watch > 0 || *csvTimeseries != "" {
+			fmt.Fprintln(os.Stderr, "Error: -replay only works with the UI, not -json, -watch, or -csv-timeseries")
+			os.Exit(1)
+		}
+	}
+
+	// CSV export runs over the whole input without the TUI
+	if *csvTimeseries != "" && *syslogTCP != "" {
+		fmt.Fprintln(os.Stderr, "Error: -csv-timeseries needs a file or stdin, not -syslog-tcp")
+		os.Exit(1)
+	}
+	if *csvTimeseries != "" {
+		if err := exportTimeSeries(input, slice, bucket, *csvTimeseries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing time series: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Override color detection before anything is rendered
+	ui.ApplyColorMode(colorMode)
+
+	// Create store and model
+	s := store.New(window)
+	s.SetMaxEntries(*maxEntries)
+	// A replay is rebased onto the wall clock as it plays
+	s.SetLogClock(logClock && !*replay)
+	s.SetSampling(*sample)
+	s.SetPathPatterns(pathPatterns)
+	s.SetNormalizePaths(*normalizePaths)
+	if excludes != nil {
+		s.SetExcludedPaths(excludes)
+	}
+	s.SetExcludeFromTotals(*excludeFromTotals)
+	s.SetDroppedPaths(drops)
+	s.SetCanonicalHosts(*canonicalHosts, *keepHostPorts)
+	s.SetDedup(*dedup)
+	s.SetWeightedTrend(*weightedTrend)
+	s.SetTrendThresholds(*trendThreshold/100, *trendMinSamples)
+
+	// Pick up where the last run left off; a bad file shouldn't stop
+	// monitoring, so it only warns
+	if *stateFile != "" {
+		if err := loadState(*stateFile, s); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring state file: %v\n", err)
+		}
+	}
+
+	// Optional Prometheus endpoint, alongside whichever mode runs below
+	if *listenAddr != "" {
+		srv, err := serveMetrics(*listenAddr, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting metrics server: %v\n", err)
+			os.Exit(1)
+		}
+		defer srv.Close()
+	}
+
+	// The UI would write escape sequences into a pipe or file, so a
+	// redirected stdout gets -watch reports instead
+	if !*jsonOut && watch == 0 && !stdoutIsTerminal() {
+		fmt.Fprintf(os.Stderr, "stdout is not a terminal; printing summaries every %s (use -json or -watch to choose)\n", defaultWatchInterval)
+		watch = defaultWatchInterval
+	}
+
+	// JSON and watch modes bypass the UI entirely
+	if *jsonOut || watch > 0 {
+		report, interval := writeJSONReport, jsonInterval
+		if watch > 0 {
+			report, interval = writeWatchReport, watch
+		}
+		dumpSummaryOnSignal(s, func(path string, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing summary: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Summary saved: %s\n", path)
+			}
+		})
+		if err := runHeadless(os.Stdout, s, input, slice, *syslogTCP, interval, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *stateFile != "" {
+			if err := saveState(*stateFile, s); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving state: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *csvPath != "" {
+			if err := exportCSV(*csvPath, s); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	opts := []ui.Option{
+		ui.WithPathTruncate(pathTruncate),
+		ui.WithWatchCodes(watchCodes),
+		ui.WithKeyMap(keys),
+		ui.WithNoStatusSection(*noStatusSection),
+		ui.WithDebug(*debug),
+		ui.WithConfirmQuit(*confirmQuit),
+		ui.WithStaleAfter(staleAfter),
+		ui.WithErrorRateThresholds(*warnRate, *critRate),
+	}
+	if *healthScore {
+		opts = append(opts, ui.WithHealthScore(healthWeights))
+	}
+	if apdexThreshold > 0 {
+		opts = append(opts, ui.WithApdex(int(apdexThreshold.Milliseconds())))
+	}
+	if *ipinfoToken == "" {
+		*ipinfoToken = os.Getenv("IPINFO_TOKEN")
+	}
+	if *ipinfoToken != "" {
+		opts = append(opts, ui.WithIpinfoToken(*ipinfoToken))
+	}
+	if *geoipPath != "" {
+		geo, err := geoip.Open(*geoipPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening GeoIP database: %v\n", err)
+			os.Exit(1)
+		}
+		defer geo.Close()
+		opts = append(opts, ui.WithGeoIP(geo))
+	}
+	if *viewStateFile != "" {
+		opts = append(opts, ui.WithViewState(*viewStateFile, viewState))
+	}
+	if *csvPath != "" {
+		opts = append(opts, ui.WithCSVPath(*csvPath))
+	}
+	m := ui.NewModel(s, refresh, opts...)
+
+	// Keys come from stdin when it's a free terminal (logs from a file or
+	// syslog); otherwise stdin is the log pipe, so open the TTY directly
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if (logPath == "" && *syslogTCP == "") || !stdinIsTerminal() {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening /dev/tty: %v\n", err)
+			os.Exit(1)
+		}
+		defer tty.Close()
+		programOpts = append(programOpts, tea.WithInput(tty))
+	}
+
+	p := tea.NewProgram(m, programOpts...)
 
 	// Handle signals for clean exit
 	sigChan := make(chan os.Signal, 1)
@@ -101,27 +540,595 @@ func main() {
 		p.Quit()
 	}()
 
-	// Start stdin reader in goroutine
-	go readStdin(p, s)
+	// SIGUSR1 dumps a summary without quitting; the header shows where
+	dumpSummaryOnSignal(s, func(path string, err error) {
+		p.Send(ui.SnapshotResultMsg{Path: path, Err: err})
+	})
+
+	// Start log reader: a syslog drain listener, or stdin/file in a goroutine
+	readErr := make(chan error, 1)
+	if *syslogTCP != "" {
+		batcher := newEntryBatcher(func(batch []*parser.Entry) {
+			p.Send(ui.EntriesMsg(batch))
+		})
+		l, err := syslog.Listen(*syslogTCP, func(line string) {
+			if entry := parseLine(line, slice); entry != nil {
+				batcher.add(entry)
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting syslog listener: %v\n", err)
+			os.Exit(1)
+		}
+		defer l.Close()
+	} else {
+		go func() {
+			readErr <- readLog(p, input, slice, replaySpeed)
+		}()
+	}
 
 	// Run program
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Stderr would garble the UI, so a read error waits until now
+	select {
+	case err := <-readErr:
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input, stream ended early: %v\n", err)
+		}
+	default:
+	}
+
+	// The alt screen is gone by now, so this lands on the real stdout
+	if *summary {
+		fmt.Print(s.Summary())
+	}
+
+	if *stateFile != "" {
+		if err := saveState(*stateFile, s); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving state: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
-func readStdin(p *tea.Program, s *store.Store) {
-	scanner := bufio.NewScanner(os.Stdin)
+// dumpSummaryOnSignal writes s.Summary() to a timestamped file each time
+// the process gets SIGUSR1, then calls done with the result. Runs in the
+// background so the UI carries on undisturbed.
+func dumpSummaryOnSignal(s *store.Store, done func(path string, err error)) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			done(writeSummaryFile(s, time.Now()))
+		}
+	}()
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		entry := parser.Parse(line)
-		if entry != nil {
-			p.Send(ui.EntryMsg{Entry: entry})
+// writeSummaryFile writes s.Summary() to hstat-summary-<unixtime>.txt in
+// the working directory and returns its path
+func writeSummaryFile(s *store.Store, at time.Time) (string, error) {
+	path := fmt.Sprintf("hstat-summary-%d.txt", at.Unix())
+	content := fmt.Sprintf("%s\n%s", at.Format(time.RFC3339), s.Summary())
+	return path, os.WriteFile(path, []byte(content), 0o644)
+}
+
+// serveMetrics starts an HTTP server exposing the store's stats at
+// /metrics. Listening happens up front so a bad address fails fast.
+func serveMetrics(addr string, s *store.Store) (*http.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(s))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(l)
+	return srv, nil
+}
+
+// loadState loads a -state file into s. A missing file is not an error,
+// since the first run won't have one yet.
+func loadState(path string, s *store.Store) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Load(bufio.NewReader(f))
+}
+
+// saveState writes s to a -state file. It writes a temp file and renames
+// it over the old one, so a crash mid-write can't lose the previous state.
+func saveState(path string, s *store.Store) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	w := bufio.NewWriter(tmp)
+	if err := s.Save(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// jsonReport is the document written by -json
+type jsonReport struct {
+	Stats        jsonStats        `json:"stats"`
+	ErrorRates   jsonErrorRates   `json:"error_rates"`
+	StatusCounts map[string]int64 `json:"status_counts"`
+	TopHosts     []jsonCount      `json:"top_hosts"`
+	TopIPs       []jsonCount      `json:"top_ips"`
+	TopPaths     []jsonCount      `json:"top_paths"`
+}
+
+type jsonStats struct {
+	Total      int64   `json:"total"`
+	ReqPerSec  float64 `json:"req_per_sec"`
+	AvgService int     `json:"avg_service_ms"`
+	P50Service int     `json:"p50_service_ms"`
+	P95Service int     `json:"p95_service_ms"`
+	P99Service int     `json:"p99_service_ms"`
+	MaxService int     `json:"max_service_ms"`
+	AvgConnect int     `json:"avg_connect_ms"`
+	P50Connect int     `json:"p50_connect_ms"`
+	P95Connect int     `json:"p95_connect_ms"`
+	P99Connect int     `json:"p99_connect_ms"`
+	MaxConnect int     `json:"max_connect_ms"`
+}
+
+type jsonErrorRates struct {
+	Rate4xx float64 `json:"4xx"`
+	Rate5xx float64 `json:"5xx"`
+}
+
+type jsonCount struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// jsonTopN is how many hosts, IPs, and paths a JSON report lists
+const jsonTopN = 20
+
+// runHeadless feeds the input (or a syslog listener) into the store and
+// writes reports to w: every interval if set, and once more when the stream
+// ends or on SIGINT/SIGTERM
+func runHeadless(w io.Writer, s *store.Store, input io.Reader, slice parser.TimeSlice, syslogAddr string, interval time.Duration, report func(io.Writer, *store.Store) error) error {
+	// An interrupt ends the run like the end of input does, so the final
+	// report and whatever the caller saves after it (e.g. -state) happen
+	done := make(chan struct{})
+	var finish sync.Once
+	stop := func() { finish.Do(func() { close(done) }) }
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			stop()
+		case <-done:
+		}
+	}()
+
+	readErr := make(chan error, 1) // filled before done closes
+	if syslogAddr != "" {
+		l, err := syslog.Listen(syslogAddr, func(line string) {
+			if entry := parseLine(line, slice); entry != nil {
+				s.Add(entry)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("starting syslog listener: %w", err)
+		}
+		defer l.Close()
+	} else {
+		go func() {
+			readErr <- ingest(input, slice, s.Add)
+			stop()
+		}()
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			if err := report(w, s); err != nil {
+				return err
+			}
+		case <-done:
+			if err := report(w, s); err != nil {
+				return err
+			}
+			select {
+			case err := <-readErr:
+				if err != nil {
+					return fmt.Errorf("reading input: %w", err)
+				}
+			default: // interrupted
+			}
+			return nil
 		}
 	}
+}
+
+// writeJSONReport writes the store's current stats as one JSON document
+func writeJSONReport(w io.Writer, s *store.Store) error {
+	s.Prune()
+	stats := s.GetStats()
+	rate4xx, rate5xx := s.GetErrorRates()
+
+	// Average over the span of the data, at least a second
+	span := s.LatestTime().Sub(s.StartTime())
+	if span < time.Second {
+		span = time.Second
+	}
+
+	report := jsonReport{
+		Stats: jsonStats{
+			Total:      stats.TotalCount,
+			ReqPerSec:  float64(stats.TotalCount) / span.Seconds(),
+			AvgService: stats.AvgService,
+			P50Service: stats.P50Service,
+			P95Service: stats.P95Service,
+			P99Service: stats.P99Service,
+			MaxService: stats.MaxService,
+			AvgConnect: stats.AvgConnect,
+			P50Connect: stats.P50Connect,
+			P95Connect: stats.P95Connect,
+			P99Connect: stats.P99Connect,
+			MaxConnect: stats.MaxConnect,
+		},
+		ErrorRates:   jsonErrorRates{Rate4xx: rate4xx, Rate5xx: rate5xx},
+		StatusCounts: make(map[string]int64),
+		TopHosts:     jsonCounts(s.GetTopHosts(jsonTopN, "")),
+		TopIPs:       jsonCounts(s.GetTopIPs(jsonTopN, "")),
+		TopPaths:     jsonCounts(s.GetAllPaths(jsonTopN)),
+	}
+	for _, sc := range s.GetStatusCounts("", "") {
+		report.StatusCounts[strconv.Itoa(sc.Status)] = sc.Count
+	}
+
+	return json.NewEncoder(w).Encode(report)
+}
+
+// watchTopN is how many hosts a -watch report lists
+const watchTopN = 5
+
+// defaultWatchInterval is the -watch interval used when stdout isn't a
+// terminal and no headless mode was asked for
+const defaultWatchInterval = 10 * time.Second
+
+// writeWatchReport writes a compact plain-text snapshot of the store for
+// -watch: a timestamp header, one status line, status counts, and the
+// top hosts with their error rates
+func writeWatchReport(w io.Writer, s *store.Store) error {
+	s.Prune()
+	stats := s.GetStats()
+	rate4xx, rate5xx := s.GetErrorRates()
+
+	// Average over the span of the data, at least a second
+	span := s.LatestTime().Sub(s.StartTime())
+	if span < time.Second {
+		span = time.Second
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- hstat %s ---\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "%d req (%.1f/s) | 4xx %.1f%% | 5xx %.1f%% | p50 %dms p95 %dms p99 %dms\n",
+		stats.TotalCount, float64(stats.TotalCount)/span.Seconds(), rate4xx, rate5xx,
+		stats.P50Service, stats.P95Service, stats.P99Service)
+
+	var codes []string
+	for _, sc := range s.GetStatusCounts("", "") {
+		codes = append(codes, fmt.Sprintf("%d: %d", sc.Status, sc.Count))
+	}
+	if len(codes) > 0 {
+		fmt.Fprintf(&b, "status  %s\n", strings.Join(codes, "  "))
+	}
+
+	hosts := s.GetTopHosts(watchTopN, "")
+	width := 0
+	for _, h := range hosts {
+		width = max(width, len(h.Label))
+	}
+	for _, h := range hosts {
+		rates := s.GetErrorRatesForHost(h.Label)
+		fmt.Fprintf(&b, "  %-*s  %8d  4xx %5.1f%%  5xx %5.1f%%\n", width, h.Label, h.Count, rates.Rate4xx, rates.Rate5xx)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// jsonCounts converts count items, keeping empty lists as [] rather than null
+func jsonCounts(items []store.CountItem) []jsonCount {
+	counts := make([]jsonCount, 0, len(items))
+	for _, item := range items {
+		counts = append(counts, jsonCount{Label: item.Label, Count: item.Count})
+	}
+	return counts
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// isRegularFile reports whether f is a regular file
+func isRegularFile(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// stdoutIsTerminal reports whether stdout is an interactive terminal
+func stdoutIsTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// gunzip wraps r to decompress gzip input. Concatenated members, as from
+// cat a.gz b.gz, are read as one stream.
+func gunzip(r io.Reader) (io.ReadCloser, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	zr.Multistream(true)
+	return zr, nil
+}
+
+// readLog streams r into the program until EOF. For a regular file (or a
+// fifo whose writer closes) that marks the stream as ended. It returns why
+// reading stopped early, if it did.
+func readLog(p *tea.Program, r io.Reader, slice parser.TimeSlice, replaySpeed float64) error {
+	var pacer *replayPacer
+	if replaySpeed > 0 {
+		pacer = newReplayPacer(replaySpeed)
+	}
+	batcher := newEntryBatcher(func(batch []*parser.Entry) {
+		p.Send(ui.EntriesMsg(batch))
+	})
+	err := ingest(r, slice, func(entry *parser.Entry) {
+		if pacer != nil {
+			pacer.pace(entry)
+		}
+		batcher.add(entry)
+	})
+	batcher.flush()
 
 	// Signal that stream has ended
 	p.Send(ui.StreamEndedMsg{})
+	return err
+}
+
+// Entries are sent to the UI in batches of up to maxBatchSize, and none
+// waits longer than maxBatchDelay
+const (
+	maxBatchSize  = 500
+	maxBatchDelay = 50 * time.Millisecond
+)
+
+// entryBatcher groups entries so a busy stream sends one message per
+// batch rather than one per line
+type entryBatcher struct {
+	mu    sync.Mutex
+	batch []*parser.Entry
+	timer *time.Timer
+	send  func([]*parser.Entry)
+}
+
+func newEntryBatcher(send func([]*parser.Entry)) *entryBatcher {
+	return &entryBatcher{send: send}
+}
+
+// add queues an entry, sending the batch once it is full
+func (b *entryBatcher) add(e *parser.Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.batch = append(b.batch, e)
+	if len(b.batch) >= maxBatchSize {
+		b.flushLocked()
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(maxBatchDelay, b.flush)
+	}
+}
+
+// flush sends any queued entries now
+func (b *entryBatcher) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.flushLocked()
+}
+
+func (b *entryBatcher) flushLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.batch) == 0 {
+		return
+	}
+	b.send(b.batch)
+	b.batch = nil
+}
+
+// parseReplaySpeed parses a -replay-speed multiplier such as 4x, 0.5x, or 2
+func parseReplaySpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 64)
+	if err != nil || speed <= 0 || math.IsInf(speed, 0) {
+		return 0, fmt.Errorf("%q is not a positive multiplier like 4x", s)
+	}
+	return speed, nil
+}
+
+// replayPacer holds back entries so they arrive spaced like their log
+// timestamps, divided by speed. Timestamps are rebased onto the wall
+// clock, so the window, rates, and trends treat the replay as live.
+type replayPacer struct {
+	speed float64
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	first time.Time // log time of the first entry
+	start time.Time // wall time the first entry was played
+}
+
+func newReplayPacer(speed float64) *replayPacer {
+	return &replayPacer{speed: speed, now: time.Now, sleep: time.Sleep}
+}
+
+// pace blocks until e is due and rewrites its timestamp to the play time
+func (r *replayPacer) pace(e *parser.Entry) {
+	if r.start.IsZero() {
+		r.first, r.start = e.Timestamp, r.now()
+	}
+
+	// Lines logged out of order play straight away
+	offset := max(time.Duration(float64(e.Timestamp.Sub(r.first))/r.speed), 0)
+	at := r.start.Add(offset)
+	if wait := at.Sub(r.now()); wait > 0 {
+		r.sleep(wait)
+	}
+	e.Timestamp = at
+}
+
+// maxLineSize is the longest log line read; bufio.Scanner's 64KB default
+// is too short for huge query strings or long fwd chains
+const maxLineSize = 1 << 20
+
+// newLineScanner returns a line scanner over r that allows maxLineSize
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return scanner
+}
+
+// ingest parses router lines from r, skipping any outside the time slice.
+// It returns why reading stopped early, e.g. a line over maxLineSize.
+func ingest(r io.Reader, slice parser.TimeSlice, emit func(*parser.Entry)) error {
+	scanner := newLineScanner(r)
+
+	for scanner.Scan() {
+		if entry := parseLine(scanner.Text(), slice); entry != nil {
+			emit(entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseLine parses a router line, or returns nil if it isn't one or
+// falls outside the time slice
+func parseLine(line string, slice parser.TimeSlice) *parser.Entry {
+	if !slice.Contains(line) {
+		return nil
+	}
+	return parser.Parse(line)
+}
+
+// exportTimeSeries buckets the input by log timestamp and writes it as CSV
+func exportTimeSeries(r io.Reader, slice parser.TimeSlice, bucket time.Duration, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := collectEntries(r, slice)
+	if err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+	buckets := store.TimeSeries(entries, bucket)
+	if err := writeTimeSeriesCSV(f, buckets); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// exportCSV writes the store's host, IP, and path aggregates to path
+func exportCSV(path string, s *store.Store) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.ExportCSV(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// collectEntries parses router lines from r, stamping each entry with its
+// log timestamp rather than the ingest time. Lines without one are skipped.
+func collectEntries(r io.Reader, slice parser.TimeSlice) ([]parser.Entry, error) {
+	var entries []parser.Entry
+	scanner := newLineScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !slice.Contains(line) {
+			continue
+		}
+		// Lines without a timestamp can't be bucketed
+		if _, ok := parser.LogTime(line); !ok {
+			continue
+		}
+		if entry := parser.Parse(line); entry != nil && !entry.Malformed {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// writeTimeSeriesCSV writes one row per bucket with a header row
+func writeTimeSeriesCSV(w io.Writer, buckets []store.Bucket) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "total", "2xx", "3xx", "4xx", "5xx", "p50", "p95", "p99", "req_per_sec"})
+
+	for _, b := range buckets {
+		cw.Write([]string{
+			b.Start.Format(time.RFC3339),
+			strconv.FormatInt(b.Total, 10),
+			strconv.FormatInt(b.Status[2], 10),
+			strconv.FormatInt(b.Status[3], 10),
+			strconv.FormatInt(b.Status[4], 10),
+			strconv.FormatInt(b.Status[5], 10),
+			strconv.Itoa(b.P50),
+			strconv.Itoa(b.P95),
+			strconv.Itoa(b.P99),
+			strconv.FormatFloat(b.Rate, 'f', 2, 64),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
 }
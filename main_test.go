@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+	"github.com/betternow/hstat/store"
+)
+
+func TestIngest_SkipsLinesOutsideTimeSlice(t *testing.T) {
+	log := strings.Join([]string{
+		`2024-01-15T10:20:00.000000+00:00 heroku[router]: host=a.com fwd="1.1.1.1" status=200 service=10ms`,
+		`2024-01-15T10:30:00.000000+00:00 heroku[router]: host=a.com fwd="1.1.1.1" status=500 service=10ms`,
+		`2024-01-15T10:31:00.000000+00:00 app[web.1]: Completed 500`,
+		`2024-01-15T10:33:00.000000+00:00 heroku[router]: host=b.com fwd="2.2.2.2" status=502 service=10ms`,
+		`2024-01-15T10:40:00.000000+00:00 heroku[router]: host=c.com fwd="3.3.3.3" status=200 service=10ms`,
+	}, "\n")
+
+	slice, err := parser.ParseTimeSlice("10:29", "10:35")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := store.New(0)
+	ingest(strings.NewReader(log), slice, s.Add)
+
+	if s.TotalCount != 2 {
+		t.Errorf("expected 2 entries inside the slice, got %d", s.TotalCount)
+	}
+	if s.StatusCounts[200] != 0 {
+		t.Errorf("expected 200s outside the slice to be skipped, got %d", s.StatusCounts[200])
+	}
+	if s.HostCounts["c.com"] != 0 {
+		t.Errorf("expected c.com (after --to) to be skipped, got %d", s.HostCounts["c.com"])
+	}
+	rate4xx, rate5xx := s.GetErrorRates()
+	if rate4xx != 0 || rate5xx != 100 {
+		t.Errorf("expected summary to reflect only the slice (0%% 4xx, 100%% 5xx), got %.1f/%.1f", rate4xx, rate5xx)
+	}
+}
+
+func TestRunHeadless_TimeSliceOldLog(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+	slice, err := parser.ParseTimeSlice("10:29", "10:32")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	if err := runHeadless(&b, s, f, slice, "", 0, writeJSONReport); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal([]byte(b.String()), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+
+	// Only 10:30 and 10:31 are in the slice, and the window is measured
+	// from the last of them rather than from now
+	if report.Stats.Total != 2 {
+		t.Errorf("expected the 2 entries in the slice, got %d", report.Stats.Total)
+	}
+	if report.StatusCounts["200"] != 1 || report.StatusCounts["404"] != 1 || report.StatusCounts["502"] != 0 {
+		t.Errorf("unexpected status counts: %v", report.StatusCounts)
+	}
+}
+
+func TestIngest_LongLines(t *testing.T) {
+	// A 200KB query string is past bufio.Scanner's 64KB default
+	long := `heroku[router]: host=a.com fwd="1.1.1.1" path="/search?q=` + strings.Repeat("x", 200*1024) + `" status=200 service=10ms`
+	log := strings.Join([]string{
+		long,
+		`heroku[router]: host=b.com fwd="2.2.2.2" status=200 service=10ms`,
+	}, "\n")
+
+	s := store.New(0)
+	if err := ingest(strings.NewReader(log), parser.TimeSlice{}, s.Add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.TotalCount != 2 || s.HostCounts["b.com"] != 1 {
+		t.Errorf("expected reading to continue past the long line, got %d entries", s.TotalCount)
+	}
+
+	// Past maxLineSize, reading stops with an error instead of silently
+	tooLong := strings.Repeat("x", maxLineSize+1) + "\n" + `heroku[router]: host=c.com status=200 service=10ms`
+	s = store.New(0)
+	if err := ingest(strings.NewReader(tooLong), parser.TimeSlice{}, s.Add); err == nil {
+		t.Error("expected an error for a line over maxLineSize")
+	}
+}
+
+func TestWriteTimeSeriesCSV_TwoMinutes(t *testing.T) {
+	log := strings.Join([]string{
+		`2024-01-15T10:30:05.000000+00:00 heroku[router]: host=a.com status=200 service=10ms`,
+		`2024-01-15T10:30:15.000000+00:00 heroku[router]: host=a.com status=200 service=20ms`,
+		`2024-01-15T10:30:45.000000+00:00 heroku[router]: host=a.com status=404 service=30ms`,
+		`2024-01-15T10:30:50.000000+00:00 app[web.1]: not a router line`,
+		`2024-01-15T10:31:10.000000+00:00 heroku[router]: host=a.com status=502 service=40ms`,
+		`2024-01-15T10:31:20.000000+00:00 heroku[router]: host=a.com status=301 service=50ms`,
+	}, "\n")
+
+	entries, err := collectEntries(strings.NewReader(log), parser.TimeSlice{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out strings.Builder
+	if err := writeTimeSeriesCSV(&out, store.TimeSeries(entries, time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(rows) != 3 {
+		t.Fatalf("expected header + 2 data rows, got %d:\n%s", len(rows), out.String())
+	}
+	if rows[0] != "timestamp,total,2xx,3xx,4xx,5xx,p50,p95,p99,req_per_sec" {
+		t.Errorf("unexpected header: %s", rows[0])
+	}
+	if rows[1] != "2024-01-15T10:30:00Z,3,2,0,1,0,20,30,30,0.05" {
+		t.Errorf("unexpected 10:30 row: %s", rows[1])
+	}
+	if rows[2] != "2024-01-15T10:31:00Z,2,0,1,0,1,40,50,50,0.03" {
+		t.Errorf("unexpected 10:31 row: %s", rows[2])
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	s := store.New(0)
+	now := time.Now()
+	s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/x", Service: 10})
+	s.Add(&parser.Entry{Timestamp: now, Status: 500, Host: "a.com", IP: "2.2.2.2", Path: "/x", Service: 30})
+
+	var b strings.Builder
+	if err := writeJSONReport(&b, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal([]byte(b.String()), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+	if report.Stats.Total != 2 || report.Stats.MaxService != 30 {
+		t.Errorf("unexpected stats: %+v", report.Stats)
+	}
+	if report.ErrorRates.Rate5xx != 50 {
+		t.Errorf("expected 50%% 5xx, got %.1f", report.ErrorRates.Rate5xx)
+	}
+	if report.StatusCounts["500"] != 1 {
+		t.Errorf("expected one 500, got %v", report.StatusCounts)
+	}
+	if len(report.TopHosts) != 1 || report.TopHosts[0] != (jsonCount{Label: "a.com", Count: 2}) {
+		t.Errorf("unexpected top hosts: %v", report.TopHosts)
+	}
+	if len(report.TopIPs) != 2 || len(report.TopPaths) != 1 {
+		t.Errorf("unexpected top IPs/paths: %v / %v", report.TopIPs, report.TopPaths)
+	}
+}
+
+// oldLog is a router log from well before any test runs, for checking that
+// file input isn't pruned against the wall clock
+var oldLog = strings.Join([]string{
+	`2024-01-15T10:20:00.000000+00:00 heroku[router]: host=a.com fwd="1.1.1.1" path="/x" status=200 service=10ms`,
+	`2024-01-15T10:30:00.000000+00:00 heroku[router]: host=a.com fwd="1.1.1.1" path="/x" status=200 service=20ms`,
+	`2024-01-15T10:31:00.000000+00:00 heroku[router]: host=b.com fwd="2.2.2.2" path="/y" status=404 service=30ms`,
+	`2024-01-15T10:33:00.000000+00:00 heroku[router]: host=b.com fwd="3.3.3.3" path="/y" status=502 service=40ms`,
+}, "\n")
+
+// openOldLog writes oldLog to a file and returns it opened along with a
+// store set up as main sets one up for that file
+func openOldLog(t *testing.T, window time.Duration) (*os.File, *store.Store) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "router.log")
+	if err := os.WriteFile(path, []byte(oldLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	s := store.New(window)
+	s.SetLogClock(isRegularFile(f))
+	return f, s
+}
+
+func TestWriteJSONReport_OldLogFile(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+	if err := ingest(f, parser.TimeSlice{}, s.Add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	if err := writeJSONReport(&b, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal([]byte(b.String()), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+
+	// The 10:20 line is more than 10m before the newest (10:33)
+	if report.Stats.Total != 3 {
+		t.Errorf("expected the 3 entries within 10m of the last line, got %d", report.Stats.Total)
+	}
+	if report.StatusCounts["200"] != 1 || report.StatusCounts["502"] != 1 {
+		t.Errorf("unexpected status counts: %v", report.StatusCounts)
+	}
+}
+
+func TestFileInput_OldLog(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+	if err := ingest(f, parser.TimeSlice{}, s.Add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The UI prunes on every tick
+	s.Prune()
+	if s.TotalCount != 3 || s.HostCounts["b.com"] != 2 {
+		t.Errorf("expected the last 10m of the file to stay, got %d entries", s.TotalCount)
+	}
+	if rate := s.GetCurrentRate(5 * time.Minute); rate == 0 {
+		t.Error("expected a rate measured from the last line, got 0")
+	}
+
+	// A fifo is a live stream, measured against the wall clock
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isRegularFile(r) {
+		t.Error("expected a pipe not to count as a regular file")
+	}
+}
+
+func TestSummary_OldLog(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+	if err := ingest(f, parser.TimeSlice{}, s.Add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Prune()
+
+	out := s.Summary()
+	if !strings.Contains(out, "Requests:  3 ") {
+		t.Errorf("expected the last 10m of the file in the summary, got:\n%s", out)
+	}
+	if !strings.Contains(out, "4xx 33.3% | 5xx 33.3%") {
+		t.Errorf("expected error rates over the same entries, got:\n%s", out)
+	}
+}
+
+func TestRunHeadless_JSONOldLog(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+
+	var b strings.Builder
+	if err := runHeadless(&b, s, f, parser.TimeSlice{}, "", 0, writeJSONReport); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal([]byte(b.String()), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", b.String(), err)
+	}
+
+	if report.Stats.Total != 3 {
+		t.Errorf("expected the 3 entries within 10m of the last line, got %d", report.Stats.Total)
+	}
+	// 3 requests from 10:30 to 10:33
+	if report.Stats.ReqPerSec != 3.0/180 {
+		t.Errorf("expected req/s over the span of the log, got %v", report.Stats.ReqPerSec)
+	}
+	if len(report.TopHosts) != 2 || report.TopHosts[0] != (jsonCount{Label: "b.com", Count: 2}) {
+		t.Errorf("unexpected top hosts: %v", report.TopHosts)
+	}
+}
+
+func TestWriteJSONReport_EmptyListsNotNull(t *testing.T) {
+	var b strings.Builder
+	if err := writeJSONReport(&b, store.New(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(b.String(), `"top_hosts":[]`) {
+		t.Errorf("expected empty top_hosts list, got %s", b.String())
+	}
+}
+
+func TestWriteWatchReport(t *testing.T) {
+	s := store.New(0)
+	now := time.Now()
+	for i := range 6 {
+		s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: fmt.Sprintf("h%d.com", i), Service: 10})
+	}
+	s.Add(&parser.Entry{Timestamp: now, Status: 500, Host: "h0.com", Service: 30})
+
+	var b strings.Builder
+	if err := writeWatchReport(&b, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	if !strings.HasPrefix(out, "--- hstat ") {
+		t.Errorf("expected timestamp header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "7 req") || !strings.Contains(out, "200: 6  500: 1") {
+		t.Errorf("expected totals and status counts, got:\n%s", out)
+	}
+	if !strings.Contains(out, "h0.com         2  4xx   0.0%  5xx  50.0%") {
+		t.Errorf("expected h0.com with its 5xx rate, got:\n%s", out)
+	}
+	// Header, status line, status counts, 5 hosts
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3+watchTopN {
+		t.Errorf("expected %d lines, got %d:\n%s", 3+watchTopN, len(lines), out)
+	}
+}
+
+func TestRunHeadless_WatchOldLog(t *testing.T) {
+	f, s := openOldLog(t, 10*time.Minute)
+
+	var b strings.Builder
+	if err := runHeadless(&b, s, f, parser.TimeSlice{}, "", time.Hour, writeWatchReport); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	if !strings.Contains(out, "3 req (0.0/s) | 4xx 33.3% | 5xx 33.3%") {
+		t.Errorf("expected the last 10m of the file, got:\n%s", out)
+	}
+	if !strings.Contains(out, "b.com         2  4xx  50.0%  5xx  50.0%") {
+		t.Errorf("expected b.com with its error rates, got:\n%s", out)
+	}
+}
+
+func TestRunHeadless_InterruptReports(t *testing.T) {
+	// Catch SIGINT here too, so it can't kill the test binary before
+	// runHeadless is listening
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+	defer signal.Stop(sigChan)
+
+	// A pipe that never ends, like a live stream
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	fmt.Fprintln(w, `2024-01-15T10:30:00.000000+00:00 heroku[router]: host=a.com fwd="1.1.1.1" status=200 service=10ms`)
+
+	s := store.New(0)
+	var b strings.Builder
+	errc := make(chan error, 1)
+	go func() {
+		errc <- runHeadless(&b, s, r, parser.TimeSlice{}, "", 0, writeJSONReport)
+	}()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-errc:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(b.String(), `"total":1`) {
+				t.Errorf("expected a final report of the entry read, got %q", b.String())
+			}
+			return
+		case <-ticker.C:
+			// Interrupt once the line is in
+			if s.GetStats().TotalCount == 1 {
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+			}
+		case <-timeout:
+			t.Fatal("runHeadless didn't return on SIGINT")
+		}
+	}
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	for in, want := range map[string]float64{"1x": 1, "4x": 4, "0.5x": 0.5, "2": 2} {
+		if got, err := parseReplaySpeed(in); err != nil || got != want {
+			t.Errorf("parseReplaySpeed(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "x", "0x", "-2x", "fast"} {
+		if _, err := parseReplaySpeed(in); err == nil {
+			t.Errorf("parseReplaySpeed(%q): expected error", in)
+		}
+	}
+}
+
+func TestReplayPacer(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	r := &replayPacer{
+		speed: 4,
+		now:   func() time.Time { return clock },
+		sleep: func(d time.Duration) {
+			slept = append(slept, d)
+			clock = clock.Add(d)
+		},
+	}
+
+	logged := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	var played []time.Time
+	for _, offset := range []time.Duration{0, 4 * time.Second, 2 * time.Second, 12 * time.Second} {
+		e := &parser.Entry{Timestamp: logged.Add(offset)}
+		r.pace(e)
+		played = append(played, e.Timestamp)
+	}
+
+	// 4s and 12s of log time at 4x; the out-of-order line doesn't wait
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(slept, want) {
+		t.Errorf("expected sleeps %v, got %v", want, slept)
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := []time.Time{start, start.Add(time.Second), start.Add(500 * time.Millisecond), start.Add(3 * time.Second)}
+	if !slices.EqualFunc(played, want, time.Time.Equal) {
+		t.Errorf("expected rebased timestamps %v, got %v", want, played)
+	}
+}
+
+func TestWriteSummaryFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com"})
+
+	at := time.Unix(1700000000, 0)
+	path, err := writeSummaryFile(s, at)
+	if err != nil {
+		t.Fatalf("writeSummaryFile: %v", err)
+	}
+	if path != "hstat-summary-1700000000.txt" {
+		t.Errorf("unexpected path %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), at.Format(time.RFC3339)+"\nhstat summary\n") || !strings.Contains(string(data), "a.com") {
+		t.Errorf("unexpected summary file:\n%s", data)
+	}
+}
+
+func TestSaveLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hstat.state")
+
+	// No file yet on the first run
+	if err := loadState(path, store.New(0)); err != nil {
+		t.Fatalf("expected missing state file to be ignored, got %v", err)
+	}
+
+	src := store.New(0)
+	src.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com"})
+	if err := saveState(path, src); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+
+	dst := store.New(0)
+	if err := loadState(path, dst); err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if dst.TotalCount != 1 {
+		t.Errorf("expected 1 restored entry, got %d", dst.TotalCount)
+	}
+}
+
+func TestEntryBatcher(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	b := newEntryBatcher(func(batch []*parser.Entry) {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(batch))
+	})
+
+	// A full batch goes out straight away
+	for i := 0; i < maxBatchSize+3; i++ {
+		b.add(&parser.Entry{Status: 200})
+	}
+	mu.Lock()
+	got := slices.Clone(sizes)
+	mu.Unlock()
+	if !slices.Equal(got, []int{maxBatchSize}) {
+		t.Fatalf("expected one full batch sent, got %v", got)
+	}
+
+	// The remainder follows within maxBatchDelay
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		got = slices.Clone(sizes)
+		mu.Unlock()
+		if len(got) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !slices.Equal(got, []int{maxBatchSize, 3}) {
+		t.Errorf("expected the remainder sent after the delay, got %v", got)
+	}
+
+	// Nothing queued, nothing sent
+	b.flush()
+	if len(sizes) != 2 {
+		t.Errorf("expected an empty flush to send nothing, got %v", sizes)
+	}
+}
+
+func TestGunzip_MultiMember(t *testing.T) {
+	// cat a.gz b.gz: two complete gzip members back to back
+	var buf bytes.Buffer
+	for _, line := range []string{
+		`heroku[router]: host=a.com fwd="1.1.1.1" status=200 service=10ms`,
+		`heroku[router]: host=b.com fwd="2.2.2.2" status=503 service=10ms`,
+	} {
+		zw := gzip.NewWriter(&buf)
+		fmt.Fprintln(zw, line)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zr, err := gunzip(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer zr.Close()
+
+	s := store.New(0)
+	ingest(zr, parser.TimeSlice{}, s.Add)
+	if s.TotalCount != 2 || s.HostCounts["b.com"] != 1 {
+		t.Errorf("expected both members read, got %d entries", s.TotalCount)
+	}
+
+	if _, err := gunzip(strings.NewReader("plain text")); err == nil {
+		t.Error("expected an error for input that isn't gzip")
+	}
+}
@@ -0,0 +1,82 @@
+// Package metrics exposes store aggregates in the Prometheus text
+// exposition format, so hstat can double as a log-derived exporter.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/betternow/hstat/store"
+)
+
+// rateWindow is how far back the req/s gauge looks, matching the UI header
+const rateWindow = 10 * time.Second
+
+// Handler serves the store's current stats at any path; mount it on
+// /metrics
+func Handler(s *store.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w, s)
+	})
+}
+
+// Write writes the store's stats as Prometheus metrics. Gauges cover the
+// current window; hstat_responses_total counts every entry since start.
+func Write(w io.Writer, s *store.Store) error {
+	s.Prune()
+	stats := s.GetStats()
+	rate4xx, rate5xx := s.GetErrorRates()
+
+	ew := &errWriter{w: w}
+
+	ew.metric("hstat_requests", "gauge", "Requests in the current window.")
+	ew.printf("hstat_requests %d\n", stats.TotalCount)
+
+	ew.metric("hstat_requests_per_second", "gauge", "Request rate over the last 10 seconds.")
+	ew.printf("hstat_requests_per_second %g\n", s.GetCurrentRate(rateWindow))
+
+	ew.metric("hstat_service_time_milliseconds", "gauge", "Router service time percentiles in the current window.")
+	for _, q := range []struct {
+		label string
+		value int
+	}{
+		{"0.5", stats.P50Service},
+		{"0.95", stats.P95Service},
+		{"0.99", stats.P99Service},
+	} {
+		ew.printf("hstat_service_time_milliseconds{quantile=%q} %d\n", q.label, q.value)
+	}
+
+	ew.metric("hstat_error_ratio", "gauge", "Share of requests in the current window with a 4xx or 5xx status (0-1).")
+	ew.printf("hstat_error_ratio{class=\"4xx\"} %g\n", rate4xx/100)
+	ew.printf("hstat_error_ratio{class=\"5xx\"} %g\n", rate5xx/100)
+
+	ew.metric("hstat_responses_total", "counter", "Responses seen since start, by status code.")
+	for _, sc := range s.GetStatusTotals() {
+		ew.printf("hstat_responses_total{status=\"%d\"} %d\n", sc.Status, sc.Count)
+	}
+
+	return ew.err
+}
+
+// errWriter keeps the first write error so the metric lines can be
+// written without checking each one
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
+
+// metric writes the HELP and TYPE lines that precede a metric's samples
+func (ew *errWriter) metric(name, typ, help string) {
+	ew.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+	"github.com/betternow/hstat/store"
+)
+
+func TestHandler(t *testing.T) {
+	s := store.New(time.Minute)
+	now := time.Now()
+	s.Add(&parser.Entry{Timestamp: now.Add(-2 * time.Minute), Status: 200, Service: 10})
+	for _, status := range []int{200, 200, 404, 500} {
+		s.Add(&parser.Entry{Timestamp: now, Status: status, Service: 20})
+	}
+
+	srv := httptest.NewServer(Handler(s))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	out := string(body)
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	for _, want := range []string{
+		"# TYPE hstat_requests gauge\nhstat_requests 4\n",
+		`hstat_service_time_milliseconds{quantile="0.95"} 20`,
+		`hstat_error_ratio{class="4xx"} 0.25`,
+		`hstat_error_ratio{class="5xx"} 0.25`,
+		"# TYPE hstat_responses_total counter\n",
+		// The pruned entry still counts towards the total
+		`hstat_responses_total{status="200"} 3`,
+		`hstat_responses_total{status="500"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a log format Parse and LogTime understand
+type Format struct {
+	parse   func(line string) *Entry
+	logTime func(line string) (time.Time, bool)
+}
+
+// Heroku is the default format: heroku[router] lines
+var Heroku = Format{parse: parseHeroku, logTime: leadingTime}
+
+// format is the active format, set once at startup before parsing begins
+var format = Heroku
+
+// SetFormat selects the format Parse uses
+func SetFormat(f Format) {
+	format = f
+}
+
+// FormatFields are the named capture groups a custom regex format can use.
+// status is required; the rest are optional.
+var FormatFields = []string{"status", "service", "host", "path", "ip", "bytes", "method", "ts"}
+
+// nginxCombined matches nginx's combined log format, optionally followed
+// by $request_time (seconds) as is commonly appended
+const nginxCombined = `^(?P<ip>\S+) \S+ \S+ \[(?P<ts>[^\]]+)\] "(?P<method>[A-Z]+) (?P<path>[^ "]+)[^"]*" (?P<status>\d{3}) (?P<bytes>\d+|-)(?: "[^"]*" "[^"]*")?(?: (?P<service>\d+(?:\.\d+)?))?`
+
+// ParseFormat returns a format by name (heroku, nginx-combined, logfmt),
+// or treats spec as a regex whose named groups are FormatFields
+func ParseFormat(spec string) (Format, error) {
+	switch spec {
+	case "", "heroku":
+		return Heroku, nil
+	case "nginx-combined":
+		return regexFormat(nginxCombined)
+	case "logfmt":
+		return fieldsFormat(logfmtFields), nil
+	}
+	return regexFormat(spec)
+}
+
+// fieldsFormat builds a format from a function that extracts FormatFields
+// values from a line (nil if it doesn't match)
+func fieldsFormat(fields func(line string) map[string]string) Format {
+	return Format{
+		parse: func(line string) *Entry {
+			return entryFromFields(fields(line))
+		},
+		logTime: func(line string) (time.Time, bool) {
+			return parseTimestamp(fields(line)["ts"])
+		},
+	}
+}
+
+// regexFormat builds a format from a regex with named capture groups
+func regexFormat(expr string) (Format, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Format{}, fmt.Errorf("invalid format regex: %v", err)
+	}
+	names := re.SubexpNames()
+	if !slices.Contains(names, "status") {
+		return Format{}, fmt.Errorf("format regex needs a (?P<status>...) group")
+	}
+	for _, n := range names {
+		if n != "" && !slices.Contains(FormatFields, n) {
+			return Format{}, fmt.Errorf("unknown format group %q (want %s)", n, strings.Join(FormatFields, ", "))
+		}
+	}
+
+	return fieldsFormat(func(line string) map[string]string {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		fields := make(map[string]string, len(names))
+		for i, n := range names {
+			if n != "" && m[i] != "" {
+				fields[n] = m[i]
+			}
+		}
+		return fields
+	}), nil
+}
+
+// logfmtAliases maps common logfmt keys onto FormatFields
+var logfmtAliases = map[string]string{
+	"status":      "status",
+	"status_code": "status",
+	"service":     "service",
+	"duration":    "service",
+	"elapsed":     "service",
+	"host":        "host",
+	"path":        "path",
+	"uri":         "path",
+	"ip":          "ip",
+	"remote_addr": "ip",
+	"fwd":         "ip",
+	"bytes":       "bytes",
+	"size":        "bytes",
+	"method":      "method",
+	"ts":          "ts",
+	"time":        "ts",
+}
+
+// logfmtFields reads key=value pairs (values optionally double-quoted)
+func logfmtFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for rest := line; rest != ""; {
+		rest = strings.TrimLeft(rest, " ")
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		// A key is the last word before "="
+		key = key[strings.LastIndex(key, " ")+1:]
+
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				value, rest = after[1:], ""
+			} else {
+				value, rest = after[1:end+1], after[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(after, " ")
+		}
+
+		if field, ok := logfmtAliases[key]; ok && value != "" {
+			if _, seen := fields[field]; !seen {
+				fields[field] = value
+			}
+		}
+	}
+	return fields
+}
+
+// entryFromFields builds an Entry from FormatFields values. Returns nil
+// without a numeric status.
+func entryFromFields(fields map[string]string) *Entry {
+	status, err := strconv.Atoi(fields["status"])
+	if err != nil {
+		return nil
+	}
+
+	entry := &Entry{
+		Timestamp: time.Now(),
+		Status:    status,
+		Service:   parseMillis(fields["service"]),
+		Method:    fields["method"],
+		Host:      fields["host"],
+	}
+	if t, ok := parseTimestamp(fields["ts"]); ok {
+		entry.Timestamp = t
+	}
+	entry.Bytes, _ = strconv.Atoi(fields["bytes"])
+	if path, _, _ := strings.Cut(fields["path"], "?"); path != "" {
+		entry.Path = path
+	}
+	if ip := fields["ip"]; ip != "" {
+		entry.setIPChain(ip)
+	}
+	return entry
+}
+
+// parseMillis reads a duration as milliseconds: "12ms" and "1.5s" by
+// unit, a bare integer as ms, and a bare decimal (nginx $request_time)
+// as seconds
+func parseMillis(s string) int {
+	if s == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return int(d.Milliseconds())
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int(f*1000 + 0.5)
+	}
+	return 0
+}
+
+// timestampLayouts are the ts layouts tried in order
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"02/Jan/2006:15:04:05 -0700", // nginx $time_local
+	"2006-01-02 15:04:05",
+}
+
+// parseTimestamp reads a ts field in one of timestampLayouts or as Unix
+// seconds
+func parseTimestamp(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// useFormat selects a format for the rest of a test
+func useFormat(t *testing.T, spec string) {
+	t.Helper()
+	f, err := ParseFormat(spec)
+	if err != nil {
+		t.Fatalf("ParseFormat(%q): %v", spec, err)
+	}
+	SetFormat(f)
+	t.Cleanup(func() { SetFormat(Heroku) })
+}
+
+func TestParseFormat_NginxCombined(t *testing.T) {
+	useFormat(t, "nginx-combined")
+
+	line := `203.0.113.7 - - [15/Jan/2024:10:30:00 +0000] "GET /api/users?page=2 HTTP/1.1" 404 512 "-" "curl/8.0" 0.125`
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+	want := Entry{
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Status:    404,
+		Service:   125,
+		Method:    "GET",
+		Path:      "/api/users",
+		IP:        "203.0.113.7",
+		Bytes:     512,
+	}
+	if !entry.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("expected timestamp %v, got %v", want.Timestamp, entry.Timestamp)
+	}
+	entry.Timestamp = want.Timestamp
+	want.IPChain = []string{"203.0.113.7"}
+	if !reflect.DeepEqual(*entry, want) {
+		t.Errorf("expected %+v, got %+v", want, *entry)
+	}
+
+	// LogTime follows the format, so -from/-to work on nginx logs
+	if ts, ok := LogTime(line); !ok || !ts.Equal(want.Timestamp) {
+		t.Errorf("expected LogTime %v, got %v (%v)", want.Timestamp, ts, ok)
+	}
+
+	// Without $request_time, and with a "-" size
+	entry = Parse(`10.0.0.1 - bob [15/Jan/2024:10:30:00 +0000] "POST /login HTTP/1.1" 302 - "-" "Mozilla/5.0"`)
+	if entry == nil || entry.Status != 302 || entry.Service != 0 || entry.Bytes != 0 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if Parse(`2024-01-15T10:30:00Z heroku[router]: status=200`) != nil {
+		t.Error("expected a router line not to match nginx-combined")
+	}
+}
+
+func TestParseFormat_Logfmt(t *testing.T) {
+	useFormat(t, "logfmt")
+
+	line := `ts=2024-01-15T10:30:00Z level=info method=POST path="/orders?x=1" host=shop.example status=201 duration=42ms remote_addr="1.2.3.4, 10.0.0.1" bytes=88`
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+	if entry.Status != 201 || entry.Service != 42 || entry.Method != "POST" || entry.Host != "shop.example" ||
+		entry.Path != "/orders" || entry.IP != "1.2.3.4" || entry.Bytes != 88 {
+		t.Errorf("unexpected entry %+v", *entry)
+	}
+	if want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC); !entry.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, entry.Timestamp)
+	}
+
+	if Parse(`level=info msg="no status here"`) != nil {
+		t.Error("expected nil without a status")
+	}
+}
+
+func TestParseFormat_CustomRegex(t *testing.T) {
+	useFormat(t, `^(?P<method>\S+) (?P<path>\S+) -> (?P<status>\d+) in (?P<service>\d+)ms$`)
+
+	entry := Parse("GET /health -> 503 in 7ms")
+	if entry == nil || entry.Method != "GET" || entry.Path != "/health" || entry.Status != 503 || entry.Service != 7 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if Parse("garbage") != nil {
+		t.Error("expected nil for a non-matching line")
+	}
+}
+
+func TestParseFormat_Errors(t *testing.T) {
+	for _, spec := range []string{
+		`(?P<status>\d+`,                // invalid regex
+		`(?P<service>\d+)ms`,            // no status group
+		`(?P<status>\d+) (?P<user>\S+)`, // unknown group
+	} {
+		if _, err := ParseFormat(spec); err == nil {
+			t.Errorf("ParseFormat(%q): expected error", spec)
+		}
+	}
+}
+
+func TestParseMillis(t *testing.T) {
+	for in, want := range map[string]int{"": 0, "12": 12, "12ms": 12, "1.5s": 1500, "0.125": 125, "junk": 0} {
+		if got := parseMillis(in); got != want {
+			t.Errorf("parseMillis(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -13,24 +13,72 @@ type Entry struct {
 	Status    int
 	Service   int // ms
 	Connect   int // ms
+	Method    string
 	Host      string
 	Path      string
-	IP        string // first from fwd chain
+	IP        string   // first from fwd chain
+	IPChain   []string // whole fwd chain, client first as sent by proxies
+	Bytes     int      // response body size
+	TLS       string   // TLS version, empty if the line has no tls= field
+	Code      string   // Heroku error code (e.g. H12), only on at=error lines
+	Desc      string   // description for Code (e.g. "Request timeout")
+	RequestID string
+
+	// Malformed marks a router line too truncated to trust, e.g. status=5
+	// or a cut-off service=. Only the timestamp is meaningful.
+	Malformed bool
 }
 
 var (
 	statusRe  = regexp.MustCompile(`status=(\d+)`)
 	serviceRe = regexp.MustCompile(`service=(\d+)ms`)
 	connectRe = regexp.MustCompile(`connect=(\d+)ms`)
+	bytesRe   = regexp.MustCompile(`bytes=(\d+)`)
+	methodRe  = regexp.MustCompile(`(?:^|\s)method=([^\s]+)`)
 	hostRe    = regexp.MustCompile(`host=([^\s]+)`)
 	pathRe    = regexp.MustCompile(`path="([^"]*)"`)
 	fwdRe     = regexp.MustCompile(`fwd="([^"]*)"`)     // quoted, possibly empty
 	fwdAltRe  = regexp.MustCompile(`fwd=([0-9][^\s]*)`) // unquoted IP
+	requestRe = regexp.MustCompile(`request_id=([^\s]+)`)
+	tlsRe     = regexp.MustCompile(`(?:^|\s)tls=([^\s]+)`)
+	codeRe    = regexp.MustCompile(`(?:^|\s)code=([^\s]+)`)
+	descRe    = regexp.MustCompile(`desc="([^"]*)"`)
 )
 
-// Parse parses a Heroku router log line into an Entry.
-// Returns nil if the line is not a valid router log.
+// LastIP returns the last hop of the forwarded chain, which is the client
+// in setups where a trusted proxy appends rather than prepends. Empty
+// without a chain.
+func (e *Entry) LastIP() string {
+	if len(e.IPChain) == 0 {
+		return ""
+	}
+	return e.IPChain[len(e.IPChain)-1]
+}
+
+// setIPChain splits a forwarded-for list (e.g. "1.2.3.4, 5.6.7.8") into
+// IPChain and sets IP to its first address
+func (e *Entry) setIPChain(fwd string) {
+	e.IPChain = e.IPChain[:0]
+	for _, ip := range strings.Split(fwd, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			e.IPChain = append(e.IPChain, ip)
+		}
+	}
+	if len(e.IPChain) > 0 {
+		e.IP = e.IPChain[0]
+	}
+}
+
+// Parse parses a log line into an Entry using the format chosen with
+// SetFormat (Heroku router logs by default). Returns nil if the line
+// doesn't match.
 func Parse(line string) *Entry {
+	return format.parse(line)
+}
+
+// parseHeroku parses a Heroku router log line into an Entry.
+// Returns nil if the line is not a valid router log.
+func parseHeroku(line string) *Entry {
 	// Must be a router log line (contains "heroku[router]")
 	if !strings.Contains(line, "heroku[router]") {
 		return nil
@@ -44,19 +92,41 @@ func Parse(line string) *Entry {
 
 	status, _ := strconv.Atoi(statusMatch[1])
 
+	// Use the line's own timestamp so buffered or replayed logs land in
+	// the right place; fall back to arrival time without one
+	timestamp, ok := leadingTime(line)
+	if !ok {
+		timestamp = time.Now()
+	}
+
+	// Logplex can truncate lines mid-field, leaving status=5 or service=12
+	if status < 100 || status > 599 {
+		return &Entry{Timestamp: timestamp, Malformed: true}
+	}
+
 	entry := &Entry{
-		Timestamp: time.Now(),
+		Timestamp: timestamp,
 		Status:    status,
 	}
 
 	if m := serviceRe.FindStringSubmatch(line); m != nil {
 		entry.Service, _ = strconv.Atoi(m[1])
+	} else if strings.Contains(line, "service=") {
+		return &Entry{Timestamp: timestamp, Malformed: true}
 	}
 
 	if m := connectRe.FindStringSubmatch(line); m != nil {
 		entry.Connect, _ = strconv.Atoi(m[1])
 	}
 
+	if m := bytesRe.FindStringSubmatch(line); m != nil {
+		entry.Bytes, _ = strconv.Atoi(m[1])
+	}
+
+	if m := methodRe.FindStringSubmatch(line); m != nil {
+		entry.Method = m[1]
+	}
+
 	if m := hostRe.FindStringSubmatch(line); m != nil {
 		entry.Host = m[1]
 	}
@@ -71,13 +141,27 @@ func Parse(line string) *Entry {
 	}
 
 	if m := fwdRe.FindStringSubmatch(line); m != nil && m[1] != "" {
-		// Take first IP from chain (e.g., "1.2.3.4, 5.6.7.8" -> "1.2.3.4")
-		entry.IP = strings.Split(m[1], ",")[0]
-		entry.IP = strings.TrimSpace(entry.IP)
+		entry.setIPChain(m[1])
 	} else if m := fwdAltRe.FindStringSubmatch(line); m != nil {
 		// Try unquoted format
-		entry.IP = strings.Split(m[1], ",")[0]
-		entry.IP = strings.TrimSpace(entry.IP)
+		entry.setIPChain(m[1])
+	}
+
+	if m := requestRe.FindStringSubmatch(line); m != nil {
+		entry.RequestID = m[1]
+	}
+
+	// Optional, only present in extended router logs
+	if m := tlsRe.FindStringSubmatch(line); m != nil {
+		entry.TLS = m[1]
+	}
+
+	// Router errors carry code=H12 desc="Request timeout"
+	if m := codeRe.FindStringSubmatch(line); m != nil {
+		entry.Code = m[1]
+		if m := descRe.FindStringSubmatch(line); m != nil {
+			entry.Desc = m[1]
+		}
 	}
 
 	return entry
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"testing"
+	"time"
 )
 
 func TestParse_ValidRouterLog(t *testing.T) {
@@ -27,6 +28,41 @@ func TestParse_ValidRouterLog(t *testing.T) {
 	if entry.IP != "1.2.3.4" {
 		t.Errorf("expected IP 1.2.3.4, got %s", entry.IP)
 	}
+	if entry.Method != "GET" {
+		t.Errorf("expected method GET, got %s", entry.Method)
+	}
+	if entry.Bytes != 1234 {
+		t.Errorf("expected bytes 1234, got %d", entry.Bytes)
+	}
+	if entry.RequestID != "abc123" {
+		t.Errorf("expected request_id abc123, got %s", entry.RequestID)
+	}
+}
+
+func TestParse_Timestamp(t *testing.T) {
+	line := `2024-01-15T10:30:00.123456+00:00 heroku[router]: at=info method=GET path="/" host=example.com status=200 service=10ms`
+
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	want := time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC)
+	if !entry.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, entry.Timestamp)
+	}
+}
+
+func TestParse_TimestampFallback(t *testing.T) {
+	before := time.Now()
+	entry := Parse(`heroku[router]: status=200`)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	if entry.Timestamp.Before(before) || entry.Timestamp.After(time.Now()) {
+		t.Errorf("expected arrival time for line without timestamp, got %v", entry.Timestamp)
+	}
 }
 
 func TestParse_MultipleIPsInFwd(t *testing.T) {
@@ -41,6 +77,11 @@ func TestParse_MultipleIPsInFwd(t *testing.T) {
 	if entry.IP != "1.2.3.4" {
 		t.Errorf("expected IP 1.2.3.4, got %s", entry.IP)
 	}
+
+	// ...and keep the whole chain
+	if len(entry.IPChain) != 2 || entry.IPChain[1] != "5.6.7.8" || entry.LastIP() != "5.6.7.8" {
+		t.Errorf("expected chain [1.2.3.4 5.6.7.8], got %v (last %q)", entry.IPChain, entry.LastIP())
+	}
 }
 
 func TestParse_UnquotedFwd(t *testing.T) {
@@ -67,6 +108,9 @@ func TestParse_EmptyFwd(t *testing.T) {
 	if entry.IP != "" {
 		t.Errorf("expected empty IP, got %s", entry.IP)
 	}
+	if len(entry.IPChain) != 0 || entry.LastIP() != "" {
+		t.Errorf("expected no chain, got %v", entry.IPChain)
+	}
 }
 
 func TestParse_NonRouterLog(t *testing.T) {
@@ -94,6 +138,30 @@ func TestParse_NoStatus(t *testing.T) {
 	}
 }
 
+func TestParse_TruncatedLinesMalformed(t *testing.T) {
+	lines := []string{
+		`2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com status=5`,
+		`2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com status=200 connect=1ms service=1`,
+		`2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com status=200 connect=1ms service=`,
+	}
+
+	for _, line := range lines {
+		entry := Parse(line)
+		if entry == nil || !entry.Malformed {
+			t.Errorf("expected a malformed entry for %q, got %+v", line, entry)
+			continue
+		}
+		if entry.Timestamp.Hour() != 10 {
+			t.Errorf("expected the line's timestamp kept, got %v", entry.Timestamp)
+		}
+	}
+
+	entry := Parse(`heroku[router]: host=example.com status=200 service=25ms`)
+	if entry == nil || entry.Malformed {
+		t.Errorf("expected a complete line not to be malformed, got %+v", entry)
+	}
+}
+
 func TestParse_VariousStatusCodes(t *testing.T) {
 	tests := []struct {
 		status int
@@ -158,6 +226,9 @@ func TestParse_MissingOptionalFields(t *testing.T) {
 	if entry.IP != "" {
 		t.Errorf("expected empty IP, got %s", entry.IP)
 	}
+	if entry.Method != "" {
+		t.Errorf("expected empty method, got %s", entry.Method)
+	}
 }
 
 func TestParse_Path(t *testing.T) {
@@ -212,3 +283,58 @@ func TestParse_PathMissing(t *testing.T) {
 		t.Errorf("expected empty path, got %s", entry.Path)
 	}
 }
+
+func TestParse_TLSVersion(t *testing.T) {
+	line := `2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com fwd="1.2.3.4" status=200 service=10ms connect=1ms tls=1.2 protocol=https`
+
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	if entry.TLS != "1.2" {
+		t.Errorf("expected TLS 1.2, got %q", entry.TLS)
+	}
+}
+
+func TestParse_ErrorCode(t *testing.T) {
+	line := `2024-01-15T10:30:00.000000+00:00 heroku[router]: at=error code=H12 desc="Request timeout" method=GET path="/slow" host=example.com fwd="1.2.3.4" dyno=web.1 connect=1ms service=30000ms status=503 bytes=0 protocol=https`
+
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	if entry.Code != "H12" {
+		t.Errorf("expected code H12, got %q", entry.Code)
+	}
+	if entry.Desc != "Request timeout" {
+		t.Errorf("expected desc Request timeout, got %q", entry.Desc)
+	}
+}
+
+func TestParse_ErrorCodeMissing(t *testing.T) {
+	line := `2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com status=200 service=10ms`
+
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	if entry.Code != "" || entry.Desc != "" {
+		t.Errorf("expected no code/desc, got %q/%q", entry.Code, entry.Desc)
+	}
+}
+
+func TestParse_TLSVersionMissing(t *testing.T) {
+	line := `2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com fwd="1.2.3.4" status=200 service=10ms connect=1ms mtls=on`
+
+	entry := Parse(line)
+	if entry == nil {
+		t.Fatal("expected entry, got nil")
+	}
+
+	if entry.TLS != "" {
+		t.Errorf("expected empty TLS when field absent, got %q", entry.TLS)
+	}
+}
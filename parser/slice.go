@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeSlice bounds ingest to a wall-clock window of the day (e.g. 10:29
+// to 10:35), compared against each log line's timestamp (see LogTime).
+// Bounds are offsets since midnight in the log's own timezone.
+type TimeSlice struct {
+	From    time.Duration
+	To      time.Duration
+	HasFrom bool
+	HasTo   bool
+}
+
+// ParseTimeSlice parses --from/--to flag values ("HH:MM" or "HH:MM:SS").
+// An empty value leaves that side of the slice open.
+func ParseTimeSlice(from, to string) (TimeSlice, error) {
+	var ts TimeSlice
+	var err error
+	if from != "" {
+		if ts.From, err = parseClock(from); err != nil {
+			return TimeSlice{}, err
+		}
+		ts.HasFrom = true
+	}
+	if to != "" {
+		if ts.To, err = parseClock(to); err != nil {
+			return TimeSlice{}, err
+		}
+		ts.HasTo = true
+	}
+	if ts.HasFrom && ts.HasTo && ts.To < ts.From {
+		return TimeSlice{}, fmt.Errorf("--to %s is before --from %s", to, from)
+	}
+	return ts, nil
+}
+
+// parseClock parses a wall-clock time into an offset since midnight
+func parseClock(s string) (time.Duration, error) {
+	layout := "15:04"
+	if strings.Count(s, ":") == 2 {
+		layout = "15:04:05"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM or HH:MM:SS)", s)
+	}
+	return sinceMidnight(t), nil
+}
+
+// Active reports whether either bound is set
+func (ts TimeSlice) Active() bool {
+	return ts.HasFrom || ts.HasTo
+}
+
+// Contains reports whether a log line falls inside the slice. Both bounds
+// are inclusive. Lines without a timestamp can't be placed, so
+// they are only kept when the slice is open on both sides.
+func (ts TimeSlice) Contains(line string) bool {
+	if !ts.Active() {
+		return true
+	}
+	t, ok := LogTime(line)
+	if !ok {
+		return false
+	}
+	offset := sinceMidnight(t)
+	if ts.HasFrom && offset < ts.From {
+		return false
+	}
+	if ts.HasTo && offset > ts.To {
+		return false
+	}
+	return true
+}
+
+// LogTime returns a log line's own timestamp, as read by the format
+// chosen with SetFormat
+func LogTime(line string) (time.Time, bool) {
+	return format.logTime(line)
+}
+
+// leadingTime returns the timestamp at the start of a log line
+// (e.g. "2024-01-15T10:30:00.000000+00:00 heroku[router]: ...")
+func leadingTime(line string) (time.Time, bool) {
+	field, _, _ := strings.Cut(line, " ")
+	t, err := time.Parse(time.RFC3339Nano, field)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+}
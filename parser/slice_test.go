@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeSlice(t *testing.T) {
+	ts, err := ParseTimeSlice("10:29", "10:35:30")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ts.HasFrom || ts.From != 10*time.Hour+29*time.Minute {
+		t.Errorf("expected from 10:29, got %v", ts.From)
+	}
+	if !ts.HasTo || ts.To != 10*time.Hour+35*time.Minute+30*time.Second {
+		t.Errorf("expected to 10:35:30, got %v", ts.To)
+	}
+
+	if ts, err := ParseTimeSlice("", ""); err != nil || ts.Active() {
+		t.Errorf("expected inactive slice for empty bounds, got %+v, %v", ts, err)
+	}
+	if _, err := ParseTimeSlice("10:29am", ""); err == nil {
+		t.Error("expected error for malformed time")
+	}
+	if _, err := ParseTimeSlice("10:35", "10:29"); err == nil {
+		t.Error("expected error when --to is before --from")
+	}
+}
+
+func TestTimeSlice_Contains(t *testing.T) {
+	ts, _ := ParseTimeSlice("10:29", "10:35")
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`2024-01-15T10:28:59.999999+00:00 heroku[router]: status=200`, false},
+		{`2024-01-15T10:29:00.000000+00:00 heroku[router]: status=200`, true},
+		{`2024-01-15T10:32:10.123456+00:00 heroku[router]: status=200`, true},
+		{`2024-01-15T10:35:00.000000+00:00 heroku[router]: status=200`, true},
+		{`2024-01-15T10:35:00.000001+00:00 heroku[router]: status=200`, false},
+		{`heroku[router]: status=200`, false}, // no timestamp to place it
+	}
+
+	for _, tc := range tests {
+		if got := ts.Contains(tc.line); got != tc.want {
+			t.Errorf("Contains(%q) = %v, want %v", tc.line, got, tc.want)
+		}
+	}
+
+	// An open slice keeps everything, timestamp or not
+	if !(TimeSlice{}).Contains(`heroku[router]: status=200`) {
+		t.Error("expected open slice to keep lines without a timestamp")
+	}
+}
+
+func TestLogTime(t *testing.T) {
+	got, ok := LogTime(`2024-01-15T10:30:00.000000+01:00 heroku[router]: status=200`)
+	if !ok {
+		t.Fatal("expected timestamp to parse")
+	}
+	if got.Hour() != 10 || got.Minute() != 30 {
+		t.Errorf("expected 10:30 in the log's own offset, got %v", got)
+	}
+
+	if _, ok := LogTime(`some random text`); ok {
+		t.Error("expected no timestamp for line without one")
+	}
+}
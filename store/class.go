@@ -0,0 +1,97 @@
+package store
+
+// ClassView is the window's traffic narrowed to one status class or code
+type ClassView struct {
+	Stats  Stats
+	Status []StatusCountItem
+	Hosts  []CountItem
+	IPs    []CountItem
+	Paths  []CountItem // excluded paths are left out, as in GetAllPaths
+}
+
+// GetStatusClassView scans the window for entries in a status class (4
+// for 4xx, 5 for 5xx) and returns their stats, status counts, and top n
+// hosts, IPs, and paths. Non-empty host, ip, or path narrow it further,
+// like the UI's filters. The per-label maps don't split by status, so
+// this walks the entries instead.
+func (s *Store) GetStatusClassView(class int, host, ip, path string, n int) ClassView {
+	return s.statusView(func(status int) bool { return status/100 == class }, host, ip, path, n)
+}
+
+// GetStatusView is GetStatusClassView for a single status code, e.g. 422
+func (s *Store) GetStatusView(status int, host, ip, path string, n int) ClassView {
+	return s.statusView(func(st int) bool { return st == status }, host, ip, path, n)
+}
+
+// statusView builds a ClassView from the entries whose status matches
+func (s *Store) statusView(match func(status int) bool, host, ip, path string, n int) ClassView {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int64
+	var service, connect []int
+	statuses := make(map[int]int64)
+	hosts := make(map[string]int64)
+	ips := make(map[string]int64)
+	paths := make(map[string]int64)
+
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !match(e.Status) {
+			continue
+		}
+		eHost, eIP, ePath := orUnknown(e.Host), orUnknown(e.IP), orUnknown(e.Path)
+		if (host != "" && eHost != host) || (ip != "" && eIP != ip) || (path != "" && ePath != path) {
+			continue
+		}
+
+		total++
+		statuses[e.Status]++
+		hosts[eHost]++
+		ips[eIP]++
+		if !s.isExcludedPath(ePath) {
+			paths[ePath]++
+		}
+		service = append(service, e.Service)
+		connect = append(connect, e.Connect)
+	}
+
+	return ClassView{
+		Stats:  computeStats(total, service, connect),
+		Status: statusItems(statuses),
+		Hosts:  s.topN(hosts, n),
+		IPs:    s.topN(ips, n),
+		Paths:  s.topN(paths, n),
+	}
+}
+
+// orUnknown returns "(unknown)" for an empty label, as Add counts it
+func orUnknown(label string) string {
+	if label == "" {
+		return "(unknown)"
+	}
+	return label
+}
+
+// GetStatsForStatus returns timing statistics for requests with one
+// status code, e.g. to tell fast-failing 500s from slow timeouts. 101s
+// carry no timings, as in GetStats.
+func (s *Store) GetStatsForStatus(status int) Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int64
+	var service, connect []int
+	for i := range s.entries {
+		e := &s.entries[i]
+		if e.Status != status {
+			continue
+		}
+		total++
+		if e.Status != 101 {
+			service = append(service, e.Service)
+			connect = append(connect, e.Connect)
+		}
+	}
+	return computeStats(total, service, connect)
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestGetStatusClassView(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+	add := func(status int, host, ip, path string, service int) {
+		s.Add(&parser.Entry{Timestamp: now, Status: status, Host: host, IP: ip, Path: path, Service: service})
+	}
+	add(200, "a.com", "1.1.1.1", "/", 10)
+	add(500, "a.com", "1.1.1.1", "/boom", 300)
+	add(503, "b.com", "2.2.2.2", "/boom", 100)
+	add(502, "b.com", "", "/robots.txt", 200)
+	add(404, "b.com", "2.2.2.2", "/missing", 5)
+
+	view := s.GetStatusClassView(5, "", "", "", 10)
+	if view.Stats.TotalCount != 3 || view.Stats.MaxService != 300 {
+		t.Errorf("unexpected stats: %+v", view.Stats)
+	}
+	if len(view.Status) != 3 || view.Status[0].Status != 500 {
+		t.Errorf("expected 500, 502, 503, got %v", view.Status)
+	}
+	if len(view.Hosts) != 2 || view.Hosts[0] != (CountItem{"b.com", 2}) {
+		t.Errorf("expected b.com first with 2, got %v", view.Hosts)
+	}
+	if len(view.IPs) != 3 {
+		t.Errorf("expected an (unknown) IP alongside the two others, got %v", view.IPs)
+	}
+	// /robots.txt is excluded by default
+	if len(view.Paths) != 1 || view.Paths[0] != (CountItem{"/boom", 2}) {
+		t.Errorf("expected only /boom, got %v", view.Paths)
+	}
+
+	// Narrowed further by host
+	view = s.GetStatusClassView(5, "a.com", "", "", 10)
+	if view.Stats.TotalCount != 1 || len(view.Hosts) != 1 || view.Hosts[0].Label != "a.com" {
+		t.Errorf("expected a.com's single 5xx, got %+v", view)
+	}
+
+	view = s.GetStatusClassView(4, "", "", "", 10)
+	if view.Stats.TotalCount != 1 || view.Paths[0].Label != "/missing" {
+		t.Errorf("expected the single 4xx, got %+v", view)
+	}
+}
+
+func TestGetStatusView(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 422, Host: "a.com", IP: "1.1.1.1", Path: "/signup"})
+	s.Add(&parser.Entry{Status: 422, Host: "a.com", IP: "1.1.1.1", Path: "/signup"})
+	s.Add(&parser.Entry{Status: 404, Host: "b.com", IP: "2.2.2.2", Path: "/missing"})
+
+	view := s.GetStatusView(422, "", "", "", 10)
+	if view.Stats.TotalCount != 2 || len(view.Status) != 1 || view.Status[0].Status != 422 {
+		t.Errorf("expected only the two 422s, got %+v", view)
+	}
+	if len(view.IPs) != 1 || view.IPs[0] != (CountItem{"1.1.1.1", 2}) {
+		t.Errorf("expected 1.1.1.1 behind the 422s, got %v", view.IPs)
+	}
+	if view := s.GetStatusView(422, "b.com", "", "", 10); view.Stats.TotalCount != 0 {
+		t.Errorf("expected no 422s for b.com, got %+v", view.Stats)
+	}
+}
+
+func TestGetStatsForStatus(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 500, Service: 5})
+	s.Add(&parser.Entry{Status: 500, Service: 15})
+	s.Add(&parser.Entry{Status: 503, Service: 30000})
+	s.Add(&parser.Entry{Status: 200, Service: 100})
+	s.Add(&parser.Entry{Status: 101, Service: 60000})
+
+	if stats := s.GetStatsForStatus(500); stats.TotalCount != 2 || stats.MaxService != 15 || stats.AvgService != 10 {
+		t.Errorf("expected two fast 500s, got %+v", stats)
+	}
+	if stats := s.GetStatsForStatus(503); stats.P50Service != 30000 {
+		t.Errorf("expected slow 503, got %+v", stats)
+	}
+	if stats := s.GetStatsForStatus(101); stats.TotalCount != 1 || stats.MaxService != 0 {
+		t.Errorf("expected 101 counted without timings, got %+v", stats)
+	}
+	if stats := s.GetStatsForStatus(404); stats.TotalCount != 0 {
+		t.Errorf("expected nothing for 404, got %+v", stats)
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ExportCSV writes every host, IP, and path in the window as CSV rows of
+// kind,label,count,pct,rate4xx,rate5xx, where pct is the share of all
+// requests and the rates are percentages. Paths hidden by
+// SetExcludedPaths are left out, as in GetAllPaths.
+func (s *Store) ExportCSV(w io.Writer) error {
+	total := s.GetStats().TotalCount
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "label", "count", "pct", "rate4xx", "rate5xx"})
+
+	writeRows := func(kind string, items []CountItem, rates func(string) ErrorRates) {
+		for _, item := range items {
+			r := rates(item.Label)
+			cw.Write([]string{
+				kind,
+				item.Label,
+				strconv.FormatInt(item.Count, 10),
+				formatPercent(float64(item.Count) * 100 / float64(max(total, 1))),
+				formatPercent(r.Rate4xx),
+				formatPercent(r.Rate5xx),
+			})
+		}
+	}
+	writeRows("host", s.GetTopHosts(math.MaxInt, ""), s.GetErrorRatesForHost)
+	writeRows("ip", s.GetTopIPs(math.MaxInt, ""), s.GetErrorRatesForIP)
+	writeRows("path", s.GetAllPaths(math.MaxInt), s.GetErrorRatesForPath)
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatPercent formats a percentage with two decimals for export
+func formatPercent(pct float64) string {
+	return strconv.FormatFloat(pct, 'f', 2, 64)
+}
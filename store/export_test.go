@@ -0,0 +1,32 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestExportCSV(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/"})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/"})
+	s.Add(&parser.Entry{Status: 500, Host: "a.com", IP: "1.1.1.1", Path: "/"})
+	s.Add(&parser.Entry{Status: 404, Host: "b.com", IP: "1.1.1.1", Path: "/robots.txt"})
+
+	var b strings.Builder
+	if err := s.ExportCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"kind,label,count,pct,rate4xx,rate5xx",
+		"host,a.com,3,75.00,0.00,33.33",
+		"host,b.com,1,25.00,100.00,0.00",
+		"ip,1.1.1.1,4,100.00,25.00,25.00",
+		"path,/,3,75.00,0.00,33.33", // /robots.txt is excluded by default
+	}
+	if got := strings.Split(strings.TrimSpace(b.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected CSV:\n%s", b.String())
+	}
+}
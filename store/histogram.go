@@ -0,0 +1,86 @@
+package store
+
+import "math/bits"
+
+// histogram counts millisecond timings in log-linear buckets so
+// percentiles can be read without sorting every sample. Values below
+// histExact get a bucket each; above that, each power of two is split
+// into histSubBuckets buckets, keeping the relative error under 1/64
+// (about 1.6%). Samples can be removed again, so it follows the window.
+type histogram struct {
+	counts []int64
+	total  int64
+	sum    int64
+}
+
+const (
+	histExact      = 128
+	histSubBuckets = 64
+)
+
+// histBucket returns the bucket index for a value
+func histBucket(v int) int {
+	if v < histExact {
+		return max(v, 0)
+	}
+	// Shift so the value lands in [histSubBuckets, 2*histSubBuckets)
+	shift := bits.Len(uint(v)) - 7
+	return histExact + (shift-1)*histSubBuckets + (v>>shift - histSubBuckets)
+}
+
+// histValue returns the value a bucket reports: exact below histExact,
+// otherwise the middle of the bucket's range
+func histValue(idx int) int {
+	if idx < histExact {
+		return idx
+	}
+	shift := (idx-histExact)/histSubBuckets + 1
+	lower := ((idx-histExact)%histSubBuckets + histSubBuckets) << shift
+	return lower + (1<<shift-1)/2
+}
+
+func (h *histogram) add(v int) {
+	idx := histBucket(v)
+	if idx >= len(h.counts) {
+		grown := make([]int64, idx+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+	h.total++
+	h.sum += int64(v)
+}
+
+func (h *histogram) remove(v int) {
+	idx := histBucket(v)
+	if idx >= len(h.counts) || h.counts[idx] == 0 {
+		return
+	}
+	h.counts[idx]--
+	h.total--
+	h.sum -= int64(v)
+}
+
+// quantile returns the value at percentileIndex, as if indexing into a
+// sorted slice of the samples
+func (h *histogram) quantile(pct float64) int {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(percentileIndex(int(h.total), pct))
+	var seen int64
+	for idx, c := range h.counts {
+		seen += c
+		if seen > rank {
+			return histValue(idx)
+		}
+	}
+	return 0
+}
+
+func (h *histogram) average() int {
+	if h.total == 0 {
+		return 0
+	}
+	return int(h.sum / h.total)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestHistBucket_RoundTrip(t *testing.T) {
+	// Exact below histExact
+	for v := 0; v < histExact; v++ {
+		if got := histValue(histBucket(v)); got != v {
+			t.Fatalf("expected %d to be exact, got %d", v, got)
+		}
+	}
+	// Within 1/64 above it
+	for _, v := range []int{128, 129, 255, 256, 1000, 4097, 30000, 1 << 20} {
+		got := histValue(histBucket(v))
+		if diff := got - v; diff*64 > v || -diff*64 > v {
+			t.Errorf("value %d reported as %d, more than 1/64 off", v, got)
+		}
+	}
+}
+
+func TestGetStats_ApproximationMatchesExact(t *testing.T) {
+	s := New(0)
+	rng := rand.New(rand.NewSource(1))
+
+	// Long-tailed latencies, as router logs tend to have
+	var exact []int
+	for i := 0; i < 10000; i++ {
+		v := int(rng.ExpFloat64() * 150)
+		exact = append(exact, v)
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: v})
+	}
+	sort.Ints(exact)
+
+	stats := s.GetStats()
+	n := len(exact)
+	if stats.MaxService != exact[n-1] {
+		t.Errorf("expected exact max %d, got %d", exact[n-1], stats.MaxService)
+	}
+
+	checks := []struct {
+		name      string
+		got, want int
+	}{
+		{"p50", stats.P50Service, exact[n*50/100]},
+		{"p95", stats.P95Service, exact[n*95/100]},
+		{"p99", stats.P99Service, exact[n*99/100]},
+	}
+	for _, c := range checks {
+		if diff := float64(c.got-c.want) / float64(c.want); diff > 0.03 || diff < -0.03 {
+			t.Errorf("%s: approx %d vs exact %d (%.1f%% off)", c.name, c.got, c.want, diff*100)
+		}
+	}
+}
+
+func TestGetStats_HistogramFollowsPrune(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 5000}, now.Add(-2*time.Minute))
+	for i := 1; i <= 10; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Service: i}, now)
+	}
+	s.Prune()
+
+	stats := s.GetStats()
+	if stats.MaxService != 10 || stats.AvgService != 5 {
+		t.Errorf("expected pruned 5000ms to leave max 10 / avg 5, got %d / %d", stats.MaxService, stats.AvgService)
+	}
+}
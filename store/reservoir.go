@@ -0,0 +1,61 @@
+package store
+
+import "math/rand/v2"
+
+// reservoir keeps a uniform random sample of every timing added, by
+// Algorithm R, so percentiles cover a whole stream rather than just the
+// entries the cap retains. Averages and maxima are tracked exactly.
+type reservoir struct {
+	service []int
+	connect []int
+	seen    int64
+
+	sumService, sumConnect int64
+	maxService, maxConnect int
+}
+
+// add offers a sample; the reservoir holds at most size of them
+func (r *reservoir) add(service, connect, size int) {
+	r.seen++
+	r.sumService += int64(service)
+	r.sumConnect += int64(connect)
+	r.maxService = max(r.maxService, service)
+	r.maxConnect = max(r.maxConnect, connect)
+
+	if len(r.service) < size {
+		r.service = append(r.service, service)
+		r.connect = append(r.connect, connect)
+		return
+	}
+	// Each sample so far stays with probability size/seen
+	if i := rand.Int64N(r.seen); i < int64(len(r.service)) {
+		r.service[i] = service
+		r.connect[i] = connect
+	}
+}
+
+// fillStats sets stats' timing fields from the sample
+func (r *reservoir) fillStats(stats *Stats) {
+	if r.seen == 0 {
+		return
+	}
+	stats.AvgService = int(r.sumService / r.seen)
+	stats.P50Service, stats.P95Service, stats.P99Service = percentiles(sortedCopy(r.service))
+	stats.MaxService = r.maxService
+	stats.AvgConnect = int(r.sumConnect / r.seen)
+	stats.P50Connect, stats.P95Connect, stats.P99Connect = percentiles(sortedCopy(r.connect))
+	stats.MaxConnect = r.maxConnect
+}
+
+// servicePercentile returns the pth percentile of the sampled service
+// times; p100 is the exact max
+func (r *reservoir) servicePercentile(p float64) int {
+	switch {
+	case r.seen == 0:
+		return 0
+	case p >= 100:
+		return r.maxService
+	}
+	sorted := sortedCopy(r.service)
+	return sorted[percentileIndex(len(sorted), p)]
+}
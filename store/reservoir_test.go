@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestSetSampling_PercentilesCoverWholeStream(t *testing.T) {
+	const total = 20000
+	capped, sampled := New(0), New(0)
+	capped.SetMaxEntries(1000)
+	sampled.SetMaxEntries(1000)
+	sampled.SetSampling(true)
+
+	// Service time rises steadily, so the retained tail is all slow
+	for i := 0; i < total; i++ {
+		e := parser.Entry{Status: 200, Service: i, Connect: 1}
+		capped.Add(&e)
+		e2 := e
+		sampled.Add(&e2)
+	}
+
+	if p50 := capped.GetStats().P50Service; p50 < total-1000 {
+		t.Fatalf("expected the capped store's p50 from the tail, got %d", p50)
+	}
+
+	stats := sampled.GetStats()
+	if stats.P50Service < total*4/10 || stats.P50Service > total*6/10 {
+		t.Errorf("expected a sampled p50 near %d, got %d", total/2, stats.P50Service)
+	}
+	if stats.AvgService != (total-1)/2 || stats.MaxService != total-1 {
+		t.Errorf("expected exact avg %d and max %d, got %d and %d", (total-1)/2, total-1, stats.AvgService, stats.MaxService)
+	}
+	if got := sampled.GetPercentile(100); got != total-1 {
+		t.Errorf("expected exact p100 %d, got %d", total-1, got)
+	}
+	if n := len(sampled.sample.service); n != 1000 {
+		t.Errorf("expected the sample capped at 1000, got %d", n)
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+// stateVersion is bumped when savedState changes incompatibly
+const stateVersion = 1
+
+// savedState is what Save writes. Aggregates aren't stored: Load rebuilds
+// them from the entries, so they can't drift from the entries.
+type savedState struct {
+	Version        int
+	Entries        []parser.Entry
+	HostFirstSeen  map[string]time.Time
+	IPFirstSeen    map[string]time.Time
+	DuplicateCount int64
+	MalformedCount int64
+}
+
+// Save writes the retained entries and session history to w
+func (s *Store) Save(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(savedState{
+		Version:        stateVersion,
+		Entries:        s.entries,
+		HostFirstSeen:  s.hostFirstSeen,
+		IPFirstSeen:    s.ipFirstSeen,
+		DuplicateCount: s.DuplicateCount,
+		MalformedCount: s.MalformedCount,
+	})
+}
+
+// Load restores entries written by Save, then prunes anything that has
+// fallen out of the window since. The entries were filtered and counted
+// when first added, so they go straight into the window: path patterns,
+// dedup and the like don't apply again, and the lifetime totals and
+// error-rate callbacks don't see them.
+func (s *Store) Load(r io.Reader) error {
+	var state savedState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+	if state.Version != stateVersion {
+		return fmt.Errorf("unsupported state version %d", state.Version)
+	}
+
+	s.mu.Lock()
+	for i := range state.Entries {
+		s.count(&state.Entries[i])
+	}
+
+	// Keep the earlier first-seen time, so hosts aren't NEW again. Only
+	// hosts and IPs in the restored entries need one; the rest would be
+	// forgotten anyway.
+	for host, t := range state.HostFirstSeen {
+		if seen, ok := s.hostFirstSeen[host]; ok && t.Before(seen) {
+			s.hostFirstSeen[host] = t
+		}
+	}
+	for ip, t := range state.IPFirstSeen {
+		if seen, ok := s.ipFirstSeen[ip]; ok && t.Before(seen) {
+			s.ipFirstSeen[ip] = t
+		}
+	}
+	s.DuplicateCount += state.DuplicateCount
+	s.MalformedCount += state.MalformedCount
+	s.mu.Unlock()
+
+	s.pruneWindow()
+	s.pruneFirstSeen()
+	return nil
+}
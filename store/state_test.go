@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	src := New(0)
+	src.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: 20, Host: "a.com", IP: "1.1.1.1", Path: "/users"})
+	src.Add(&parser.Entry{Timestamp: time.Now(), Status: 503, Service: 900, Host: "b.com", IP: "2.2.2.2", Path: "/orders"})
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	dst := New(0)
+	if err := dst.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if dst.TotalCount != 2 || dst.HostCounts["b.com"] != 1 || dst.StatusCounts[503] != 1 {
+		t.Errorf("expected aggregates rebuilt, got total %d, hosts %v, statuses %v", dst.TotalCount, dst.HostCounts, dst.StatusCounts)
+	}
+	if stats := dst.GetStats(); stats.MaxService != 900 {
+		t.Errorf("expected max 900ms after load, got %d", stats.MaxService)
+	}
+	if rates := dst.GetErrorRatesForPath("/orders"); rates.Rate5xx != 100 {
+		t.Errorf("expected /orders 5xx rate 100%%, got %+v", rates)
+	}
+	if !dst.GetHostFirstSeen("a.com").Equal(src.GetHostFirstSeen("a.com")) {
+		t.Error("expected first-seen time to be restored")
+	}
+}
+
+func TestLoad_PrunesStaleEntries(t *testing.T) {
+	src := New(0)
+	src.addEntryAtTime(&parser.Entry{Status: 200, Host: "old.com"}, time.Now().Add(-2*time.Hour))
+	src.addEntryAtTime(&parser.Entry{Status: 200, Host: "old.com"}, time.Now().Add(-90*time.Minute))
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Every saved entry is older than the window
+	dst := New(time.Hour)
+	if err := dst.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if dst.TotalCount != 0 || dst.HostCounts["old.com"] != 0 {
+		t.Errorf("expected stale entries pruned on load, got total %d", dst.TotalCount)
+	}
+}
+
+func TestLoad_RestoresWithoutRecounting(t *testing.T) {
+	src := New(0)
+	src.Add(&parser.Entry{Timestamp: time.Now(), Status: 500, Host: "a.com", Path: "/users/42", RequestID: "r1"})
+	src.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/users/43", RequestID: "r2"})
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	dst := New(0)
+	dst.SetNormalizePaths(true)
+	dst.SetDedup(true)
+	fired := 0
+	dst.OnErrorRateAbove(10, func(ErrorRates) { fired++ })
+	if err := dst.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	pathCount := func(path string) int64 {
+		for _, p := range dst.GetAllPaths(10) {
+			if p.Label == path {
+				return p.Count
+			}
+		}
+		return 0
+	}
+
+	if dst.TotalCount != 2 || dst.StatusCounts[500] != 1 {
+		t.Errorf("expected both entries in the window, got total %d", dst.TotalCount)
+	}
+	// Counted by the session that added them, not again on restore
+	if got := dst.LifetimeCount(); got != 0 {
+		t.Errorf("expected restored entries outside the lifetime count, got %d", got)
+	}
+	if got := dst.GetStatusTotals(); len(got) != 0 {
+		t.Errorf("expected restored entries outside the status totals, got %v", got)
+	}
+	// Rules apply to new entries only
+	if pathCount("/users/42") != 1 {
+		t.Errorf("expected restored paths kept as saved, got %v", dst.GetAllPaths(10))
+	}
+	if fired != 0 {
+		t.Errorf("expected no error-rate callback on load, fired %d", fired)
+	}
+
+	dst.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/users/44"})
+	if dst.LifetimeCount() != 1 || pathCount("/users/:id") != 1 {
+		t.Errorf("expected new entries counted and normalized, got lifetime %d, paths %v", dst.LifetimeCount(), dst.GetAllPaths(10))
+	}
+}
+
+func TestLoad_RejectsGarbage(t *testing.T) {
+	if err := New(0).Load(bytes.NewBufferString("not a state file")); err == nil {
+		t.Error("expected error loading garbage")
+	}
+}
@@ -1,36 +1,115 @@
 package store
 
 import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/betternow/hstat/parser"
 )
 
-const maxEntries = 100000
+// DefaultMaxEntries is how many entries a store keeps unless
+// SetMaxEntries says otherwise
+const DefaultMaxEntries = 100000
 
-// Paths to exclude from display
-var excludedPaths = []string{
+// DefaultExcludedPaths are hidden from display unless SetExcludedPaths
+// says otherwise. A trailing * matches any path with that prefix.
+var DefaultExcludedPaths = []string{
 	"/ahoy/events",
 	"/ahoy/visits",
 	"/robots.txt",
+	"/system-status-*",
+	"/hirefire*",
 }
 
-var excludedPathPrefixes = []string{
-	"/system-status-",
-	"/hirefire",
+// SetExcludedPaths replaces the paths hidden from display. Each pattern is
+// an exact path, or a prefix when it ends in * (e.g. "/health*"). An
+// empty list hides nothing.
+func (s *Store) SetExcludedPaths(patterns []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.excludedPaths, s.excludedPrefixes = splitPathPatterns(patterns)
 }
 
 // isExcludedPath returns true if the path should be hidden from display
-func isExcludedPath(path string) bool {
-	for _, excluded := range excludedPaths {
-		if path == excluded {
-			return true
+func (s *Store) isExcludedPath(path string) bool {
+	return matchesPath(s.excludedPaths, s.excludedPrefixes, path)
+}
+
+// SetExcludeFromTotals makes excluded paths drop out of the counts as
+// well: their entries are discarded on Add, so totals, rates and
+// percentiles match what the paths table shows. Only affects entries
+// added afterwards.
+func (s *Store) SetExcludeFromTotals(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.excludeFromTotals = enabled
+}
+
+// GetExcludedCount returns how many requests in the window went to
+// excluded paths, which are counted but hidden from the paths table
+func (s *Store) GetExcludedCount() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int64
+	for path, hosts := range s.pathToHosts {
+		if !s.isExcludedPath(path) {
+			continue
+		}
+		for _, count := range hosts {
+			total += count
 		}
 	}
-	for _, prefix := range excludedPathPrefixes {
+	return total
+}
+
+// SetDroppedPaths sets paths whose entries are discarded on Add, e.g.
+// health checks. Unlike SetExcludedPaths, which only hides paths from
+// display, dropped entries never reach totals, rates or percentiles.
+// Patterns are exact paths, or prefixes when they end in *, and match the
+// path as logged, before any path patterns or normalization. Only affects
+// entries added afterwards.
+func (s *Store) SetDroppedPaths(patterns []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := *s.rewrite.Load()
+	r.dropPaths, r.dropPrefixes = splitPathPatterns(patterns)
+	s.rewrite.Store(&r)
+}
+
+// splitPathPatterns splits exact/prefix* patterns into a set of exact
+// paths and a list of prefixes
+func splitPathPatterns(patterns []string) (map[string]bool, []string) {
+	exact := make(map[string]bool)
+	var prefixes []string
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			exact[p] = true
+		}
+	}
+	return exact, prefixes
+}
+
+// matchesPath reports whether path is in exact or starts with a prefix
+func matchesPath(exact map[string]bool, prefixes []string, path string) bool {
+	if exact[path] {
+		return true
+	}
+	for _, prefix := range prefixes {
 		if strings.HasPrefix(path, prefix) {
 			return true
 		}
@@ -38,22 +117,67 @@ func isExcludedPath(path string) bool {
 	return false
 }
 
+// PathPattern maps paths matching Re to a fixed route label
+type PathPattern struct {
+	Re    *regexp.Regexp
+	Label string
+}
+
+// ParsePathPattern parses a "regex=>label" flag value
+// (e.g. `^/users/\d+$=>/users/:id`)
+func ParsePathPattern(s string) (PathPattern, error) {
+	expr, label, ok := strings.Cut(s, "=>")
+	if !ok || expr == "" || label == "" {
+		return PathPattern{}, fmt.Errorf("invalid path pattern %q (want regex=>label)", s)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return PathPattern{}, fmt.Errorf("invalid path pattern %q: %v", s, err)
+	}
+	return PathPattern{Re: re, Label: label}, nil
+}
+
 // Store holds time-windowed log data with pre-computed aggregates
 type Store struct {
 	mu      sync.RWMutex
 	entries []parser.Entry
 	window  time.Duration // 0 = keep all (up to maxEntries)
 
+	// With SetLogClock, "now" is the newest log timestamp seen rather
+	// than the wall clock
+	logClock bool
+	newest   time.Time
+
+	// Cap on retained entries, oldest dropped first
+	maxEntries int
+
 	// Aggregates
 	TotalCount   int64
+	TotalBytes   int64 // response bytes; lines without bytes= count as 0
 	StatusCounts map[int]int64
 	HostCounts   map[string]int64
 	IPCounts     map[string]int64
+	MethodCounts map[string]int64
+	TLSCounts    map[string]int64 // only entries that carry a TLS version
+	CodeCounts   map[string]int64 // Heroku error codes (H12, R14, ...)
 
-	// For percentiles
+	// Raw timings, for GetServiceTimes/GetConnectTimes
 	serviceTimes []int
 	connectTimes []int
 
+	// For percentiles, kept incrementally so GetStats needn't sort
+	serviceHist histogram
+	connectHist histogram
+
+	// With SetSampling, a sample of every timing ever added; nil otherwise
+	sample *reservoir
+
+	// Per-host timings, oldest first, in step with entries (101s excluded)
+	hostToServiceTimes map[string][]int
+	ipToServiceTimes   map[string][]int
+	pathToServiceTimes map[string][]int
+	hostToConnectTimes map[string][]int
+
 	// For filtered views
 	hostToIPs    map[string]map[string]int64 // host -> ip -> count
 	ipToHosts    map[string]map[string]int64 // ip -> host -> count
@@ -61,22 +185,342 @@ type Store struct {
 	ipToStatus   map[string]map[int]int64    // ip -> status -> count
 	hostToPaths  map[string]map[string]int64 // host -> path -> count
 	ipToPaths    map[string]map[string]int64 // ip -> path -> count
+	pathToHosts  map[string]map[string]int64 // path -> host -> count
+	pathToIPs    map[string]map[string]int64 // path -> ip -> count
+	pathToStatus map[string]map[int]int64    // path -> status -> count
+	hostToMethod map[string]map[string]int64 // host -> method -> count
+	ipToMethod   map[string]map[string]int64 // ip -> method -> count
+	pathToMethod map[string]map[string]int64 // path -> method -> count
+
+	// Latest description seen for each error code
+	codeDescs map[string]string
+
+	// First-seen times, kept while a host or IP is in the window and for
+	// firstSeenRetention after. The queues hold first sightings in arrival
+	// order so pruning doesn't have to scan the maps.
+	hostFirstSeen      map[string]time.Time
+	ipFirstSeen        map[string]time.Time
+	hostFirstSeenQueue []firstSeen
+	ipFirstSeenQueue   []firstSeen
+
+	// Threshold callbacks, checked on each Prune
+	errorRateHooks []*errorRateHook
+
+	// Paths hidden from display: exact matches and prefixes
+	excludedPaths    map[string]bool
+	excludedPrefixes []string
+
+	// Discard entries for excluded paths too, instead of only hiding them
+	excludeFromTotals bool
+
+	// Path and host rewriting, swapped whole by its setters so Add can
+	// apply it before taking mu
+	rewrite atomic.Pointer[rewriteRules]
+
+	// Duplicate detection by request_id, bounded to maxEntries ids
+	dedup          bool
+	seenIDs        map[string]time.Time
+	seenOrder      []string // oldest first, for eviction
+	DuplicateCount int64
+
+	// Truncated router lines, counted but left out of every stat
+	MalformedCount int64
+
+	// Require trend shifts to be statistically significant for their volume
+	weightedTrend bool
+
+	// Error-rate shift (as a fraction) that makes a trend, and the samples
+	// each period needs before one is called
+	trendThreshold  float64
+	trendMinSamples int
+
+	// Per-status counts since start, never pruned (for counter metrics)
+	statusTotals map[int]int64
+
+	// Entries added since start; unlike TotalCount, pruning never lowers it
+	lifetimeCount int64
+}
+
+// rewriteRules drop or canonicalize an entry before counting
+type rewriteRules struct {
+	// Paths whose entries are discarded: exact matches and prefixes
+	dropPaths    map[string]bool
+	dropPrefixes []string
+
+	// Route patterns applied to paths, first match wins
+	pathPatterns []PathPattern
+
+	// Collapse numeric/UUID path segments
+	normalizePaths bool
+
+	// Host canonicalization
+	canonicalHosts bool
+	keepHostPorts  bool
+}
+
+// firstSeenRetention is how long a first-seen time outlives its host or
+// IP leaving the window. It covers the UI's NEW badge and new-IP rate; a
+// label that comes back after longer counts as new again.
+const firstSeenRetention = 5 * time.Minute
+
+// firstSeen is a queued first sighting of a host or IP
+type firstSeen struct {
+	label string
+	at    time.Time
+}
+
+// errorRateHook is a registered OnErrorRateAbove callback
+type errorRateHook struct {
+	threshold float64
+	fn        func(ErrorRates)
+	above     bool // whether the last check was above threshold
 }
 
 // New creates a new Store with the given window duration
 func New(window time.Duration) *Store {
-	return &Store{
+	s := &Store{
 		window:       window,
+		maxEntries:   DefaultMaxEntries,
 		StatusCounts: make(map[int]int64),
 		HostCounts:   make(map[string]int64),
 		IPCounts:     make(map[string]int64),
+		MethodCounts: make(map[string]int64),
+		TLSCounts:    make(map[string]int64),
+		CodeCounts:   make(map[string]int64),
+		codeDescs:    make(map[string]string),
 		hostToIPs:    make(map[string]map[string]int64),
 		ipToHosts:    make(map[string]map[string]int64),
 		hostToStatus: make(map[string]map[int]int64),
 		ipToStatus:   make(map[string]map[int]int64),
 		hostToPaths:  make(map[string]map[string]int64),
 		ipToPaths:    make(map[string]map[string]int64),
+		pathToHosts:  make(map[string]map[string]int64),
+		pathToIPs:    make(map[string]map[string]int64),
+		pathToStatus: make(map[string]map[int]int64),
+		hostToMethod: make(map[string]map[string]int64),
+		ipToMethod:   make(map[string]map[string]int64),
+		pathToMethod: make(map[string]map[string]int64),
+
+		hostToServiceTimes: make(map[string][]int),
+		ipToServiceTimes:   make(map[string][]int),
+		pathToServiceTimes: make(map[string][]int),
+		hostToConnectTimes: make(map[string][]int),
+
+		hostFirstSeen: make(map[string]time.Time),
+		ipFirstSeen:   make(map[string]time.Time),
+
+		statusTotals: make(map[int]int64),
+
+		trendThreshold:  DefaultTrendThreshold,
+		trendMinSamples: DefaultTrendMinSamples,
 	}
+	s.rewrite.Store(&rewriteRules{})
+	s.SetExcludedPaths(DefaultExcludedPaths)
+	return s
+}
+
+// SetPathPatterns sets the route patterns paths are mapped through before
+// counting. Patterns are tried in order and the first match wins; paths
+// that match none are counted as-is. Only affects entries added afterwards.
+func (s *Store) SetPathPatterns(patterns []PathPattern) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := *s.rewrite.Load()
+	r.pathPatterns = patterns
+	s.rewrite.Store(&r)
+}
+
+// SetNormalizePaths enables collapsing variable path segments: numeric
+// segments become :id and UUIDs become :uuid (e.g. /users/123 and
+// /users/456 both count as /users/:id). Only affects entries added
+// afterwards.
+func (s *Store) SetNormalizePaths(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := *s.rewrite.Load()
+	r.normalizePaths = enabled
+	s.rewrite.Store(&r)
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// normalizePath replaces numeric and UUID path segments with placeholders
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+		case isDigits(seg):
+			segments[i] = ":id"
+		case uuidRe.MatchString(seg):
+			segments[i] = ":uuid"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isDigits reports whether s is a non-empty run of digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// SetCanonicalHosts enables host canonicalization so variants of the same
+// host count together: hosts are percent-decoded, lowercased, and, unless
+// keepPorts is set, stripped of a trailing :port. Only affects entries
+// added afterwards.
+func (s *Store) SetCanonicalHosts(enabled, keepPorts bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := *s.rewrite.Load()
+	r.canonicalHosts = enabled
+	r.keepHostPorts = keepPorts
+	s.rewrite.Store(&r)
+}
+
+// SetLogClock measures the window, rates and trends against the newest
+// entry's log timestamp instead of the wall clock. Meant for finite input
+// such as an archived log file, which would otherwise be entirely older
+// than the window and pruned to nothing.
+func (s *Store) SetLogClock(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logClock = enabled
+}
+
+// now returns the time windows are measured back from; the caller holds mu
+func (s *Store) now() time.Time {
+	if s.logClock && !s.newest.IsZero() {
+		return s.newest
+	}
+	return time.Now()
+}
+
+// Now returns the time windows are measured back from: the wall clock, or
+// the newest log timestamp with SetLogClock
+func (s *Store) Now() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.now()
+}
+
+// SetMaxEntries caps how many entries are kept, dropping the oldest
+// beyond it right away. A lower cap saves memory; on busy streams it also
+// means the stats cover less than the window.
+func (s *Store) SetMaxEntries(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxEntries = n
+	if len(s.entries) > n {
+		s.pruneOldest(len(s.entries) - n)
+	}
+}
+
+// SetSampling makes GetStats and GetPercentile read timings from a
+// uniform random sample of every entry added, up to the entry cap, rather
+// than from the retained entries. Once the cap starts dropping entries,
+// percentiles still reflect the whole stream instead of its tail. Meant
+// for use without a window; per-host and other stats are unaffected.
+func (s *Store) SetSampling(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sample = nil
+	if enabled {
+		s.sample = &reservoir{}
+	}
+}
+
+// SetDedup enables skipping entries whose request_id was already seen
+// within the window, e.g. from overlapping log streams. Seen ids are
+// capped at maxEntries, so memory stays bounded with no window.
+func (s *Store) SetDedup(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dedup = enabled
+	s.seenIDs = nil
+	s.seenOrder = nil
+	if enabled {
+		s.seenIDs = make(map[string]time.Time)
+	}
+}
+
+// isDuplicate records id and reports whether it was already seen
+func (s *Store) isDuplicate(id string, t time.Time) bool {
+	if _, ok := s.seenIDs[id]; ok {
+		return true
+	}
+	s.seenIDs[id] = t
+	s.seenOrder = append(s.seenOrder, id)
+	if len(s.seenOrder) > s.maxEntries {
+		s.forgetOldestIDs(len(s.seenOrder) - s.maxEntries)
+	}
+	return false
+}
+
+// forgetOldestIDs drops the count oldest seen request ids
+func (s *Store) forgetOldestIDs(count int) {
+	for _, id := range s.seenOrder[:count] {
+		delete(s.seenIDs, id)
+	}
+	s.seenOrder = s.seenOrder[count:]
+}
+
+// apply returns e with its path and host rewritten, e itself when no
+// rewriting is enabled, or nil when e's path is dropped
+func (r *rewriteRules) apply(e *parser.Entry) *parser.Entry {
+	if matchesPath(r.dropPaths, r.dropPrefixes, e.Path) {
+		return nil
+	}
+	if len(r.pathPatterns) == 0 && !r.normalizePaths && !r.canonicalHosts {
+		return e
+	}
+	c := *e
+	c.Path = r.routePath(e.Path)
+	if r.normalizePaths {
+		c.Path = normalizePath(c.Path)
+	}
+	c.Host = r.canonicalHost(e.Host)
+	return &c
+}
+
+// canonicalHost applies host canonicalization, if enabled
+func (r *rewriteRules) canonicalHost(host string) string {
+	if !r.canonicalHosts || host == "" {
+		return host
+	}
+	// Malformed escapes are left as-is rather than dropping the host
+	if decoded, err := url.PathUnescape(host); err == nil {
+		host = decoded
+	}
+	host = strings.ToLower(host)
+	if !r.keepHostPorts {
+		if i := strings.LastIndex(host, ":"); i != -1 && isDigits(host[i+1:]) {
+			host = host[:i]
+		}
+	}
+	return host
+}
+
+// routePath maps a path through the first matching pattern
+func (r *rewriteRules) routePath(path string) string {
+	for _, p := range r.pathPatterns {
+		if p.Re.MatchString(path) {
+			return p.Label
+		}
+	}
+	return path
 }
 
 // Add adds an entry to the store
@@ -85,28 +529,110 @@ func (s *Store) Add(e *parser.Entry) {
 		return
 	}
 
+	// Canonicalize up front so the retained entry (and its eventual
+	// prune) uses the same host and path that were counted. The regexes
+	// are the costly part of Add, so this runs before taking the lock.
+	e = s.rewrite.Load().apply(e)
+	if e == nil {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Normalize empty values
-	host := e.Host
-	ip := e.IP
-	if host == "" {
-		host = "(unknown)"
+	s.add(e)
+}
+
+// AddBatch adds entries under a single lock, so a burst contends with
+// readers once rather than per entry. Nil and dropped entries are skipped.
+func (s *Store) AddBatch(entries []*parser.Entry) {
+	rules := s.rewrite.Load()
+	rewritten := make([]*parser.Entry, 0, len(entries))
+	for _, e := range entries {
+		if e == nil {
+			continue
+		}
+		if e = rules.apply(e); e != nil {
+			rewritten = append(rewritten, e)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range rewritten {
+		s.add(e)
 	}
-	if ip == "" {
-		ip = "(unknown)"
+}
+
+// add counts an already rewritten entry; the caller holds mu
+func (s *Store) add(e *parser.Entry) {
+	if e.Malformed {
+		s.MalformedCount++
+		return
 	}
+	if s.excludeFromTotals && e.Path != "" && s.isExcludedPath(e.Path) {
+		return
+	}
+	if s.dedup && e.RequestID != "" && s.isDuplicate(e.RequestID, e.Timestamp) {
+		s.DuplicateCount++
+		return
+	}
+
+	s.statusTotals[e.Status]++
+	s.lifetimeCount++
+	if s.sample != nil && e.Status != 101 {
+		s.sample.add(e.Service, e.Connect, s.maxEntries)
+	}
+	s.count(e)
+}
+
+// count adds an entry to the window: the retained entries and every
+// aggregate over them. Unlike add it skips the filters and lifetime
+// totals, so Load can restore entries counted in an earlier session. The
+// caller holds mu.
+func (s *Store) count(e *parser.Entry) {
+	// Normalize empty values
+	host := orUnknown(e.Host)
+	ip := orUnknown(e.IP)
+	method := orUnknown(e.Method)
 
 	s.entries = append(s.entries, *e)
+	if e.Timestamp.After(s.newest) {
+		s.newest = e.Timestamp
+	}
 	s.TotalCount++
+	s.TotalBytes += int64(e.Bytes)
 	s.StatusCounts[e.Status]++
 	s.HostCounts[host]++
 	s.IPCounts[ip]++
+	s.MethodCounts[method]++
+	if e.TLS != "" {
+		s.TLSCounts[e.TLS]++
+	}
+	if e.Code != "" {
+		s.CodeCounts[e.Code]++
+		if e.Desc != "" {
+			s.codeDescs[e.Code] = e.Desc
+		}
+	}
+	if _, ok := s.hostFirstSeen[host]; !ok {
+		s.hostFirstSeen[host] = e.Timestamp
+		s.hostFirstSeenQueue = append(s.hostFirstSeenQueue, firstSeen{label: host, at: e.Timestamp})
+	}
+	if _, ok := s.ipFirstSeen[ip]; !ok {
+		s.ipFirstSeen[ip] = e.Timestamp
+		s.ipFirstSeenQueue = append(s.ipFirstSeenQueue, firstSeen{label: ip, at: e.Timestamp})
+	}
 	// Skip 101 (WebSocket upgrade) for response time stats - they skew percentiles
 	if e.Status != 101 {
 		s.serviceTimes = append(s.serviceTimes, e.Service)
 		s.connectTimes = append(s.connectTimes, e.Connect)
+		s.serviceHist.add(e.Service)
+		s.connectHist.add(e.Connect)
+		s.hostToServiceTimes[host] = append(s.hostToServiceTimes[host], e.Service)
+		s.ipToServiceTimes[ip] = append(s.ipToServiceTimes[ip], e.Service)
+		s.hostToConnectTimes[host] = append(s.hostToConnectTimes[host], e.Connect)
 	}
 
 	// Track relationships
@@ -131,10 +657,7 @@ func (s *Store) Add(e *parser.Entry) {
 	s.ipToStatus[ip][e.Status]++
 
 	// Track paths per host and IP
-	path := e.Path
-	if path == "" {
-		path = "(unknown)"
-	}
+	path := orUnknown(e.Path)
 	if s.hostToPaths[host] == nil {
 		s.hostToPaths[host] = make(map[string]int64)
 	}
@@ -145,23 +668,69 @@ func (s *Store) Add(e *parser.Entry) {
 	}
 	s.ipToPaths[ip][path]++
 
+	// Track hosts, IPs, and statuses per path
+	if s.pathToHosts[path] == nil {
+		s.pathToHosts[path] = make(map[string]int64)
+	}
+	s.pathToHosts[path][host]++
+
+	if s.pathToIPs[path] == nil {
+		s.pathToIPs[path] = make(map[string]int64)
+	}
+	s.pathToIPs[path][ip]++
+
+	if s.pathToStatus[path] == nil {
+		s.pathToStatus[path] = make(map[int]int64)
+	}
+	s.pathToStatus[path][e.Status]++
+
+	if e.Status != 101 {
+		s.pathToServiceTimes[path] = append(s.pathToServiceTimes[path], e.Service)
+	}
+
+	// Track methods per host, IP, and path
+	if s.hostToMethod[host] == nil {
+		s.hostToMethod[host] = make(map[string]int64)
+	}
+	s.hostToMethod[host][method]++
+
+	if s.ipToMethod[ip] == nil {
+		s.ipToMethod[ip] = make(map[string]int64)
+	}
+	s.ipToMethod[ip][method]++
+
+	if s.pathToMethod[path] == nil {
+		s.pathToMethod[path] = make(map[string]int64)
+	}
+	s.pathToMethod[path][method]++
+
 	// Cap at maxEntries
-	if len(s.entries) > maxEntries {
-		s.pruneOldest(len(s.entries) - maxEntries)
+	if len(s.entries) > s.maxEntries {
+		s.pruneOldest(len(s.entries) - s.maxEntries)
 	}
 }
 
-// Prune removes entries older than the window
+// Prune removes entries older than the window, then runs any
+// threshold callbacks whose condition was crossed
 func (s *Store) Prune() {
-	if s.window == 0 {
-		return
-	}
+	s.pruneWindow()
+	s.pruneFirstSeen()
+	s.runHooks()
+}
 
+func (s *Store) pruneWindow() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	cutoff := time.Now().Add(-s.window)
-	pruneCount := 0
+	if s.window == 0 {
+		return
+	}
+
+	// Entries carry their log timestamps, so a line that arrives late can
+	// sit after a newer one. The scan stops at the first in-window entry,
+	// so a stray older line behind it is kept until it reaches the front.
+	cutoff := s.now().Add(-s.window)
+	pruneCount := len(s.entries) // all stale unless an in-window entry is found
 	for i, e := range s.entries {
 		if e.Timestamp.After(cutoff) {
 			pruneCount = i
@@ -172,6 +741,81 @@ func (s *Store) Prune() {
 	if pruneCount > 0 {
 		s.pruneOldest(pruneCount)
 	}
+
+	// Request ids age out with the window too
+	if s.dedup {
+		expired := 0
+		for expired < len(s.seenOrder) && !s.seenIDs[s.seenOrder[expired]].After(cutoff) {
+			expired++
+		}
+		s.forgetOldestIDs(expired)
+	}
+}
+
+// pruneFirstSeen forgets first-seen times older than firstSeenRetention
+// for hosts and IPs no longer in the window. Those still in it are
+// forgotten by pruneOldest once they leave.
+func (s *Store) pruneFirstSeen() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-firstSeenRetention)
+	s.hostFirstSeenQueue = forgetFirstSeen(s.hostFirstSeenQueue, s.hostFirstSeen, s.HostCounts, cutoff)
+	s.ipFirstSeenQueue = forgetFirstSeen(s.ipFirstSeenQueue, s.ipFirstSeen, s.IPCounts, cutoff)
+}
+
+// forgetFirstSeen pops queued sightings from before cutoff, deleting their
+// first-seen times unless the label is still counted, and returns the rest
+// of the queue. A label can be queued again after being forgotten; only
+// its latest sighting deletes it.
+func forgetFirstSeen(queue []firstSeen, seen map[string]time.Time, counts map[string]int64, cutoff time.Time) []firstSeen {
+	n := 0
+	for n < len(queue) && queue[n].at.Before(cutoff) {
+		f := queue[n]
+		if counts[f.label] == 0 && !seen[f.label].After(f.at) {
+			delete(seen, f.label)
+		}
+		n++
+	}
+	return queue[n:]
+}
+
+// OnErrorRateAbove registers fn to be called when the combined 4xx+5xx
+// error rate (in percent) rises above threshold. It is edge-triggered:
+// fn fires once per crossing and re-arms after the rate drops back to
+// or below the threshold. Callbacks run without the store lock held, so
+// they may safely call back into the store.
+func (s *Store) OnErrorRateAbove(threshold float64, fn func(ErrorRates)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errorRateHooks = append(s.errorRateHooks, &errorRateHook{threshold: threshold, fn: fn})
+}
+
+// runHooks evaluates registered callbacks and invokes the ones that crossed
+func (s *Store) runHooks() {
+	s.mu.Lock()
+	if len(s.errorRateHooks) == 0 {
+		s.mu.Unlock()
+		return
+	}
+
+	rates := s.calculateErrorRates(s.StatusCounts)
+	combined := rates.Rate4xx + rates.Rate5xx
+
+	var fire []func(ErrorRates)
+	for _, h := range s.errorRateHooks {
+		above := combined > h.threshold
+		if above && !h.above {
+			fire = append(fire, h.fn)
+		}
+		h.above = above
+	}
+	s.mu.Unlock()
+
+	for _, fn := range fire {
+		fn(rates)
+	}
 }
 
 func (s *Store) pruneOldest(count int) {
@@ -182,49 +826,72 @@ func (s *Store) pruneOldest(count int) {
 	// Count non-101 entries being pruned (they have timing data)
 	timingCount := 0
 
+	// Hosts and IPs leaving the window forget first-seen times this old
+	firstSeenCutoff := s.now().Add(-firstSeenRetention)
+
 	// Decrement counts for pruned entries
 	for i := 0; i < count; i++ {
 		e := s.entries[i]
-		host := e.Host
-		ip := e.IP
-		if host == "" {
-			host = "(unknown)"
-		}
-		if ip == "" {
-			ip = "(unknown)"
-		}
+		host := orUnknown(e.Host)
+		ip := orUnknown(e.IP)
+		method := orUnknown(e.Method)
 
 		s.TotalCount--
-		s.StatusCounts[e.Status]--
-		s.HostCounts[host]--
-		s.IPCounts[ip]--
-
-		if s.hostToIPs[host] != nil {
-			s.hostToIPs[host][ip]--
+		s.TotalBytes -= int64(e.Bytes)
+		decr(s.StatusCounts, e.Status)
+		decr(s.HostCounts, host)
+		decr(s.IPCounts, ip)
+		decr(s.MethodCounts, method)
+		if s.HostCounts[host] == 0 && s.hostFirstSeen[host].Before(firstSeenCutoff) {
+			delete(s.hostFirstSeen, host)
 		}
-		if s.ipToHosts[ip] != nil {
-			s.ipToHosts[ip][host]--
+		if s.IPCounts[ip] == 0 && s.ipFirstSeen[ip].Before(firstSeenCutoff) {
+			delete(s.ipFirstSeen, ip)
 		}
-		if s.hostToStatus[host] != nil {
-			s.hostToStatus[host][e.Status]--
+		if e.TLS != "" {
+			decr(s.TLSCounts, e.TLS)
 		}
-		if s.ipToStatus[ip] != nil {
-			s.ipToStatus[ip][e.Status]--
+		if e.Code != "" {
+			decr(s.CodeCounts, e.Code)
 		}
 
-		path := e.Path
-		if path == "" {
-			path = "(unknown)"
-		}
-		if s.hostToPaths[host] != nil {
-			s.hostToPaths[host][path]--
-		}
-		if s.ipToPaths[ip] != nil {
-			s.ipToPaths[ip][path]--
-		}
+		decrNested(s.hostToIPs, host, ip)
+		decrNested(s.ipToHosts, ip, host)
+		decrNested(s.hostToStatus, host, e.Status)
+		decrNested(s.ipToStatus, ip, e.Status)
+
+		path := orUnknown(e.Path)
+		decrNested(s.hostToPaths, host, path)
+		decrNested(s.ipToPaths, ip, path)
+		decrNested(s.pathToHosts, path, host)
+		decrNested(s.pathToIPs, path, ip)
+		decrNested(s.pathToStatus, path, e.Status)
+		decrNested(s.hostToMethod, host, method)
+		decrNested(s.ipToMethod, ip, method)
+		decrNested(s.pathToMethod, path, method)
 
 		if e.Status != 101 {
 			timingCount++
+			s.serviceHist.remove(e.Service)
+			s.connectHist.remove(e.Connect)
+			// This host's oldest sample is the one being pruned
+			if times := s.hostToServiceTimes[host]; len(times) > 1 {
+				s.hostToServiceTimes[host] = times[1:]
+				s.hostToConnectTimes[host] = s.hostToConnectTimes[host][1:]
+			} else {
+				delete(s.hostToServiceTimes, host)
+				delete(s.hostToConnectTimes, host)
+			}
+			if times := s.ipToServiceTimes[ip]; len(times) > 1 {
+				s.ipToServiceTimes[ip] = times[1:]
+			} else {
+				delete(s.ipToServiceTimes, ip)
+			}
+			if times := s.pathToServiceTimes[path]; len(times) > 1 {
+				s.pathToServiceTimes[path] = times[1:]
+			} else {
+				delete(s.pathToServiceTimes, path)
+			}
 		}
 	}
 
@@ -233,6 +900,29 @@ func (s *Store) pruneOldest(count int) {
 	s.connectTimes = s.connectTimes[timingCount:]
 }
 
+// decr decrements counts[key], deleting the key at zero so labels that
+// churn through a long-running session don't accumulate
+func decr[K comparable](counts map[K]int64, key K) {
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
+
+// decrNested is decr for a two-level map, also deleting the inner map
+// once it is empty
+func decrNested[K, V comparable](counts map[K]map[V]int64, outer K, inner V) {
+	m := counts[outer]
+	if m == nil {
+		return
+	}
+	decr(m, inner)
+	if len(m) == 0 {
+		delete(counts, outer)
+	}
+}
+
 // Stats returns computed statistics
 type Stats struct {
 	TotalCount int64
@@ -242,59 +932,248 @@ type Stats struct {
 	P99Service int
 	MaxService int
 	AvgConnect int
+	P50Connect int
+	P95Connect int
+	P99Connect int
 	MaxConnect int
 }
 
-// GetStats returns current statistics
+// GetStats returns current statistics. Percentiles come from histograms
+// and are accurate to within about 1.6% (exact below 128ms); averages and
+// maxima are exact.
 func (s *Store) GetStats() Stats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	stats := Stats{TotalCount: s.TotalCount}
-
-	if len(s.serviceTimes) == 0 {
+	if s.sample != nil {
+		s.sample.fillStats(&stats)
 		return stats
 	}
+	if s.serviceHist.total > 0 {
+		stats.AvgService = s.serviceHist.average()
+		stats.P50Service = windowPercentile(&s.serviceHist, s.serviceTimes, 50)
+		stats.P95Service = windowPercentile(&s.serviceHist, s.serviceTimes, 95)
+		stats.P99Service = windowPercentile(&s.serviceHist, s.serviceTimes, 99)
+		stats.MaxService = windowPercentile(&s.serviceHist, s.serviceTimes, 100)
+	}
+	if s.connectHist.total > 0 {
+		stats.AvgConnect = s.connectHist.average()
+		stats.P50Connect = windowPercentile(&s.connectHist, s.connectTimes, 50)
+		stats.P95Connect = windowPercentile(&s.connectHist, s.connectTimes, 95)
+		stats.P99Connect = windowPercentile(&s.connectHist, s.connectTimes, 99)
+		stats.MaxConnect = windowPercentile(&s.connectHist, s.connectTimes, 100)
+	}
+	return stats
+}
 
-	// Make a copy for sorting
-	times := make([]int, len(s.serviceTimes))
-	copy(times, s.serviceTimes)
-	sort.Ints(times)
+// GetPercentile returns the pth percentile (0-100) of the service times
+// in the window, computed the same way as GetStats. p is clamped to
+// [0, 100]; p0 and p100 are the exact min and max. Returns 0 when there
+// are no timed requests.
+func (s *Store) GetPercentile(p float64) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	// Avg
-	sum := 0
-	for _, t := range times {
-		sum += t
+	if s.sample != nil {
+		return s.sample.servicePercentile(p)
 	}
-	stats.AvgService = sum / len(times)
-
-	// Percentiles
-	stats.P50Service = times[len(times)*50/100]
-	stats.P95Service = times[len(times)*95/100]
-	p99idx := len(times) * 99 / 100
-	if p99idx >= len(times) {
-		p99idx = len(times) - 1
-	}
-	stats.P99Service = times[p99idx]
-	stats.MaxService = times[len(times)-1]
-
-	// Connect times
-	if len(s.connectTimes) > 0 {
-		connSum := 0
-		maxConn := 0
-		for _, t := range s.connectTimes {
-			connSum += t
-			if t > maxConn {
-				maxConn = t
-			}
+	return windowPercentile(&s.serviceHist, s.serviceTimes, p)
+}
+
+// windowPercentile reads the pth percentile from h, except at the ends,
+// where the raw samples give the exact min and max
+func windowPercentile(h *histogram, raw []int, p float64) int {
+	switch {
+	case len(raw) == 0:
+		return 0
+	case p <= 0:
+		return slices.Min(raw)
+	case p >= 100:
+		return slices.Max(raw)
+	}
+	return h.quantile(p)
+}
+
+// GetServiceTimes returns a sorted copy of the service times (ms) in the
+// current window, excluding 101s, for external percentile tools
+func (s *Store) GetServiceTimes() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedCopy(s.serviceTimes)
+}
+
+// GetConnectTimes returns a sorted copy of the connect times (ms) in the
+// current window, excluding 101s
+func (s *Store) GetConnectTimes() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedCopy(s.connectTimes)
+}
+
+func sortedCopy(times []int) []int {
+	out := make([]int, len(times))
+	copy(out, times)
+	sort.Ints(out)
+	return out
+}
+
+// GetStatsForHost returns timing statistics for a single host (101s
+// excluded, as in GetStats)
+func (s *Store) GetStatsForHost(host string) Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return computeStats(s.HostCounts[host], s.hostToServiceTimes[host], s.hostToConnectTimes[host])
+}
+
+// GetP95ForHost returns a host's p95 response time (101s excluded), or
+// false if it has no timed requests
+func (s *Store) GetP95ForHost(host string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return p95Of(s.hostToServiceTimes[host])
+}
+
+// GetP95ForIP returns an IP's p95 response time (101s excluded), or
+// false if it has no timed requests
+func (s *Store) GetP95ForIP(ip string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return p95Of(s.ipToServiceTimes[ip])
+}
+
+// HistogramBucket counts response times from Min (inclusive) up to Max
+// (exclusive), in ms. The last bucket has Max 0: it has no upper bound.
+type HistogramBucket struct {
+	Min   int
+	Max   int
+	Count int64
+}
+
+// GetServiceHistogram counts the window's response times (101s excluded)
+// into buckets split at the given ascending bounds in ms: bounds
+// {10, 50} give 0-10ms, 10-50ms, and 50ms+
+func (s *Store) GetServiceHistogram(bounds []int) []HistogramBucket {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	buckets := make([]HistogramBucket, len(bounds)+1)
+	lower := 0
+	for i, bound := range bounds {
+		buckets[i] = HistogramBucket{Min: lower, Max: bound}
+		lower = bound
+	}
+	buckets[len(bounds)] = HistogramBucket{Min: lower}
+
+	for _, ms := range s.serviceTimes {
+		i, _ := slices.BinarySearch(bounds, ms+1)
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// PathLatency is a path's p95 response time over Count timed requests
+type PathLatency struct {
+	Label string
+	P95   int
+	Count int64
+}
+
+// slowPathMinSamples is how many timed requests a path needs before
+// GetSlowestPaths ranks it, so one slow request can't top the list
+const slowPathMinSamples = 5
+
+// GetSlowestPaths returns up to n paths with the highest p95 response
+// time (101s excluded), skipping excluded paths and paths with fewer than
+// slowPathMinSamples timed requests
+func (s *Store) GetSlowestPaths(n int) []PathLatency {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var paths []PathLatency
+	for path, times := range s.pathToServiceTimes {
+		if len(times) < slowPathMinSamples || s.isExcludedPath(path) {
+			continue
+		}
+		p95, _ := p95Of(times)
+		paths = append(paths, PathLatency{Label: path, P95: p95, Count: int64(len(times))})
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].P95 != paths[j].P95 {
+			return paths[i].P95 > paths[j].P95
 		}
-		stats.AvgConnect = connSum / len(s.connectTimes)
-		stats.MaxConnect = maxConn
+		if paths[i].Count != paths[j].Count {
+			return paths[i].Count > paths[j].Count
+		}
+		return paths[i].Label < paths[j].Label
+	})
+
+	if len(paths) > n {
+		paths = paths[:n]
+	}
+	return paths
+}
+
+func p95Of(times []int) (int, bool) {
+	if len(times) == 0 {
+		return 0, false
+	}
+	_, p95, _ := percentiles(sortedCopy(times))
+	return p95, true
+}
+
+// computeStats derives Stats from unsorted timing samples. Each slice is
+// copied and sorted once.
+func computeStats(total int64, service, connect []int) Stats {
+	stats := Stats{TotalCount: total}
+
+	if len(service) > 0 {
+		times := sortedCopy(service)
+		stats.AvgService = average(times)
+		stats.P50Service, stats.P95Service, stats.P99Service = percentiles(times)
+		stats.MaxService = times[len(times)-1]
+	}
+
+	if len(connect) > 0 {
+		times := sortedCopy(connect)
+		stats.AvgConnect = average(times)
+		stats.P50Connect, stats.P95Connect, stats.P99Connect = percentiles(times)
+		stats.MaxConnect = times[len(times)-1]
 	}
 
 	return stats
 }
 
+// percentiles returns p50, p95, and p99 of a sorted, non-empty slice
+func percentiles(sorted []int) (p50, p95, p99 int) {
+	n := len(sorted)
+	return sorted[percentileIndex(n, 50)], sorted[percentileIndex(n, 95)], sorted[percentileIndex(n, 99)]
+}
+
+// percentileIndex returns where the pth percentile (0-100) sits among n > 0
+// sorted samples, by nearest rank: the smallest sample with at least p% of
+// samples at or below it. Every percentile in the store goes through here.
+func percentileIndex(n int, p float64) int {
+	// The epsilon keeps float noise (99.9/100*1000 = 999.0000000000001)
+	// from bumping an exact rank up by one
+	idx := int(math.Ceil(float64(n)*p/100-1e-9)) - 1
+	return min(max(idx, 0), n-1)
+}
+
+func average(times []int) int {
+	sum := 0
+	for _, t := range times {
+		sum += t
+	}
+	return sum / len(times)
+}
+
 // CountItem represents a count with label
 type CountItem struct {
 	Label string
@@ -322,6 +1201,47 @@ func (s *Store) GetStatusCounts(filterHost, filterIP string) []StatusCountItem {
 		counts = s.StatusCounts
 	}
 
+	return statusItems(counts)
+}
+
+// GetStatusCountsForPath returns status counts for a path, sorted by
+// status code
+func (s *Store) GetStatusCountsForPath(path string) []StatusCountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return statusItems(s.pathToStatus[path])
+}
+
+// LifetimeCount returns how many entries were added since the store was
+// created, including ones since pruned from the window
+func (s *Store) LifetimeCount() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lifetimeCount
+}
+
+// GetMalformedCount returns how many truncated lines were skipped
+func (s *Store) GetMalformedCount() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.MalformedCount
+}
+
+// GetStatusTotals returns per-status counts of every entry added since
+// the store was created, sorted by status code. Unlike GetStatusCounts
+// these never drop as the window moves, so they suit monotonic counters.
+func (s *Store) GetStatusTotals() []StatusCountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return statusItems(s.statusTotals)
+}
+
+// statusItems converts status counts to items sorted by status code
+func statusItems(counts map[int]int64) []StatusCountItem {
 	if counts == nil {
 		return nil
 	}
@@ -337,39 +1257,140 @@ func (s *Store) GetStatusCounts(filterHost, filterIP string) []StatusCountItem {
 		return items[i].Status < items[j].Status
 	})
 
-	return items
+	return items
+}
+
+// GetMethodCounts returns HTTP method counts, most common first, for all
+// traffic or for a host or IP
+func (s *Store) GetMethodCounts(filterHost, filterIP string) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var counts map[string]int64
+
+	if filterHost != "" {
+		counts = s.hostToMethod[filterHost]
+	} else if filterIP != "" {
+		counts = s.ipToMethod[filterIP]
+	} else {
+		counts = s.MethodCounts
+	}
+
+	return s.topN(counts, len(counts))
+}
+
+// GetMethodCountsForPath returns HTTP method counts for a path, most
+// common first
+func (s *Store) GetMethodCountsForPath(path string) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := s.pathToMethod[path]
+	return s.topN(counts, len(counts))
+}
+
+// GetTopErrorCodes returns the top N Heroku error codes by count
+func (s *Store) GetTopErrorCodes(n int) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.topN(s.CodeCounts, n)
+}
+
+// ErrorCodeDesc returns the most recent description logged for an error
+// code (e.g. "Request timeout" for H12), or "" if none was seen
+func (s *Store) ErrorCodeDesc(code string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.codeDescs[code]
+}
+
+// GetTopHosts returns top N hosts by count
+func (s *Store) GetTopHosts(n int, filterIP string) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var counts map[string]int64
+
+	if filterIP != "" {
+		counts = s.ipToHosts[filterIP]
+	} else {
+		counts = s.HostCounts
+	}
+
+	return s.topN(counts, n)
+}
+
+// GetTopIPs returns top N IPs by count
+func (s *Store) GetTopIPs(n int, filterHost string) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var counts map[string]int64
+
+	if filterHost != "" {
+		counts = s.hostToIPs[filterHost]
+	} else {
+		counts = s.IPCounts
+	}
+
+	return s.topN(counts, n)
+}
+
+// GetTopErrorIPs returns IPs ranked by how many class (4 or 5) responses
+// they got, so a low-volume scanner racking up 404s stands out even
+// though it would never make GetTopIPs. Count is the error count.
+func (s *Store) GetTopErrorIPs(n int, class int) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for ip, statuses := range s.ipToStatus {
+		for status, count := range statuses {
+			if status/100 == class && count > 0 {
+				counts[ip] += count
+			}
+		}
+	}
+	return s.topN(counts, n)
+}
+
+// GetIPCount returns how many requests in the window came from ip
+func (s *Store) GetIPCount(ip string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.IPCounts[ip]
 }
 
-// GetTopHosts returns top N hosts by count
-func (s *Store) GetTopHosts(n int, filterIP string) []CountItem {
+// GetBottomHosts returns the N least frequent hosts, optionally for an
+// IP. Rare hosts are often scanners or misconfigured clients.
+func (s *Store) GetBottomHosts(n int, filterIP string) []CountItem {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var counts map[string]int64
-
+	counts := s.HostCounts
 	if filterIP != "" {
 		counts = s.ipToHosts[filterIP]
-	} else {
-		counts = s.HostCounts
 	}
-
-	return s.topN(counts, n)
+	return s.bottomN(counts, n)
 }
 
-// GetTopIPs returns top N IPs by count
-func (s *Store) GetTopIPs(n int, filterHost string) []CountItem {
+// GetTopHostsForPath returns top N hosts that requested a path
+func (s *Store) GetTopHostsForPath(n int, path string) []CountItem {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var counts map[string]int64
+	return s.topN(s.pathToHosts[path], n)
+}
 
-	if filterHost != "" {
-		counts = s.hostToIPs[filterHost]
-	} else {
-		counts = s.IPCounts
-	}
+// GetTopIPsForPath returns top N IPs that requested a path
+func (s *Store) GetTopIPsForPath(n int, path string) []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return s.topN(counts, n)
+	return s.topN(s.pathToIPs[path], n)
 }
 
 // GetTopPaths returns top N paths for a given host or IP
@@ -390,7 +1411,7 @@ func (s *Store) GetTopPaths(n int, host, ip string) []CountItem {
 	// Filter out excluded paths
 	filtered := make(map[string]int64)
 	for path, count := range counts {
-		if !isExcludedPath(path) {
+		if !s.isExcludedPath(path) {
 			filtered[path] = count
 		}
 	}
@@ -399,6 +1420,24 @@ func (s *Store) GetTopPaths(n int, host, ip string) []CountItem {
 }
 
 func (s *Store) topN(counts map[string]int64, n int) []CountItem {
+	return rankN(counts, n, func(a, b CountItem) bool {
+		return a.Count > b.Count
+	})
+}
+
+// bottomN returns the n least frequent items with a non-zero count. Low
+// counts tie often, so ties are ordered by label to keep rows steady.
+func (s *Store) bottomN(counts map[string]int64, n int) []CountItem {
+	return rankN(counts, n, func(a, b CountItem) bool {
+		if a.Count != b.Count {
+			return a.Count < b.Count
+		}
+		return a.Label < b.Label
+	})
+}
+
+// rankN returns the first n non-zero items in the order given by less
+func rankN(counts map[string]int64, n int, less func(a, b CountItem) bool) []CountItem {
 	if counts == nil {
 		return nil
 	}
@@ -411,7 +1450,7 @@ func (s *Store) topN(counts map[string]int64, n int) []CountItem {
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].Count > items[j].Count
+		return less(items[i], items[j])
 	})
 
 	if len(items) > n {
@@ -451,6 +1490,147 @@ func (s *Store) StartTime() time.Time {
 	return s.entries[0].Timestamp
 }
 
+// LatestTime returns when the most recent entry was recorded, or the zero
+// time if the store is empty
+func (s *Store) LatestTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.entries) == 0 {
+		return time.Time{}
+	}
+	return s.entries[len(s.entries)-1].Timestamp
+}
+
+// Window returns the configured data window (0 = keep all)
+func (s *Store) Window() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.window
+}
+
+// SetWindow changes the data window (0 = keep all). Narrowing takes
+// effect on the next Prune; widening can't bring back pruned entries.
+func (s *Store) SetWindow(window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window = window
+}
+
+// GetTLSBreakdown returns request counts per TLS version, most common first.
+// Entries without a tls= field are not included.
+func (s *Store) GetTLSBreakdown() []CountItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.topN(s.TLSCounts, len(s.TLSCounts))
+}
+
+// GetHostFirstSeen returns when a host was first seen, or the zero time
+// if it hasn't been seen or left the window over firstSeenRetention ago
+func (s *Store) GetHostFirstSeen(host string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.hostFirstSeen[host]
+}
+
+// GetIPFirstSeen returns when an IP was first seen, or the zero time if
+// it hasn't been seen or left the window over firstSeenRetention ago
+func (s *Store) GetIPFirstSeen(ip string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ipFirstSeen[ip]
+}
+
+// DebugStats describes how much the store is holding, for diagnostics
+type DebugStats struct {
+	Entries    int
+	MaxEntries int
+	Hosts      int // labels tracked; pruning drops labels whose count reaches zero
+	IPs        int
+	Paths      int
+}
+
+// DebugStats returns entry and label-cardinality counts, i.e. how much
+// the store is holding
+func (s *Store) DebugStats() DebugStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pathSet := make(map[string]bool)
+	for _, pathCounts := range s.hostToPaths {
+		for path := range pathCounts {
+			pathSet[path] = true
+		}
+	}
+
+	return DebugStats{
+		Entries:    len(s.entries),
+		MaxEntries: s.maxEntries,
+		Hosts:      len(s.HostCounts),
+		IPs:        len(s.IPCounts),
+		Paths:      len(pathSet),
+	}
+}
+
+// GetNewUniqueIPRate returns how many IPs were first seen within the
+// given recent window, as a per-minute rate. A surge of new IPs is a
+// better scan signal than raw request rate. Windows past
+// firstSeenRetention undercount; an empty window gives 0.
+func (s *Store) GetNewUniqueIPRate(window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// First sightings are queued in arrival order, so walk back from the
+	// newest until we leave the window
+	cutoff := s.now().Add(-window)
+	count := 0
+	for i := len(s.ipFirstSeenQueue) - 1; i >= 0; i-- {
+		f := s.ipFirstSeenQueue[i]
+		if !f.at.After(cutoff) {
+			break
+		}
+		// A restored state can hold an earlier first-seen time
+		if f.label != "(unknown)" && s.ipFirstSeen[f.label].Equal(f.at) {
+			count++
+		}
+	}
+
+	return float64(count) / window.Minutes()
+}
+
+// Apdex returns the window's Apdex score from 0 to 1:
+// (satisfied + tolerated/2) / total, where satisfied responses take at most
+// satisfiedMs and tolerated ones at most toleratedMs (by convention 4x the
+// former). 101s are excluded like the other timing stats; without timed
+// requests the score is 1.
+func (s *Store) Apdex(satisfiedMs, toleratedMs int) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.serviceTimes) == 0 {
+		return 1
+	}
+	var satisfied, tolerated int
+	for _, ms := range s.serviceTimes {
+		switch {
+		case ms <= satisfiedMs:
+			satisfied++
+		case ms <= toleratedMs:
+			tolerated++
+		}
+	}
+	return (float64(satisfied) + float64(tolerated)/2) / float64(len(s.serviceTimes))
+}
+
 // GetErrorRates returns the percentage of 4xx and 5xx responses
 func (s *Store) GetErrorRates() (rate4xx, rate5xx float64) {
 	s.mu.RLock()
@@ -504,6 +1684,45 @@ func (s *Store) GetUniqueCounts() (hosts, ips, paths int) {
 	return
 }
 
+// UnknownHostCount returns how many requests in the window had no Host
+// header or a bare IP as their host, which usually means scanners or
+// misrouted traffic
+func (s *Store) UnknownHostCount() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int64
+	for host, count := range s.HostCounts {
+		if host == "(unknown)" || isIPHost(host) {
+			total += count
+		}
+	}
+	return total
+}
+
+// isIPHost reports whether host is an IP address, with or without a port
+func isIPHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return net.ParseIP(strings.Trim(host, "[]")) != nil
+}
+
+// GetUniqueIPsInWindow returns how many distinct IPs sent requests in the
+// last d. This walks every entry in that span, so call it once per
+// refresh rather than per key press.
+func (s *Store) GetUniqueIPsInWindow(d time.Duration) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := s.now().Add(-d)
+	seen := make(map[string]struct{})
+	for i := len(s.entries) - 1; i >= 0 && s.entries[i].Timestamp.After(cutoff); i-- {
+		seen[orUnknown(s.entries[i].IP)] = struct{}{}
+	}
+	return len(seen)
+}
+
 // GetCurrentRate returns the request rate over the given window
 func (s *Store) GetCurrentRate(window time.Duration) float64 {
 	s.mu.RLock()
@@ -513,7 +1732,7 @@ func (s *Store) GetCurrentRate(window time.Duration) float64 {
 		return 0
 	}
 
-	cutoff := time.Now().Add(-window)
+	cutoff := s.now().Add(-window)
 	count := 0
 
 	// Count entries within the window (iterate backwards for efficiency)
@@ -528,6 +1747,113 @@ func (s *Store) GetCurrentRate(window time.Duration) float64 {
 	return float64(count) / window.Seconds()
 }
 
+// GetRateBuckets returns the request rate (req/s) in each of the last count
+// buckets of the given width, oldest first. The final bucket ends now.
+func (s *Store) GetRateBuckets(bucket time.Duration, count int) []float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := make([]float64, count)
+	if count <= 0 || bucket <= 0 {
+		return rates
+	}
+
+	now := s.now()
+	cutoff := now.Add(-bucket * time.Duration(count))
+
+	// Entries are in arrival order, so walk back until we leave the range
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		ts := s.entries[i].Timestamp
+		if !ts.After(cutoff) {
+			break
+		}
+		idx := count - 1 - int(now.Sub(ts)/bucket)
+		if idx >= 0 && idx < count {
+			rates[idx]++
+		}
+	}
+
+	for i := range rates {
+		rates[i] /= bucket.Seconds()
+	}
+	return rates
+}
+
+// GetRateForHost returns the host's requests per second over the most
+// recent window, unlike its count, which covers the whole data window
+func (s *Store) GetRateForHost(host string, window time.Duration) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := s.now().Add(-window)
+	count := 0
+	for i := len(s.entries) - 1; i >= 0 && s.entries[i].Timestamp.After(cutoff); i-- {
+		if orUnknown(s.entries[i].Host) == host {
+			count++
+		}
+	}
+	return float64(count) / window.Seconds()
+}
+
+// GetThroughput returns response bytes per second over the given recent
+// window
+func (s *Store) GetThroughput(window time.Duration) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.entries) == 0 {
+		return 0
+	}
+
+	cutoff := s.now().Add(-window)
+	var total int64
+
+	// Sum entries within the window (iterate backwards for efficiency)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].Timestamp.After(cutoff) {
+			total += int64(s.entries[i].Bytes)
+		} else {
+			break
+		}
+	}
+
+	return float64(total) / window.Seconds()
+}
+
+// GetHottestPath returns the path with the most requests over the given
+// recent window and its rate in req/s, skipping excluded paths. Returns
+// an empty path if nothing arrived in the window.
+func (s *Store) GetHottestPath(window time.Duration) (string, float64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := s.now().Add(-window)
+	counts := make(map[string]int)
+
+	// Entries are in arrival order, so walk back until we leave the window
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if !e.Timestamp.After(cutoff) {
+			break
+		}
+		path := orUnknown(e.Path)
+		if !s.isExcludedPath(path) {
+			counts[path]++
+		}
+	}
+
+	var hottest string
+	var best int
+	for path, count := range counts {
+		// Break ties by label so the callout doesn't flicker between equals
+		if count > best || (count == best && path < hottest) {
+			hottest, best = path, count
+		}
+	}
+
+	return hottest, float64(best) / window.Seconds()
+}
+
 // ErrorRates holds separate 4xx and 5xx error rates
 type ErrorRates struct {
 	Rate4xx float64
@@ -577,6 +1903,46 @@ func (s *Store) calculateErrorRates(statusCounts map[int]int64) ErrorRates {
 	}
 }
 
+// BytesSplit holds average response sizes for successful vs errored requests
+type BytesSplit struct {
+	OKCount  int64
+	ErrCount int64
+	AvgOK    int64 // bytes, over 1xx-3xx responses
+	AvgErr   int64 // bytes, over 4xx/5xx responses
+}
+
+// GetBytesSplitForHost returns average response sizes for a host's
+// successful and errored requests, computed from retained entries
+func (s *Store) GetBytesSplitForHost(host string) BytesSplit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var split BytesSplit
+	var okBytes, errBytes int64
+
+	for _, e := range s.entries {
+		h := orUnknown(e.Host)
+		if h != host {
+			continue
+		}
+		if e.Status >= 400 {
+			split.ErrCount++
+			errBytes += int64(e.Bytes)
+		} else {
+			split.OKCount++
+			okBytes += int64(e.Bytes)
+		}
+	}
+
+	if split.OKCount > 0 {
+		split.AvgOK = okBytes / split.OKCount
+	}
+	if split.ErrCount > 0 {
+		split.AvgErr = errBytes / split.ErrCount
+	}
+	return split
+}
+
 // Trend represents error rate trend direction
 type Trend int
 
@@ -586,6 +1952,57 @@ const (
 	TrendDown         // Error rate decreasing (good)
 )
 
+// trendZ is the z-score a weighted trend must reach (95% confidence)
+const trendZ = 1.96
+
+// Trend defaults: a 2-point error-rate shift, with at least 10 requests
+// in each period
+const (
+	DefaultTrendThreshold  = 0.02
+	DefaultTrendMinSamples = 10
+)
+
+// SetTrendThresholds sets the error-rate shift, as a fraction, that makes
+// a trend, and how many requests each period needs before one is called.
+// Lower both for low-traffic apps; raise them where the defaults twitch.
+func (s *Store) SetTrendThresholds(threshold float64, minSamples int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trendThreshold = threshold
+	s.trendMinSamples = minSamples
+}
+
+// TrendThreshold returns the error-rate shift that makes a trend
+func (s *Store) TrendThreshold() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trendThreshold
+}
+
+// SetWeightedTrend makes the error-rate trend volume-aware: besides the
+// threshold shift, the shift must be significant under a two-proportion
+// z-test, so small samples during ramp-up/down don't make it jumpy.
+func (s *Store) SetWeightedTrend(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.weightedTrend = enabled
+}
+
+// significantShift reports whether the error fractions of two periods
+// differ beyond what their sample sizes would explain by chance
+func significantShift(recentErrors, recentTotal, oldErrors, oldTotal int64) bool {
+	pooled := float64(recentErrors+oldErrors) / float64(recentTotal+oldTotal)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(recentTotal) + 1/float64(oldTotal)))
+	if se == 0 {
+		return false // no errors (or all errors) in both periods
+	}
+	diff := float64(recentErrors)/float64(recentTotal) - float64(oldErrors)/float64(oldTotal)
+	return math.Abs(diff)/se >= trendZ
+}
+
 // GetTrend compares error rate in recent period vs previous period
 // Returns the trend and the rate difference for hysteresis handling
 func (s *Store) GetTrend(period time.Duration) Trend {
@@ -602,7 +2019,7 @@ func (s *Store) GetTrendWithDiff(period time.Duration) (float64, Trend) {
 		return 0, TrendStable
 	}
 
-	now := time.Now()
+	now := s.now()
 	recentCutoff := now.Add(-period)
 	oldCutoff := now.Add(-2 * period)
 
@@ -626,7 +2043,8 @@ func (s *Store) GetTrendWithDiff(period time.Duration) (float64, Trend) {
 	}
 
 	// Need sufficient data in both periods
-	if recentTotal < 10 || oldTotal < 10 {
+	minSamples := int64(s.trendMinSamples)
+	if recentTotal < minSamples || oldTotal < minSamples {
 		return 0, TrendStable
 	}
 
@@ -635,16 +2053,69 @@ func (s *Store) GetTrendWithDiff(period time.Duration) (float64, Trend) {
 
 	diff := recentRate - oldRate
 
-	// Use 2 percentage points as threshold for significance
-	if diff > 0.02 {
+	if s.weightedTrend && !significantShift(recentErrors, recentTotal, oldErrors, oldTotal) {
+		return diff, TrendStable
+	}
+
+	if diff > s.trendThreshold {
 		return diff, TrendUp
-	} else if diff < -0.02 {
+	} else if diff < -s.trendThreshold {
 		return diff, TrendDown
 	}
 
 	return diff, TrendStable
 }
 
+// LatencyTrendThreshold is the relative p95 change that counts as a
+// latency trend (0.2 = 20% slower or faster than the previous period)
+const LatencyTrendThreshold = 0.2
+
+// GetLatencyTrend compares p95 service time in the recent period vs the
+// previous period. Up means slower.
+func (s *Store) GetLatencyTrend(period time.Duration) Trend {
+	_, trend := s.GetLatencyTrendWithDiff(period)
+	return trend
+}
+
+// GetLatencyTrendWithDiff returns both the relative p95 change and the
+// computed trend
+func (s *Store) GetLatencyTrendWithDiff(period time.Duration) (float64, Trend) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	recentCutoff := now.Add(-period)
+	oldCutoff := now.Add(-2 * period)
+
+	var recent, old []int
+	for _, e := range s.entries {
+		if e.Status == 101 {
+			continue
+		}
+		if e.Timestamp.After(recentCutoff) {
+			recent = append(recent, e.Service)
+		} else if e.Timestamp.After(oldCutoff) {
+			old = append(old, e.Service)
+		}
+	}
+
+	// Need sufficient data in both periods
+	if len(recent) < s.trendMinSamples || len(old) < s.trendMinSamples {
+		return 0, TrendStable
+	}
+
+	_, recentP95, _ := percentiles(sortedCopy(recent))
+	_, oldP95, _ := percentiles(sortedCopy(old))
+	diff := float64(recentP95-oldP95) / float64(max(oldP95, 1))
+
+	if diff > LatencyTrendThreshold {
+		return diff, TrendUp
+	} else if diff < -LatencyTrendThreshold {
+		return diff, TrendDown
+	}
+	return diff, TrendStable
+}
+
 // addEntryAtTime is a helper for testing - adds entry with specific timestamp
 func (s *Store) addEntryAtTime(e *parser.Entry, t time.Time) {
 	if e == nil {
@@ -663,7 +2134,7 @@ func (s *Store) GetAllPaths(n int) []CountItem {
 	pathCounts := make(map[string]int64)
 	for _, paths := range s.hostToPaths {
 		for path, count := range paths {
-			if count > 0 && !isExcludedPath(path) {
+			if count > 0 && !s.isExcludedPath(path) {
 				pathCounts[path] += count
 			}
 		}
@@ -677,31 +2148,5 @@ func (s *Store) GetErrorRatesForPath(path string) ErrorRates {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// We need to track path-to-status mapping
-	// For now, we'll iterate through entries
-	var total, count4xx, count5xx int64
-
-	for _, e := range s.entries {
-		p := e.Path
-		if p == "" {
-			p = "(unknown)"
-		}
-		if p == path {
-			total++
-			if e.Status >= 400 && e.Status < 500 {
-				count4xx++
-			} else if e.Status >= 500 {
-				count5xx++
-			}
-		}
-	}
-
-	if total == 0 {
-		return ErrorRates{}
-	}
-
-	return ErrorRates{
-		Rate4xx: float64(count4xx) * 100 / float64(total),
-		Rate5xx: float64(count5xx) * 100 / float64(total),
-	}
+	return s.calculateErrorRates(s.pathToStatus[path])
 }
@@ -1,6 +1,10 @@
 package store
 
 import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
 	"testing"
 	"time"
 
@@ -102,6 +106,136 @@ func TestAdd_MultipleEntries(t *testing.T) {
 	}
 }
 
+func TestAddBatch(t *testing.T) {
+	s := New(0)
+	s.SetCanonicalHosts(true, false)
+
+	s.AddBatch([]*parser.Entry{
+		{Status: 200, Host: "Example.com:443", IP: "1.2.3.4"},
+		nil,
+		{Status: 503, Host: "example.com", IP: "5.6.7.8"},
+	})
+
+	if s.TotalCount != 2 {
+		t.Errorf("expected TotalCount 2 with the nil skipped, got %d", s.TotalCount)
+	}
+	if s.HostCounts["example.com"] != 2 || s.StatusCounts[503] != 1 {
+		t.Errorf("expected batch counted like Add, got hosts %v, statuses %v", s.HostCounts, s.StatusCounts)
+	}
+}
+
+func TestSetDroppedPaths(t *testing.T) {
+	s := New(0)
+	s.SetDroppedPaths([]string{"/health", "/elb*"})
+	s.SetExcludedPaths([]string{"/robots.txt"})
+
+	s.Add(&parser.Entry{Status: 200, Service: 1, Path: "/health"})
+	s.AddBatch([]*parser.Entry{
+		{Status: 200, Service: 1, Path: "/elb-status"},
+		{Status: 200, Service: 500, Path: "/robots.txt"},
+		{Status: 200, Service: 100, Path: "/healthz"},
+	})
+
+	// Dropped paths never count; excluded ones still do
+	if s.TotalCount != 2 {
+		t.Errorf("expected 2 entries counted, got %d", s.TotalCount)
+	}
+	if stats := s.GetStats(); stats.MaxService != 500 {
+		t.Errorf("expected the excluded path still in latency, got max %d", stats.MaxService)
+	}
+	if paths := s.GetAllPaths(10); len(paths) != 1 || paths[0].Label != "/healthz" {
+		t.Errorf("expected only /healthz shown, got %v", paths)
+	}
+}
+
+func TestSetExcludeFromTotals(t *testing.T) {
+	add := func(s *Store) {
+		s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/robots.txt"})
+		s.Add(&parser.Entry{Status: 200, Host: "b.com", Path: "/robots.txt"})
+		s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users"})
+	}
+
+	// By default excluded paths are hidden but counted
+	s := New(0)
+	s.SetExcludedPaths([]string{"/robots.txt"})
+	add(s)
+	if s.TotalCount != 3 || s.GetExcludedCount() != 2 {
+		t.Errorf("expected 3 counted with 2 excluded, got %d and %d", s.TotalCount, s.GetExcludedCount())
+	}
+
+	s = New(0)
+	s.SetExcludedPaths([]string{"/robots.txt"})
+	s.SetExcludeFromTotals(true)
+	add(s)
+	if s.TotalCount != 1 || s.GetExcludedCount() != 0 {
+		t.Errorf("expected only /users counted, got %d with %d excluded", s.TotalCount, s.GetExcludedCount())
+	}
+}
+
+func TestUnknownHostCount(t *testing.T) {
+	s := New(0)
+	for _, host := range []string{"", "", "10.0.0.1", "10.0.0.1:80", "[::1]:443", "example.com", "example.com:443"} {
+		s.Add(&parser.Entry{Status: 200, Host: host, IP: "1.1.1.1"})
+	}
+
+	if got := s.UnknownHostCount(); got != 5 {
+		t.Errorf("expected 5 requests without a named host, got %d", got)
+	}
+}
+
+func TestGetTopErrorIPs(t *testing.T) {
+	s := New(0)
+	for i := 0; i < 50; i++ {
+		s.Add(&parser.Entry{Status: 200, IP: "1.1.1.1"})
+	}
+	s.Add(&parser.Entry{Status: 404, IP: "1.1.1.1"})
+	for i := 0; i < 4; i++ {
+		s.Add(&parser.Entry{Status: 404, IP: "6.6.6.6"})
+	}
+	s.Add(&parser.Entry{Status: 503, IP: "2.2.2.2"})
+
+	got := s.GetTopErrorIPs(10, 4)
+	if len(got) != 2 || got[0].Label != "6.6.6.6" || got[0].Count != 4 || got[1].Count != 1 {
+		t.Errorf("expected 6.6.6.6 (4) then 1.1.1.1 (1), got %v", got)
+	}
+	if got := s.GetTopErrorIPs(10, 5); len(got) != 1 || got[0].Label != "2.2.2.2" {
+		t.Errorf("expected only 2.2.2.2 for 5xx, got %v", got)
+	}
+}
+
+func TestGetRateForHost(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+	for i := 0; i < 30; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com"}, now.Add(-time.Minute))
+	}
+	for i := 0; i < 10; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com"}, now.Add(-time.Second))
+		s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-time.Second))
+	}
+
+	if got := s.GetRateForHost("a.com", 10*time.Second); got != 1 {
+		t.Errorf("expected 1 req/s for recent a.com traffic only, got %v", got)
+	}
+	if got := s.GetRateForHost("(unknown)", 10*time.Second); got != 1 {
+		t.Errorf("expected 1 req/s for hostless requests, got %v", got)
+	}
+}
+
+func TestAdd_MalformedExcluded(t *testing.T) {
+	s := New(0)
+
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: 10, Host: "a.com"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Malformed: true})
+
+	if s.TotalCount != 1 || s.StatusCounts[0] != 0 {
+		t.Errorf("expected the malformed line left out of stats, got total %d, statuses %v", s.TotalCount, s.StatusCounts)
+	}
+	if got := s.GetMalformedCount(); got != 1 {
+		t.Errorf("expected MalformedCount 1, got %d", got)
+	}
+}
+
 func TestGetStats_Empty(t *testing.T) {
 	s := New(0)
 	stats := s.GetStats()
@@ -159,6 +293,24 @@ func TestGetStats_Percentiles(t *testing.T) {
 	}
 }
 
+func TestGetStats_ConnectPercentiles(t *testing.T) {
+	s := New(0)
+
+	// Connect times 1-100, in reverse to check they get sorted
+	for i := 100; i >= 1; i-- {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: 10, Connect: i})
+	}
+
+	stats := s.GetStats()
+	if stats.P50Connect != 50 || stats.P95Connect != 95 || stats.P99Connect != 99 {
+		t.Errorf("expected connect p50/p95/p99 50/95/99, got %d/%d/%d",
+			stats.P50Connect, stats.P95Connect, stats.P99Connect)
+	}
+	if stats.AvgConnect != 50 || stats.MaxConnect != 100 {
+		t.Errorf("expected connect avg 50 / max 100, got %d / %d", stats.AvgConnect, stats.MaxConnect)
+	}
+}
+
 func TestGetStatusCounts(t *testing.T) {
 	s := New(0)
 
@@ -194,6 +346,59 @@ func TestGetStatusCounts(t *testing.T) {
 	}
 }
 
+func TestGetStatusTotals_SurvivePrune(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 200})
+	s.Add(&parser.Entry{Status: 500})
+	s.pruneOldest(2)
+
+	if counts := s.GetStatusCounts("", ""); len(counts) != 0 {
+		t.Errorf("expected window counts to be pruned, got %v", counts)
+	}
+	totals := s.GetStatusTotals()
+	if len(totals) != 2 || totals[0] != (StatusCountItem{200, 1}) || totals[1] != (StatusCountItem{500, 1}) {
+		t.Errorf("expected totals to survive the prune, got %v", totals)
+	}
+}
+
+func TestGetMethodCounts(t *testing.T) {
+	s := New(0)
+
+	s.Add(&parser.Entry{Status: 200, Method: "GET", Host: "a.com", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 200, Method: "GET", Host: "a.com", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 201, Method: "POST", Host: "a.com", IP: "2.2.2.2"})
+	s.Add(&parser.Entry{Status: 200, Method: "POST", Host: "b.com", IP: "2.2.2.2"})
+	s.Add(&parser.Entry{Status: 200, Method: "POST", Host: "b.com", IP: "2.2.2.2"})
+	s.Add(&parser.Entry{Status: 200, Host: "b.com", IP: "2.2.2.2"})
+
+	// Unfiltered, most common first
+	counts := s.GetMethodCounts("", "")
+	if len(counts) != 3 {
+		t.Fatalf("expected 3 methods, got %d", len(counts))
+	}
+	if counts[0].Label != "POST" || counts[0].Count != 3 {
+		t.Errorf("expected POST count 3 first, got %s count %d", counts[0].Label, counts[0].Count)
+	}
+
+	// Filtered by host
+	counts = s.GetMethodCounts("a.com", "")
+	if len(counts) != 2 || counts[0].Label != "GET" || counts[0].Count != 2 {
+		t.Errorf("expected GET 2 first for a.com, got %v", counts)
+	}
+
+	// Filtered by IP, with the missing method normalized
+	counts = s.GetMethodCounts("", "2.2.2.2")
+	found := false
+	for _, c := range counts {
+		if c.Label == "(unknown)" && c.Count == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected (unknown) count 1 for 2.2.2.2, got %v", counts)
+	}
+}
+
 func TestGetTopHosts(t *testing.T) {
 	s := New(0)
 
@@ -260,6 +465,34 @@ func TestGetTopHosts_FilteredByIP(t *testing.T) {
 	}
 }
 
+func TestGetBottomHosts(t *testing.T) {
+	s := New(0)
+
+	for i := 0; i < 3; i++ {
+		s.Add(&parser.Entry{Status: 200, Host: "busy.com", IP: "1.1.1.1"})
+	}
+	s.Add(&parser.Entry{Status: 200, Host: "mid.com", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 200, Host: "mid.com", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 404, Host: "scan-b.com", IP: "6.6.6.6"})
+	s.Add(&parser.Entry{Status: 404, Host: "scan-a.com", IP: "6.6.6.6"})
+
+	// Least first, ties by label
+	hosts := s.GetBottomHosts(3, "")
+	want := []string{"scan-a.com", "scan-b.com", "mid.com"}
+	if len(hosts) != len(want) {
+		t.Fatalf("expected %d hosts, got %v", len(want), hosts)
+	}
+	for i, label := range want {
+		if hosts[i].Label != label {
+			t.Errorf("hosts[%d] = %s, want %s", i, hosts[i].Label, label)
+		}
+	}
+
+	if hosts := s.GetBottomHosts(10, "1.1.1.1"); len(hosts) != 2 || hosts[0].Label != "mid.com" {
+		t.Errorf("expected mid.com then busy.com for 1.1.1.1, got %v", hosts)
+	}
+}
+
 func TestGetTopIPs_FilteredByHost(t *testing.T) {
 	s := New(0)
 
@@ -444,6 +677,28 @@ func TestStatus101_StillCountedInStatusCounts(t *testing.T) {
 	}
 }
 
+func TestPrune_LogClock(t *testing.T) {
+	s := New(time.Minute)
+	s.SetLogClock(true)
+
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	s.Add(&parser.Entry{Timestamp: base, Status: 200, Host: "old.com"})
+	s.Add(&parser.Entry{Timestamp: base.Add(90 * time.Second), Status: 200, Host: "a.com"})
+	s.Add(&parser.Entry{Timestamp: base.Add(2 * time.Minute), Status: 200, Host: "a.com"})
+	s.Prune()
+
+	// Only the first entry is over a minute older than the newest
+	if s.TotalCount != 2 || s.HostCounts["old.com"] != 0 {
+		t.Errorf("expected 2 entries within a minute of the newest, got %d (old.com %d)", s.TotalCount, s.HostCounts["old.com"])
+	}
+	if !s.Now().Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("expected Now to be the newest log time, got %v", s.Now())
+	}
+	if rate := s.GetCurrentRate(time.Minute); rate != 2.0/60 {
+		t.Errorf("expected rate measured from the newest entry, got %v", rate)
+	}
+}
+
 func TestPrune_WithStatus101(t *testing.T) {
 	s := New(100 * time.Millisecond)
 
@@ -667,6 +922,65 @@ func TestPathTracking_ByIP_Prune(t *testing.T) {
 	}
 }
 
+func TestLifetimeCount_SurvivesPrune(t *testing.T) {
+	s := New(time.Minute)
+	s.Add(&parser.Entry{Timestamp: time.Now().Add(-2 * time.Minute), Status: 200})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200})
+	s.Prune()
+
+	if s.TotalCount != 1 || s.LifetimeCount() != 2 {
+		t.Errorf("expected 1 in window and 2 lifetime, got %d and %d", s.TotalCount, s.LifetimeCount())
+	}
+}
+
+func TestGetServiceHistogram(t *testing.T) {
+	s := New(0)
+	for _, ms := range []int{0, 9, 10, 49, 50, 500, 6000} {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: ms})
+	}
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 101, Service: 60000})
+
+	got := s.GetServiceHistogram([]int{10, 50, 100})
+	want := []HistogramBucket{{0, 10, 2}, {10, 50, 2}, {50, 100, 1}, {100, 0, 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGetUniqueIPsInWindow(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+	s.Add(&parser.Entry{Timestamp: now.Add(-10 * time.Minute), IP: "9.9.9.9"})
+	s.Add(&parser.Entry{Timestamp: now.Add(-2 * time.Minute), IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Timestamp: now, IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Timestamp: now, IP: "2.2.2.2"})
+	s.Add(&parser.Entry{Timestamp: now})
+
+	if got := s.GetUniqueIPsInWindow(5 * time.Minute); got != 3 {
+		t.Errorf("expected 1.1.1.1, 2.2.2.2, and (unknown), got %d", got)
+	}
+	if got := s.GetUniqueIPsInWindow(time.Hour); got != 4 {
+		t.Errorf("expected 4 within the hour, got %d", got)
+	}
+}
+
+func TestApdex(t *testing.T) {
+	s := New(0)
+	if got := s.Apdex(100, 400); got != 1 {
+		t.Errorf("expected 1 without data, got %v", got)
+	}
+
+	// 2 satisfied, 1 tolerated, 1 frustrated; the 101 is ignored
+	for _, ms := range []int{50, 100, 400, 401} {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Service: ms})
+	}
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 101, Service: 60000})
+
+	if got := s.Apdex(100, 400); got != 0.625 {
+		t.Errorf("expected (2 + 1/2) / 4 = 0.625, got %v", got)
+	}
+}
+
 func TestGetErrorRates(t *testing.T) {
 	s := New(0)
 
@@ -750,6 +1064,55 @@ func TestGetCurrentRate(t *testing.T) {
 	}
 }
 
+func TestGetThroughput(t *testing.T) {
+	s := New(0)
+
+	now := time.Now()
+
+	// Old traffic outside the window
+	s.addEntryAtTime(&parser.Entry{Status: 200, Bytes: 1000000}, now.Add(-30*time.Second))
+
+	// 4 recent entries; one without bytes= counts as 0
+	for _, b := range []int{1000, 2000, 0, 2000} {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Bytes: b}, now.Add(-time.Second))
+	}
+
+	// 5000 bytes in 10 seconds = 500 B/s
+	if got := s.GetThroughput(10 * time.Second); got != 500 {
+		t.Errorf("expected 500 B/s, got %.2f", got)
+	}
+	if s.TotalBytes != 1005000 {
+		t.Errorf("expected running total 1005000, got %d", s.TotalBytes)
+	}
+}
+
+func TestGetRateBuckets(t *testing.T) {
+	s := New(0)
+
+	now := time.Now()
+
+	// Outside the 5s range
+	s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-10*time.Second))
+
+	// Two in the oldest bucket, four in the newest
+	s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-4500*time.Millisecond))
+	s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-4500*time.Millisecond))
+	for i := 0; i < 4; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-200*time.Millisecond))
+	}
+
+	buckets := s.GetRateBuckets(time.Second, 5)
+	want := []float64{2, 0, 0, 0, 4}
+	if len(buckets) != len(want) {
+		t.Fatalf("expected %d buckets, got %d", len(want), len(buckets))
+	}
+	for i := range want {
+		if buckets[i] != want[i] {
+			t.Errorf("bucket %d: expected %.1f/s, got %.1f/s", i, want[i], buckets[i])
+		}
+	}
+}
+
 func TestGetErrorRatesForHost(t *testing.T) {
 	s := New(0)
 
@@ -826,6 +1189,124 @@ func TestGetTrend(t *testing.T) {
 	}
 }
 
+func TestSetTrendThresholds(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+
+	// 5 requests per period, errors 0% then 20%: too thin for the defaults
+	for i := 0; i < 5; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-45*time.Second))
+	}
+	for i := 0; i < 4; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-15*time.Second))
+	}
+	s.addEntryAtTime(&parser.Entry{Status: 500}, now.Add(-15*time.Second))
+
+	if trend := s.GetTrend(30 * time.Second); trend != TrendStable {
+		t.Errorf("expected TrendStable under the default minimum, got %v", trend)
+	}
+
+	s.SetTrendThresholds(0.02, 5)
+	if trend := s.GetTrend(30 * time.Second); trend != TrendUp {
+		t.Errorf("expected TrendUp with a 5-sample minimum, got %v", trend)
+	}
+
+	// A 20-point shift no longer counts once the threshold is 25 points
+	s.SetTrendThresholds(0.25, 5)
+	if trend := s.GetTrend(30 * time.Second); trend != TrendStable {
+		t.Errorf("expected TrendStable under a 25-point threshold, got %v", trend)
+	}
+	if got := s.TrendThreshold(); got != 0.25 {
+		t.Errorf("expected TrendThreshold 0.25, got %v", got)
+	}
+}
+
+func TestGetLatencyTrend(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+
+	// Same error rate throughout, but p95 climbs from 100ms to 300ms
+	for i := 0; i < 20; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Service: 100}, now.Add(-45*time.Second))
+		s.addEntryAtTime(&parser.Entry{Status: 200, Service: 300}, now.Add(-15*time.Second))
+	}
+
+	if trend := s.GetTrend(30 * time.Second); trend != TrendStable {
+		t.Errorf("expected a stable error trend, got %v", trend)
+	}
+	diff, trend := s.GetLatencyTrendWithDiff(30 * time.Second)
+	if trend != TrendUp || diff != 2 {
+		t.Errorf("expected TrendUp with p95 200%% higher, got %v (%.2f)", trend, diff)
+	}
+
+	// Too few recent samples to call it
+	s = New(0)
+	for i := 0; i < 20; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Service: 100}, now.Add(-45*time.Second))
+	}
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 900}, now.Add(-15*time.Second))
+	if trend := s.GetLatencyTrend(30 * time.Second); trend != TrendStable {
+		t.Errorf("expected TrendStable on thin data, got %v", trend)
+	}
+}
+
+func TestGetTrend_WeightedIgnoresLowVolumeNoise(t *testing.T) {
+	now := time.Now()
+	fill := func(s *Store) {
+		// Old period: 12 requests, no errors
+		for i := 0; i < 12; i++ {
+			s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-45*time.Second))
+		}
+		// Recent period: 12 requests, 2 errors (~17%) - big jump, tiny sample
+		for i := 0; i < 10; i++ {
+			s.addEntryAtTime(&parser.Entry{Status: 200}, now.Add(-15*time.Second))
+		}
+		for i := 0; i < 2; i++ {
+			s.addEntryAtTime(&parser.Entry{Status: 500}, now.Add(-15*time.Second))
+		}
+	}
+
+	unweighted := New(0)
+	fill(unweighted)
+	if trend := unweighted.GetTrend(30 * time.Second); trend != TrendUp {
+		t.Errorf("expected unweighted trend to fire on noise, got %v", trend)
+	}
+
+	weighted := New(0)
+	weighted.SetWeightedTrend(true)
+	fill(weighted)
+	if trend := weighted.GetTrend(30 * time.Second); trend != TrendStable {
+		t.Errorf("expected weighted trend to ignore low-volume noise, got %v", trend)
+	}
+}
+
+func TestGetTrend_WeightedFiresOnHighVolumeShift(t *testing.T) {
+	s := New(0)
+	s.SetWeightedTrend(true)
+	now := time.Now()
+
+	// Old period: 1000 requests, 2% errors
+	for i := 0; i < 1000; i++ {
+		status := 200
+		if i < 20 {
+			status = 500
+		}
+		s.addEntryAtTime(&parser.Entry{Status: status}, now.Add(-45*time.Second))
+	}
+	// Recent period: 1000 requests, 5% errors
+	for i := 0; i < 1000; i++ {
+		status := 200
+		if i < 50 {
+			status = 500
+		}
+		s.addEntryAtTime(&parser.Entry{Status: status}, now.Add(-15*time.Second))
+	}
+
+	if trend := s.GetTrend(30 * time.Second); trend != TrendUp {
+		t.Errorf("expected weighted trend to fire on a genuine shift, got %v", trend)
+	}
+}
+
 func BenchmarkAdd(b *testing.B) {
 	s := New(5 * time.Minute)
 	entry := &parser.Entry{
@@ -843,6 +1324,52 @@ func BenchmarkAdd(b *testing.B) {
 	}
 }
 
+// BenchmarkAddParallel adds from several goroutines with path rewriting on,
+// as when the syslog listener serves multiple drains, while another
+// goroutine keeps reading like the UI refresh
+func BenchmarkAddParallel(b *testing.B) {
+	s := New(5 * time.Minute)
+	s.SetPathPatterns([]PathPattern{
+		{Re: regexp.MustCompile(`^/api/v\d+/orders/\d+$`), Label: "/api/orders/:id"},
+		{Re: regexp.MustCompile(`^/api/v\d+/users/[^/]+/avatar$`), Label: "/api/users/:id/avatar"},
+	})
+	s.SetNormalizePaths(true)
+	s.SetCanonicalHosts(true, false)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		tick := time.NewTicker(time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				s.GetStats()
+				s.GetTopHosts(20, "")
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			s.Add(&parser.Entry{
+				Timestamp: time.Now(),
+				Status:    200,
+				Service:   i % 500,
+				Connect:   1,
+				Host:      "Example.com:443",
+				IP:        "1.2.3.4",
+				Path:      fmt.Sprintf("/api/v2/items/%d/comments/%d", i%100, i),
+			})
+		}
+	})
+}
+
 func BenchmarkGetStats(b *testing.B) {
 	s := New(0)
 	for i := 0; i < 10000; i++ {
@@ -906,6 +1433,21 @@ func TestGetErrorRatesForPath(t *testing.T) {
 	}
 }
 
+func TestGetErrorRatesForPath_FollowsPruneOldest(t *testing.T) {
+	s := New(0)
+
+	s.Add(&parser.Entry{Status: 500, Host: "a.com", Path: "/users", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Status: 404, Host: "a.com", Path: "/users", IP: "1.1.1.1"})
+	s.pruneOldest(1)
+
+	// The 500 was evicted, leaving one 200 and one 404
+	rates := s.GetErrorRatesForPath("/users")
+	if rates.Rate4xx != 50 || rates.Rate5xx != 0 {
+		t.Errorf("expected 50%% 4xx / 0%% 5xx after eviction, got %+v", rates)
+	}
+}
+
 func TestGetAllPaths_Empty(t *testing.T) {
 	s := New(0)
 	paths := s.GetAllPaths(10)
@@ -956,3 +1498,835 @@ func TestExcludedPaths(t *testing.T) {
 		t.Errorf("expected 2 paths from GetAllPaths, got %d", len(allPaths))
 	}
 }
+
+func TestSetExcludedPaths(t *testing.T) {
+	s := New(0)
+	s.SetExcludedPaths([]string{"/health", "/internal/*"})
+
+	s.Add(&parser.Entry{Host: "a.com", Path: "/health", Status: 200})
+	s.Add(&parser.Entry{Host: "a.com", Path: "/healthz", Status: 200})
+	s.Add(&parser.Entry{Host: "a.com", Path: "/internal/metrics", Status: 200})
+	s.Add(&parser.Entry{Host: "a.com", Path: "/robots.txt", Status: 200})
+
+	got := make(map[string]bool)
+	for _, p := range s.GetTopPaths(10, "a.com", "") {
+		got[p.Label] = true
+	}
+	if len(got) != 2 || !got["/healthz"] || !got["/robots.txt"] {
+		t.Errorf("expected only /healthz and /robots.txt (defaults replaced), got %v", got)
+	}
+
+	// An empty list hides nothing
+	s.SetExcludedPaths(nil)
+	if paths := s.GetTopPaths(10, "a.com", ""); len(paths) != 4 {
+		t.Errorf("expected all 4 paths with no exclusions, got %d", len(paths))
+	}
+}
+
+func TestOnErrorRateAbove_EdgeTriggered(t *testing.T) {
+	s := New(0)
+
+	fired := 0
+	var lastRates ErrorRates
+	s.OnErrorRateAbove(20, func(r ErrorRates) {
+		fired++
+		lastRates = r
+		// Callbacks run unlocked, so reading the store must not deadlock
+		s.GetStats()
+	})
+
+	// 10% errors - below threshold
+	for i := 0; i < 9; i++ {
+		s.Add(&parser.Entry{Status: 200})
+	}
+	s.Add(&parser.Entry{Status: 500})
+	s.Prune()
+	if fired != 0 {
+		t.Fatalf("expected no callback below threshold, got %d", fired)
+	}
+
+	// Push to 50% errors - crosses threshold
+	for i := 0; i < 10; i++ {
+		s.Add(&parser.Entry{Status: 503})
+	}
+	s.Prune()
+	if fired != 1 {
+		t.Fatalf("expected callback to fire once on crossing, got %d", fired)
+	}
+	if lastRates.Rate5xx < 54.9 || lastRates.Rate5xx > 55.1 {
+		t.Errorf("expected 5xx rate ~55%% passed to callback, got %.1f%%", lastRates.Rate5xx)
+	}
+
+	// Still above threshold - must not fire again
+	s.Prune()
+	s.Prune()
+	if fired != 1 {
+		t.Errorf("expected callback to stay at 1 while above threshold, got %d", fired)
+	}
+
+	// Drop below, then cross again - re-arms and fires a second time
+	for i := 0; i < 100; i++ {
+		s.Add(&parser.Entry{Status: 200})
+	}
+	s.Prune()
+	for i := 0; i < 100; i++ {
+		s.Add(&parser.Entry{Status: 500})
+	}
+	s.Prune()
+	if fired != 2 {
+		t.Errorf("expected callback to fire again after re-crossing, got %d", fired)
+	}
+}
+
+func TestFirstSeen(t *testing.T) {
+	s := New(100 * time.Millisecond)
+
+	first := time.Now().Add(-50 * time.Millisecond)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1"}, first)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1"}, time.Now())
+
+	if got := s.GetHostFirstSeen("a.com"); !got.Equal(first) {
+		t.Errorf("expected host first seen %v, got %v", first, got)
+	}
+	if got := s.GetIPFirstSeen("1.1.1.1"); !got.Equal(first) {
+		t.Errorf("expected IP first seen %v, got %v", first, got)
+	}
+	if got := s.GetHostFirstSeen("never.com"); !got.IsZero() {
+		t.Errorf("expected zero time for unseen host, got %v", got)
+	}
+
+	// First-seen survives pruning - it's session-scoped, not windowed
+	time.Sleep(150 * time.Millisecond)
+	s.Prune()
+	if got := s.GetHostFirstSeen("a.com"); !got.Equal(first) {
+		t.Errorf("expected first seen to survive prune, got %v", got)
+	}
+}
+
+func TestFirstSeen_ForgottenAfterLeavingWindow(t *testing.T) {
+	s := New(time.Minute)
+	s.SetLogClock(true)
+
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	addAt := func(host, ip string, at time.Duration) {
+		s.Add(&parser.Entry{Timestamp: base.Add(at), Status: 200, Host: host, IP: ip})
+		s.Prune()
+	}
+	addAt("gone.com", "1.1.1.1", 0)
+	addAt("stay.com", "2.2.2.2", 0)
+
+	// Out of the window, but not yet for firstSeenRetention
+	addAt("stay.com", "2.2.2.2", 3*time.Minute)
+	if s.GetHostFirstSeen("gone.com").IsZero() || s.GetIPFirstSeen("1.1.1.1").IsZero() {
+		t.Error("expected first-seen kept for firstSeenRetention after leaving the window")
+	}
+
+	addAt("stay.com", "2.2.2.2", 6*time.Minute)
+	if !s.GetHostFirstSeen("gone.com").IsZero() || !s.GetIPFirstSeen("1.1.1.1").IsZero() {
+		t.Error("expected first-seen forgotten after firstSeenRetention")
+	}
+	if !s.GetHostFirstSeen("stay.com").Equal(base) {
+		t.Errorf("expected first-seen kept while still in the window, got %v", s.GetHostFirstSeen("stay.com"))
+	}
+
+	// Long past firstSeenRetention, so forgotten as soon as it leaves
+	addAt("other.com", "3.3.3.3", 10*time.Minute)
+	if !s.GetHostFirstSeen("stay.com").IsZero() || !s.GetIPFirstSeen("2.2.2.2").IsZero() {
+		t.Error("expected first-seen forgotten when an old host leaves the window")
+	}
+	if len(s.hostFirstSeen) != 1 || len(s.ipFirstSeen) != 1 {
+		t.Errorf("expected only other.com tracked, got %v / %v", s.hostFirstSeen, s.ipFirstSeen)
+	}
+
+	// Back after being forgotten, so new again
+	addAt("gone.com", "1.1.1.1", 11*time.Minute)
+	if got := s.GetHostFirstSeen("gone.com"); !got.Equal(base.Add(11 * time.Minute)) {
+		t.Errorf("expected gone.com first seen again at 10:41, got %v", got)
+	}
+}
+
+func TestGetTLSBreakdown(t *testing.T) {
+	s := New(0)
+
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Status: 200, TLS: "1.3"})
+	}
+	s.Add(&parser.Entry{Status: 200, TLS: "1.0"})
+	s.Add(&parser.Entry{Status: 200, TLS: "1.0"})
+	s.Add(&parser.Entry{Status: 200}) // no TLS field
+
+	breakdown := s.GetTLSBreakdown()
+	if len(breakdown) != 2 {
+		t.Fatalf("expected 2 TLS versions (absent field excluded), got %d", len(breakdown))
+	}
+	if breakdown[0].Label != "1.3" || breakdown[0].Count != 5 {
+		t.Errorf("expected 1.3 with count 5 first, got %s with %d", breakdown[0].Label, breakdown[0].Count)
+	}
+	if breakdown[1].Label != "1.0" || breakdown[1].Count != 2 {
+		t.Errorf("expected 1.0 with count 2 second, got %s with %d", breakdown[1].Label, breakdown[1].Count)
+	}
+}
+
+func TestGetTLSBreakdown_Empty(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 200})
+
+	if breakdown := s.GetTLSBreakdown(); len(breakdown) != 0 {
+		t.Errorf("expected empty breakdown without TLS fields, got %v", breakdown)
+	}
+}
+
+func TestGetBytesSplitForHost(t *testing.T) {
+	s := New(0)
+
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Bytes: 10000})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Bytes: 20000})
+	s.Add(&parser.Entry{Status: 304, Host: "a.com", Bytes: 0})
+	s.Add(&parser.Entry{Status: 404, Host: "a.com", Bytes: 500})
+	s.Add(&parser.Entry{Status: 503, Host: "a.com", Bytes: 1500})
+	s.Add(&parser.Entry{Status: 500, Host: "b.com", Bytes: 99999}) // other host
+
+	split := s.GetBytesSplitForHost("a.com")
+	if split.OKCount != 3 || split.ErrCount != 2 {
+		t.Errorf("expected 3 ok / 2 err, got %d / %d", split.OKCount, split.ErrCount)
+	}
+	if split.AvgOK != 10000 {
+		t.Errorf("expected avg ok bytes 10000, got %d", split.AvgOK)
+	}
+	if split.AvgErr != 1000 {
+		t.Errorf("expected avg err bytes 1000, got %d", split.AvgErr)
+	}
+
+	if split := s.GetBytesSplitForHost("missing.com"); split != (BytesSplit{}) {
+		t.Errorf("expected zero split for unknown host, got %+v", split)
+	}
+}
+
+func TestSetMaxEntries(t *testing.T) {
+	s := New(0)
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Status: 200, Host: fmt.Sprintf("host%d.com", i)})
+	}
+
+	// Lowering the cap drops the oldest right away
+	s.SetMaxEntries(3)
+	if stats := s.GetStats(); stats.TotalCount != 3 {
+		t.Errorf("expected 3 entries after lowering the cap, got %d", stats.TotalCount)
+	}
+	if _, ok := s.HostCounts["host0.com"]; ok {
+		t.Error("expected the oldest host pruned")
+	}
+
+	s.Add(&parser.Entry{Status: 200, Host: "host5.com"})
+	if stats := s.DebugStats(); stats.Entries != 3 || stats.MaxEntries != 3 {
+		t.Errorf("expected 3/3 entries, got %d/%d", stats.Entries, stats.MaxEntries)
+	}
+}
+
+func TestDebugStats(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "old.com", IP: "1.1.1.1", Path: "/old"}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "old.com", IP: "1.1.1.1", Path: "/old"}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "2.2.2.2", Path: "/a"}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 500, Host: "b.com", IP: "2.2.2.2", Path: "/b"}, now)
+
+	stats := s.DebugStats()
+	if stats.Entries != 4 || stats.MaxEntries != DefaultMaxEntries {
+		t.Errorf("expected 4/%d entries, got %d/%d", DefaultMaxEntries, stats.Entries, stats.MaxEntries)
+	}
+	if stats.Hosts != 3 || stats.IPs != 2 || stats.Paths != 3 {
+		t.Errorf("expected 3 hosts, 2 IPs, 3 paths, got %+v", stats)
+	}
+
+	s.Prune()
+
+	// Entries drop with the window, and so do labels left with no entries
+	stats = s.DebugStats()
+	if stats.Entries != 2 {
+		t.Errorf("expected 2 entries after prune, got %d", stats.Entries)
+	}
+	if stats.Hosts != 2 || stats.IPs != 1 || stats.Paths != 2 {
+		t.Errorf("expected pruned labels to be dropped, got %+v", stats)
+	}
+}
+
+func TestPathPatterns(t *testing.T) {
+	var patterns []PathPattern
+	for _, spec := range []string{
+		`^/users/\d+/orders$=>/users/:id/orders`,
+		`^/users/\d+$=>/users/:id`,
+		`^/users/.*=>/users/*`, // would shadow the first two if tried first
+	} {
+		p, err := ParsePathPattern(spec)
+		if err != nil {
+			t.Fatalf("ParsePathPattern(%q): %v", spec, err)
+		}
+		patterns = append(patterns, p)
+	}
+
+	s := New(0)
+	s.SetPathPatterns(patterns)
+
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users/1"})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users/22"})
+	s.Add(&parser.Entry{Status: 500, Host: "a.com", Path: "/users/3/orders"})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users/me"})
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/health"}) // pass-through
+
+	counts := make(map[string]int64)
+	for _, item := range s.GetAllPaths(10) {
+		counts[item.Label] = item.Count
+	}
+
+	expected := map[string]int64{
+		"/users/:id":        2,
+		"/users/:id/orders": 1,
+		"/users/*":          1,
+		"/health":           1,
+	}
+	if len(counts) != len(expected) {
+		t.Errorf("expected %d path labels, got %v", len(expected), counts)
+	}
+	for label, want := range expected {
+		if counts[label] != want {
+			t.Errorf("expected %s count %d, got %d", label, want, counts[label])
+		}
+	}
+
+	// Error rates follow the routed label
+	if rates := s.GetErrorRatesForPath("/users/:id/orders"); rates.Rate5xx != 100 {
+		t.Errorf("expected 100%% 5xx for routed path, got %.1f", rates.Rate5xx)
+	}
+}
+
+func TestParsePathPattern_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "/users", "=>/users", "^/users=>", "^/users/(\\d+=>/users/:id"} {
+		if _, err := ParsePathPattern(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestGetHottestPath(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+
+	// Busiest overall, but all outside the recent window
+	for i := 0; i < 50; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Path: "/old"}, now.Add(-time.Minute))
+	}
+	for i := 0; i < 20; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Path: "/api/feed"}, now.Add(-2*time.Second))
+	}
+	for i := 0; i < 10; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Path: "/api/users"}, now.Add(-time.Second))
+	}
+	// Excluded paths never win
+	for i := 0; i < 30; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Path: "/robots.txt"}, now)
+	}
+
+	path, rate := s.GetHottestPath(10 * time.Second)
+	if path != "/api/feed" {
+		t.Errorf("expected /api/feed hottest, got %q", path)
+	}
+	if rate != 2.0 {
+		t.Errorf("expected 2.0/s (20 reqs over 10s), got %.1f", rate)
+	}
+}
+
+func TestGetHottestPath_Empty(t *testing.T) {
+	s := New(0)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Path: "/old"}, time.Now().Add(-time.Minute))
+
+	if path, rate := s.GetHottestPath(10 * time.Second); path != "" || rate != 0 {
+		t.Errorf("expected no hot path outside window, got %q at %.1f/s", path, rate)
+	}
+}
+
+func TestCanonicalHosts(t *testing.T) {
+	hosts := []string{"example.com", "example.com:443", "Example.COM", "example%2Ecom:8080", "bad%zzhost"}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		keepPorts bool
+		expected  map[string]int64
+	}{
+		{"off", false, false, map[string]int64{
+			"example.com": 1, "example.com:443": 1, "Example.COM": 1, "example%2Ecom:8080": 1, "bad%zzhost": 1,
+		}},
+		{"on, ports stripped", true, false, map[string]int64{
+			"example.com": 4, "bad%zzhost": 1,
+		}},
+		{"on, ports kept", true, true, map[string]int64{
+			"example.com": 2, "example.com:443": 1, "example.com:8080": 1, "bad%zzhost": 1,
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(0)
+			s.SetCanonicalHosts(tc.enabled, tc.keepPorts)
+			for _, h := range hosts {
+				s.Add(&parser.Entry{Status: 200, Host: h, IP: "1.1.1.1"})
+			}
+
+			for host, want := range tc.expected {
+				if got := s.HostCounts[host]; got != want {
+					t.Errorf("expected %s count %d, got %d", host, want, got)
+				}
+			}
+			if len(s.HostCounts) != len(tc.expected) {
+				t.Errorf("expected %d distinct hosts, got %v", len(tc.expected), s.HostCounts)
+			}
+		})
+	}
+}
+
+func TestGetNewUniqueIPRate(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+
+	// Seen long ago, then again recently: not new
+	s.addEntryAtTime(&parser.Entry{Status: 200, IP: "1.1.1.1"}, now.Add(-10*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, IP: "1.1.1.1"}, now)
+
+	// First seen within the window, repeat requests count once
+	for i := 0; i < 3; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, IP: "2.2.2.2"}, now.Add(-30*time.Second))
+	}
+	s.addEntryAtTime(&parser.Entry{Status: 200, IP: "3.3.3.3"}, now.Add(-10*time.Second))
+	s.addEntryAtTime(&parser.Entry{Status: 200, IP: "4.4.4.4"}, now)
+
+	// Missing IPs aren't clients
+	s.addEntryAtTime(&parser.Entry{Status: 200}, now)
+
+	if rate := s.GetNewUniqueIPRate(time.Minute); rate != 3 {
+		t.Errorf("expected 3 new IPs/min, got %.1f", rate)
+	}
+	if rate := s.GetNewUniqueIPRate(2 * time.Minute); rate != 1.5 {
+		t.Errorf("expected 1.5 new IPs/min over 2m, got %.1f", rate)
+	}
+	if rate := s.GetNewUniqueIPRate(0); rate != 0 {
+		t.Errorf("expected 0 for an empty window, got %v", rate)
+	}
+}
+
+func TestGetNewUniqueIPRate_AgesOutFirstSightings(t *testing.T) {
+	s := New(time.Minute)
+	s.SetLogClock(true)
+
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for i := range 1000 {
+		s.Add(&parser.Entry{Timestamp: base, Status: 200, IP: fmt.Sprintf("10.0.%d.%d", i/256, i%256)})
+	}
+	s.Add(&parser.Entry{Timestamp: base.Add(10 * time.Minute), Status: 200, IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Timestamp: base.Add(10*time.Minute + time.Second), Status: 200, IP: "2.2.2.2"})
+	s.Prune()
+
+	if rate := s.GetNewUniqueIPRate(time.Minute); rate != 2 {
+		t.Errorf("expected 2 new IPs/min, got %.1f", rate)
+	}
+	if len(s.ipFirstSeenQueue) != 2 {
+		t.Errorf("expected old first sightings aged out, %d still queued", len(s.ipFirstSeenQueue))
+	}
+}
+
+func TestGetServiceTimes_ReturnsSortedCopy(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 999, Connect: 99}, now.Add(-2*time.Minute)) // pruned
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 30, Connect: 3}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 101, Service: 5000, Connect: 50}, now) // no timing
+	s.addEntryAtTime(&parser.Entry{Status: 500, Service: 10, Connect: 1}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 20, Connect: 2}, now)
+	s.Prune()
+
+	service := s.GetServiceTimes()
+	connect := s.GetConnectTimes()
+	if len(service) != 3 || service[0] != 10 || service[1] != 20 || service[2] != 30 {
+		t.Errorf("expected service times [10 20 30], got %v", service)
+	}
+	if len(connect) != 3 || connect[0] != 1 || connect[1] != 2 || connect[2] != 3 {
+		t.Errorf("expected connect times [1 2 3], got %v", connect)
+	}
+
+	// Mutating the result must not touch the store
+	service[0] = 12345
+	connect[0] = 12345
+	if got := s.GetServiceTimes(); got[0] != 10 {
+		t.Errorf("expected store service times unchanged, got %v", got)
+	}
+	if got := s.GetConnectTimes(); got[0] != 1 {
+		t.Errorf("expected store connect times unchanged, got %v", got)
+	}
+	if stats := s.GetStats(); stats.P50Service != 20 {
+		t.Errorf("expected p50 unaffected by caller mutation, got %d", stats.P50Service)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	add := func(s *Store) {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", RequestID: "abc"})
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", RequestID: "abc"}) // replayed
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 500, Host: "a.com", RequestID: "def"})
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com"}) // no id, never deduped
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com"})
+	}
+
+	on := New(0)
+	on.SetDedup(true)
+	add(on)
+	if on.TotalCount != 4 || on.StatusCounts[200] != 3 {
+		t.Errorf("expected repeated request_id counted once (4 total, 3x200), got %d total, %d x200", on.TotalCount, on.StatusCounts[200])
+	}
+	if on.DuplicateCount != 1 {
+		t.Errorf("expected 1 duplicate skipped, got %d", on.DuplicateCount)
+	}
+
+	off := New(0)
+	add(off)
+	if off.TotalCount != 5 || off.DuplicateCount != 0 {
+		t.Errorf("expected all 5 counted with dedup off, got %d (dups %d)", off.TotalCount, off.DuplicateCount)
+	}
+}
+
+func TestDedup_IDsExpireWithWindow(t *testing.T) {
+	s := New(time.Minute)
+	s.SetDedup(true)
+
+	s.Add(&parser.Entry{Timestamp: time.Now().Add(-2 * time.Minute), Status: 200, RequestID: "abc"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, RequestID: "xyz"})
+	s.Prune()
+
+	// Seen outside the window: no longer a duplicate
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, RequestID: "abc"})
+	if s.TotalCount != 2 || s.DuplicateCount != 0 {
+		t.Errorf("expected expired id to be accepted again, got total %d dups %d", s.TotalCount, s.DuplicateCount)
+	}
+}
+
+func TestGetStatsForHost(t *testing.T) {
+	s := New(0)
+	for _, ms := range []int{10, 20, 30, 40} {
+		s.Add(&parser.Entry{Status: 200, Host: "a.com", Service: ms, Connect: ms / 10})
+	}
+	s.Add(&parser.Entry{Status: 101, Host: "a.com", Service: 60000})
+	s.Add(&parser.Entry{Status: 200, Host: "b.com", Service: 9999})
+
+	stats := s.GetStatsForHost("a.com")
+	if stats.TotalCount != 5 {
+		t.Errorf("expected 5 requests for a.com, got %d", stats.TotalCount)
+	}
+	if stats.AvgService != 25 || stats.P50Service != 20 || stats.MaxService != 40 {
+		t.Errorf("expected avg 25 / p50 20 / max 40 (101 excluded), got %+v", stats)
+	}
+	if stats.AvgConnect != 2 || stats.MaxConnect != 4 {
+		t.Errorf("expected connect avg 2 / max 4, got %d / %d", stats.AvgConnect, stats.MaxConnect)
+	}
+
+	if stats := s.GetStatsForHost("missing.com"); stats != (Stats{}) {
+		t.Errorf("expected zero stats for unknown host, got %+v", stats)
+	}
+}
+
+func TestGetStatsForHost_Pruned(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", Service: 5000}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 101, Host: "a.com", Service: 60000}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", Service: 10}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", Service: 30}, now)
+
+	s.Prune()
+
+	stats := s.GetStatsForHost("a.com")
+	if stats.TotalCount != 2 || stats.MaxService != 30 || stats.AvgService != 20 {
+		t.Errorf("expected only in-window samples for a.com, got %+v", stats)
+	}
+}
+
+func TestGetP95ForHostAndIP(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Service: 5000}, now.Add(-2*time.Minute))
+	for i := 1; i <= 20; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Service: i * 10}, now)
+	}
+	s.addEntryAtTime(&parser.Entry{Status: 101, Host: "ws.com", IP: "2.2.2.2", Service: 60000}, now)
+	s.Prune()
+
+	if p95, ok := s.GetP95ForHost("a.com"); !ok || p95 != 190 {
+		t.Errorf("expected a.com p95 190ms (old sample pruned), got %d, %v", p95, ok)
+	}
+	if p95, ok := s.GetP95ForIP("1.1.1.1"); !ok || p95 != 190 {
+		t.Errorf("expected 1.1.1.1 p95 190ms, got %d, %v", p95, ok)
+	}
+	if _, ok := s.GetP95ForHost("ws.com"); ok {
+		t.Error("expected no p95 for a host with only 101s")
+	}
+	if _, ok := s.GetP95ForIP("2.2.2.2"); ok {
+		t.Error("expected no p95 for an IP with only 101s")
+	}
+}
+
+func TestGetTopErrorCodes(t *testing.T) {
+	s := New(0)
+
+	s.Add(&parser.Entry{Status: 503, Code: "H12", Desc: "Request timeout"})
+	s.Add(&parser.Entry{Status: 503, Code: "H12", Desc: "Request timeout"})
+	s.Add(&parser.Entry{Status: 503, Code: "H18", Desc: "Server Request Interrupted"})
+	s.Add(&parser.Entry{Status: 200})
+
+	codes := s.GetTopErrorCodes(10)
+	if len(codes) != 2 {
+		t.Fatalf("expected 2 codes, got %d", len(codes))
+	}
+	if codes[0].Label != "H12" || codes[0].Count != 2 {
+		t.Errorf("expected H12 count 2 first, got %s count %d", codes[0].Label, codes[0].Count)
+	}
+	if desc := s.ErrorCodeDesc("H18"); desc != "Server Request Interrupted" {
+		t.Errorf("expected H18 description, got %q", desc)
+	}
+}
+
+func TestPathRelationships(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 500, Host: "old.com", IP: "9.9.9.9", Path: "/api"}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/api"}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 404, Host: "a.com", IP: "2.2.2.2", Path: "/api"}, now)
+	s.addEntryAtTime(&parser.Entry{Status: 200, Host: "b.com", IP: "1.1.1.1", Path: "/other"}, now)
+	s.Prune()
+
+	hosts := s.GetTopHostsForPath(10, "/api")
+	if len(hosts) != 1 || hosts[0].Label != "a.com" || hosts[0].Count != 2 {
+		t.Errorf("expected a.com x2 for /api (old.com pruned), got %v", hosts)
+	}
+	if ips := s.GetTopIPsForPath(10, "/api"); len(ips) != 2 {
+		t.Errorf("expected 2 IPs for /api, got %v", ips)
+	}
+	counts := s.GetStatusCountsForPath("/api")
+	if len(counts) != 2 || counts[0].Status != 200 || counts[1].Status != 404 {
+		t.Errorf("expected 200 and 404 for /api, got %v", counts)
+	}
+	if rates := s.GetErrorRatesForPath("/api"); rates.Rate4xx != 50 || rates.Rate5xx != 0 {
+		t.Errorf("expected 50%% 4xx / 0%% 5xx for /api, got %+v", rates)
+	}
+}
+
+func TestGetMethodCountsForPath(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Method: "GET", Path: "/items"})
+	s.Add(&parser.Entry{Method: "GET", Path: "/items"})
+	s.Add(&parser.Entry{Method: "POST", Path: "/items"})
+	s.Add(&parser.Entry{Method: "DELETE", Path: "/other"})
+
+	counts := s.GetMethodCountsForPath("/items")
+	if len(counts) != 2 || counts[0].Label != "GET" || counts[0].Count != 2 || counts[1].Label != "POST" {
+		t.Errorf("expected GET x2 then POST for /items, got %v", counts)
+	}
+	if counts := s.GetMethodCountsForPath("/missing"); len(counts) != 0 {
+		t.Errorf("expected no methods for unknown path, got %v", counts)
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"/users/12345":          "/users/:id",
+		"/users/12345/orders/9": "/users/:id/orders/:id",
+		"/files/3f2b1c9e-8a4d-4e1f-9b2a-7c6d5e4f3a2b": "/files/:uuid",
+		"/v2/users":  "/v2/users",
+		"/":          "/",
+		"/users/12a": "/users/12a",
+	}
+	for in, want := range tests {
+		if got := normalizePath(in); got != want {
+			t.Errorf("normalizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetNormalizePaths(t *testing.T) {
+	s := New(0)
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users/1"})
+
+	s.SetNormalizePaths(true)
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", Path: "/users/2"})
+	s.Add(&parser.Entry{Status: 500, Host: "a.com", Path: "/users/3"})
+
+	paths := s.GetAllPaths(10)
+	if len(paths) != 2 {
+		t.Fatalf("expected raw /users/1 and /users/:id, got %v", paths)
+	}
+	if paths[0].Label != "/users/:id" || paths[0].Count != 2 {
+		t.Errorf("expected /users/:id x2 first, got %v", paths)
+	}
+	if rates := s.GetErrorRatesForPath("/users/:id"); rates.Rate5xx != 50 {
+		t.Errorf("expected 50%% 5xx for /users/:id, got %.1f", rates.Rate5xx)
+	}
+}
+
+func TestPercentileIndex_NearestRank(t *testing.T) {
+	tests := []struct {
+		n             int
+		p50, p95, p99 int
+	}{
+		{1, 0, 0, 0},
+		{2, 0, 1, 1}, // p50 of two samples is the lower one, not the max
+		{3, 1, 2, 2},
+		{10, 4, 9, 9},
+		{100, 49, 94, 98},
+		{1000, 499, 949, 989},
+	}
+	for _, tc := range tests {
+		got := []int{percentileIndex(tc.n, 50), percentileIndex(tc.n, 95), percentileIndex(tc.n, 99)}
+		if got[0] != tc.p50 || got[1] != tc.p95 || got[2] != tc.p99 {
+			t.Errorf("n=%d: expected p50/p95/p99 indices %d/%d/%d, got %v", tc.n, tc.p50, tc.p95, tc.p99, got)
+		}
+	}
+
+	// Bounds and float noise
+	if got := percentileIndex(10, 0); got != 0 {
+		t.Errorf("expected p0 index 0, got %d", got)
+	}
+	if got := percentileIndex(10, 100); got != 9 {
+		t.Errorf("expected p100 index 9, got %d", got)
+	}
+	if got := percentileIndex(1000, 99.9); got != 998 {
+		t.Errorf("expected p99.9 of 1000 at index 998, got %d", got)
+	}
+}
+
+func TestGetPercentile(t *testing.T) {
+	s := New(0)
+	if got := s.GetPercentile(50); got != 0 {
+		t.Errorf("expected 0 for an empty store, got %d", got)
+	}
+
+	// 1..1000ms; a 101 that must not count
+	for i := 1000; i >= 1; i-- {
+		s.Add(&parser.Entry{Status: 200, Service: i})
+	}
+	s.Add(&parser.Entry{Status: 101, Service: 60000})
+
+	exact := []struct {
+		p    float64
+		want int
+	}{
+		{0, 1},
+		{-5, 1},
+		{100, 1000},
+		{150, 1000},
+		{5, 50}, // below 128ms the histogram is exact
+	}
+	for _, tc := range exact {
+		if got := s.GetPercentile(tc.p); got != tc.want {
+			t.Errorf("GetPercentile(%v) = %d, want %d", tc.p, got, tc.want)
+		}
+	}
+
+	// Above 128ms, within the histogram's 1/64 error
+	if got := s.GetPercentile(99.9); math.Abs(float64(got)-999) > 999.0/64 {
+		t.Errorf("GetPercentile(99.9) = %d, want ~999", got)
+	}
+
+	stats := s.GetStats()
+	if got := s.GetPercentile(95); got != stats.P95Service {
+		t.Errorf("expected GetPercentile(95) to match GetStats p95 %d, got %d", stats.P95Service, got)
+	}
+}
+
+func TestPruneDeletesZeroCountKeys(t *testing.T) {
+	s := New(time.Minute)
+	now := time.Now()
+
+	s.addEntryAtTime(&parser.Entry{Status: 503, Service: 50, Method: "POST", Host: "gone.com", IP: "9.9.9.9", Path: "/gone", TLS: "TLSv1.2", Code: "H12"}, now.Add(-2*time.Minute))
+	s.addEntryAtTime(&parser.Entry{Status: 200, Service: 10, Method: "GET", Host: "a.com", IP: "1.1.1.1", Path: "/a"}, now)
+	s.Prune()
+
+	if _, ok := s.HostCounts["gone.com"]; ok {
+		t.Error("expected gone.com removed from HostCounts")
+	}
+	if _, ok := s.IPCounts["9.9.9.9"]; ok {
+		t.Error("expected 9.9.9.9 removed from IPCounts")
+	}
+	for name, present := range map[string]bool{
+		"StatusCounts[503]":   s.StatusCounts[503] != 0 || len(s.StatusCounts) != 1,
+		"MethodCounts[POST]":  len(s.MethodCounts) != 1,
+		"TLSCounts":           len(s.TLSCounts) != 0,
+		"CodeCounts":          len(s.CodeCounts) != 0,
+		"hostToIPs":           s.hostToIPs["gone.com"] != nil || s.hostToIPs["a.com"]["9.9.9.9"] != 0,
+		"ipToHosts":           s.ipToHosts["9.9.9.9"] != nil,
+		"hostToStatus":        s.hostToStatus["gone.com"] != nil,
+		"ipToStatus":          s.ipToStatus["9.9.9.9"] != nil,
+		"hostToPaths":         s.hostToPaths["gone.com"] != nil,
+		"ipToPaths":           s.ipToPaths["9.9.9.9"] != nil,
+		"pathToHosts":         s.pathToHosts["/gone"] != nil,
+		"pathToIPs":           s.pathToIPs["/gone"] != nil,
+		"pathToStatus":        s.pathToStatus["/gone"] != nil,
+		"hostToMethod":        s.hostToMethod["gone.com"] != nil,
+		"ipToMethod":          s.ipToMethod["9.9.9.9"] != nil,
+		"pathToMethod":        s.pathToMethod["/gone"] != nil,
+		"hostToServiceTimes":  s.hostToServiceTimes["gone.com"] != nil,
+		"hostToConnectTimes":  s.hostToConnectTimes["gone.com"] != nil,
+		"ipToServiceTimes":    s.ipToServiceTimes["9.9.9.9"] != nil,
+		"pathToServiceTimes":  s.pathToServiceTimes["/gone"] != nil,
+		"a.com still counted": s.HostCounts["a.com"] != 1,
+	} {
+		if present {
+			t.Errorf("unexpected state after prune: %s", name)
+		}
+	}
+
+	// Views built on these maps still agree
+	hosts, ips, paths := s.GetUniqueCounts()
+	if hosts != 1 || ips != 1 || paths != 1 {
+		t.Errorf("expected 1 host/IP/path after prune, got %d/%d/%d", hosts, ips, paths)
+	}
+	if other := s.GetOtherCount(s.HostCounts, s.GetTopHosts(10, "")); other != 0 {
+		t.Errorf("expected no other count, got %d", other)
+	}
+}
+
+func TestGetSlowestPaths(t *testing.T) {
+	s := New(0)
+	add := func(path string, status, ms, times int) {
+		for i := 0; i < times; i++ {
+			s.Add(&parser.Entry{Status: status, Path: path, Service: ms})
+		}
+	}
+
+	add("/fast", 200, 20, 10)
+	add("/slow", 200, 800, 6)
+	add("/once", 200, 30000, 1)      // too few samples to rank
+	add("/ws", 101, 60000, 10)       // 101s aren't timed
+	add("/robots.txt", 200, 5000, 5) // excluded by default
+	add("/medium", 200, 300, 5)
+
+	paths := s.GetSlowestPaths(10)
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 ranked paths, got %v", paths)
+	}
+	want := []PathLatency{
+		{Label: "/slow", P95: 800, Count: 6},
+		{Label: "/medium", P95: 300, Count: 5},
+		{Label: "/fast", P95: 20, Count: 10},
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("rank %d: expected %+v, got %+v", i, want[i], paths[i])
+		}
+	}
+
+	if top := s.GetSlowestPaths(1); len(top) != 1 || top[0].Label != "/slow" {
+		t.Errorf("expected only /slow with n=1, got %v", top)
+	}
+}
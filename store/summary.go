@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// summaryTopN is how many hosts and paths Summary lists
+const summaryTopN = 10
+
+// Summary returns a plain-text digest of the retained data: totals,
+// average rate, percentiles, error rates, and the top hosts and paths.
+// Meant for printing after the UI exits.
+func (s *Store) Summary() string {
+	stats := s.GetStats()
+	rate4xx, rate5xx := s.GetErrorRates()
+
+	var b strings.Builder
+	b.WriteString("hstat summary\n")
+
+	if stats.TotalCount == 0 {
+		b.WriteString("No requests\n")
+		return b.String()
+	}
+
+	// Average over the span of the data, at least a second
+	span := s.LatestTime().Sub(s.StartTime())
+	if span < time.Second {
+		span = time.Second
+	}
+
+	fmt.Fprintf(&b, "Requests:  %d (%.1f/s avg)\n", stats.TotalCount, float64(stats.TotalCount)/span.Seconds())
+	fmt.Fprintf(&b, "Errors:    4xx %.1f%% | 5xx %.1f%%\n", rate4xx, rate5xx)
+	fmt.Fprintf(&b, "Response:  p50 %dms | p95 %dms | p99 %dms\n", stats.P50Service, stats.P95Service, stats.P99Service)
+
+	writeSummaryTable(&b, "Top hosts", s.GetTopHosts(summaryTopN, ""), stats.TotalCount)
+	writeSummaryTable(&b, "Top paths", s.GetAllPaths(summaryTopN), stats.TotalCount)
+
+	return b.String()
+}
+
+// writeSummaryTable writes a titled list of labels with counts and their
+// share of total
+func writeSummaryTable(b *strings.Builder, title string, items []CountItem, total int64) {
+	fmt.Fprintf(b, "\n%s:\n", title)
+	if len(items) == 0 {
+		b.WriteString("  (none)\n")
+		return
+	}
+
+	width := 0
+	for _, item := range items {
+		width = max(width, len(item.Label))
+	}
+	for _, item := range items {
+		fmt.Fprintf(b, "  %-*s  %8d  %5.1f%%\n", width, item.Label, item.Count, float64(item.Count)*100/float64(total))
+	}
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestSummary(t *testing.T) {
+	s := New(0)
+	start := time.Now().Add(-10 * time.Second)
+
+	for i := 0; i < 8; i++ {
+		s.addEntryAtTime(&parser.Entry{Status: 200, Host: "api.com", Path: "/users", Service: 20}, start.Add(time.Duration(i)*time.Second))
+	}
+	s.addEntryAtTime(&parser.Entry{Status: 404, Host: "web.com", Path: "/missing", Service: 5}, start.Add(9*time.Second))
+	s.addEntryAtTime(&parser.Entry{Status: 500, Host: "web.com", Path: "/users", Service: 90}, start.Add(10*time.Second))
+
+	summary := s.Summary()
+
+	for _, want := range []string{
+		"Requests:  10 (1.0/s avg)",
+		"Errors:    4xx 10.0% | 5xx 10.0%",
+		"Response:  p50 20ms | p95 90ms | p99 90ms",
+		"Top hosts:\n  api.com         8   80.0%\n  web.com         2   20.0%",
+		"Top paths:\n  /users           9   90.0%\n  /missing         1   10.0%",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+}
+
+func TestSummary_Empty(t *testing.T) {
+	if summary := New(0).Summary(); !strings.Contains(summary, "No requests") {
+		t.Errorf("expected empty summary, got:\n%s", summary)
+	}
+}
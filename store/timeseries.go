@@ -0,0 +1,70 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+// Bucket holds stats for one interval of a time series
+type Bucket struct {
+	Start  time.Time
+	Total  int64
+	Status map[int]int64 // status class (2, 3, 4, 5) -> count
+	P50    int
+	P95    int
+	P99    int
+	Rate   float64 // req/s over the bucket
+}
+
+// TimeSeries groups entries into fixed intervals by timestamp and computes
+// per-bucket stats. Buckets run from the earliest to the latest entry with
+// empty intervals included, so rows line up with wall-clock time. As in
+// the live store, 101s are counted but excluded from percentiles.
+func TimeSeries(entries []parser.Entry, interval time.Duration) []Bucket {
+	if len(entries) == 0 || interval <= 0 {
+		return nil
+	}
+
+	first, last := entries[0].Timestamp, entries[0].Timestamp
+	for _, e := range entries {
+		if e.Timestamp.Before(first) {
+			first = e.Timestamp
+		}
+		if e.Timestamp.After(last) {
+			last = e.Timestamp
+		}
+	}
+	start := first.Truncate(interval)
+	n := int(last.Truncate(interval).Sub(start)/interval) + 1
+
+	buckets := make([]Bucket, n)
+	times := make([][]int, n)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * interval)
+		buckets[i].Status = make(map[int]int64)
+	}
+
+	for _, e := range entries {
+		i := int(e.Timestamp.Sub(start) / interval)
+		buckets[i].Total++
+		buckets[i].Status[e.Status/100]++
+		if e.Status != 101 {
+			times[i] = append(times[i], e.Service)
+		}
+	}
+
+	for i := range buckets {
+		b := &buckets[i]
+		b.Rate = float64(b.Total) / interval.Seconds()
+		if len(times[i]) == 0 {
+			continue
+		}
+		t := times[i]
+		sort.Ints(t)
+		b.P50, b.P95, b.P99 = percentiles(t)
+	}
+
+	return buckets
+}
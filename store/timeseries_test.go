@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+)
+
+func TestTimeSeries(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	entries := []parser.Entry{
+		{Timestamp: base.Add(5 * time.Second), Status: 200, Service: 10},
+		{Timestamp: base.Add(20 * time.Second), Status: 200, Service: 30},
+		{Timestamp: base.Add(40 * time.Second), Status: 404, Service: 20},
+		{Timestamp: base.Add(50 * time.Second), Status: 101, Service: 90000},
+		// 10:31 is empty
+		{Timestamp: base.Add(2*time.Minute + 10*time.Second), Status: 503, Service: 100},
+	}
+
+	buckets := TimeSeries(entries, time.Minute)
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets (incl. empty 10:31), got %d", len(buckets))
+	}
+
+	b := buckets[0]
+	if !b.Start.Equal(base) || b.Total != 4 {
+		t.Errorf("expected 4 requests at 10:30, got %d at %v", b.Total, b.Start)
+	}
+	if b.Status[2] != 2 || b.Status[4] != 1 || b.Status[1] != 1 {
+		t.Errorf("unexpected status classes %v", b.Status)
+	}
+	if b.P50 != 20 || b.P99 != 30 {
+		t.Errorf("expected p50 20 / p99 30 (101 excluded), got %d / %d", b.P50, b.P99)
+	}
+
+	if buckets[1].Total != 0 || buckets[1].P50 != 0 {
+		t.Errorf("expected empty middle bucket, got %+v", buckets[1])
+	}
+	if buckets[2].Total != 1 || buckets[2].Status[5] != 1 || buckets[2].Rate != 1.0/60 {
+		t.Errorf("unexpected last bucket %+v", buckets[2])
+	}
+}
+
+func TestTimeSeries_Empty(t *testing.T) {
+	if buckets := TimeSeries(nil, time.Minute); buckets != nil {
+		t.Errorf("expected no buckets, got %v", buckets)
+	}
+}
@@ -0,0 +1,150 @@
+// Package syslog receives log lines over a TCP syslog connection, as sent
+// by Heroku log drains, and converts them back to plain log lines.
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxFrame caps an octet-counted frame so a bad length can't exhaust memory
+const maxFrame = 64 * 1024
+
+// Listen starts a TCP syslog listener on addr. Each received message is
+// converted with ToLogLine and passed to emit, which may be called from
+// several connections at once.
+func Listen(addr string, emit func(line string)) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go Serve(l, emit)
+	return l, nil
+}
+
+// Serve accepts connections on l until it is closed
+func Serve(l net.Listener, emit func(line string)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			ReadFrames(conn, func(msg string) {
+				emit(ToLogLine(msg))
+			})
+		}()
+	}
+}
+
+// ReadFrames splits a syslog stream into messages. Both RFC6587 framings
+// are accepted, per message: octet counting ("123 <158>1 ...") and
+// newline-terminated.
+func ReadFrames(r io.Reader, emit func(msg string)) error {
+	br := bufio.NewReader(r)
+	for {
+		first, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var msg string
+		if first[0] >= '1' && first[0] <= '9' {
+			msg, err = readOctetCounted(br)
+		} else {
+			msg, err = br.ReadString('\n')
+			if err == io.EOF && msg != "" {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		msg = strings.TrimRight(msg, "\r\n")
+		if msg != "" {
+			emit(msg)
+		}
+	}
+}
+
+// readOctetCounted reads a "LEN SP MSG" frame
+func readOctetCounted(br *bufio.Reader) (string, error) {
+	lenStr, err := br.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+	if err != nil || n <= 0 || n > maxFrame {
+		return "", errors.New("syslog: invalid frame length " + strconv.Quote(lenStr))
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// ToLogLine converts a syslog message into the plain log line format the
+// parser expects, e.g.
+//
+//	<158>1 2024-01-15T10:30:00+00:00 host heroku router - at=info ...
+//
+// becomes
+//
+//	2024-01-15T10:30:00+00:00 heroku[router]: at=info ...
+//
+// RFC3164 messages already carry a "tag[pid]:" so only the priority,
+// timestamp, and hostname are dropped. Anything unrecognised is returned
+// unchanged.
+func ToLogLine(msg string) string {
+	if !strings.HasPrefix(msg, "<") {
+		return msg
+	}
+	end := strings.IndexByte(msg, '>')
+	if end == -1 {
+		return msg
+	}
+	rest := msg[end+1:]
+
+	// RFC5424: VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+	if strings.HasPrefix(rest, "1 ") {
+		fields := strings.SplitN(rest, " ", 7)
+		if len(fields) < 7 {
+			return msg
+		}
+		timestamp, app, proc, body := fields[1], fields[3], fields[4], fields[6]
+		if strings.HasPrefix(body, "- ") {
+			body = body[2:] // no structured data
+		}
+		return timestamp + " " + app + "[" + proc + "]: " + body
+	}
+
+	// RFC3164: "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG"
+	if fields := strings.SplitN(rest, " ", 5); len(fields) == 5 {
+		if fields[1] == "" { // single-digit day is space padded
+			fields = strings.SplitN(rest, " ", 6)[1:]
+		}
+		if len(fields) == 5 {
+			return fields[4]
+		}
+	}
+	return msg
+}
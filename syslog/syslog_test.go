@@ -0,0 +1,102 @@
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const routerMsg = `<158>1 2024-01-15T10:30:00.000000+00:00 host heroku router - at=info method=GET path="/" host=example.com fwd="1.2.3.4" status=200 service=10ms`
+
+func TestToLogLine(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{routerMsg, `2024-01-15T10:30:00.000000+00:00 heroku[router]: at=info method=GET path="/" host=example.com fwd="1.2.3.4" status=200 service=10ms`},
+		{`<134>Jan 15 10:30:00 myhost heroku[router]: status=200`, `heroku[router]: status=200`},
+		{`<134>Jan  5 10:30:00 myhost heroku[router]: status=200`, `heroku[router]: status=200`},
+		{`2024-01-15T10:30:00Z heroku[router]: status=200`, `2024-01-15T10:30:00Z heroku[router]: status=200`},
+	}
+	for _, tc := range tests {
+		if got := ToLogLine(tc.in); got != tc.want {
+			t.Errorf("ToLogLine(%q)\n got %q\nwant %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestReadFrames_MixedFraming(t *testing.T) {
+	stream := fmt.Sprintf("%d %s", len(routerMsg), routerMsg) +
+		"<134>Jan 15 10:30:00 myhost heroku[router]: status=404\n" +
+		fmt.Sprintf("%d %s", len("<14>1 a"), "<14>1 a")
+
+	var got []string
+	if err := ReadFrames(strings.NewReader(stream), func(msg string) { got = append(got, msg) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[0] != routerMsg || got[2] != "<14>1 a" {
+		t.Errorf("unexpected frames: %q", got)
+	}
+}
+
+func TestListen_IngestsOverLoopback(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	l, err := Listen("127.0.0.1:0", func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	// Two concurrent connections, one per framing
+	var wg sync.WaitGroup
+	for _, frame := range []string{
+		fmt.Sprintf("%d %s", len(routerMsg), routerMsg),
+		"<134>Jan 15 10:30:00 myhost heroku[router]: status=503\n",
+	} {
+		wg.Add(1)
+		go func(frame string) {
+			defer wg.Done()
+			conn, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Errorf("dial: %v", err)
+				return
+			}
+			defer conn.Close()
+			conn.Write([]byte(frame))
+		}(frame)
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(lines)
+		mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(lines)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines ingested, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "2024-01-15T10:30:00.000000+00:00 heroku[router]: ") {
+		t.Errorf("expected RFC5424 message converted, got %q", lines[0])
+	}
+	if lines[1] != "heroku[router]: status=503" {
+		t.Errorf("expected RFC3164 message converted, got %q", lines[1])
+	}
+}
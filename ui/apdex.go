@@ -0,0 +1,28 @@
+package ui
+
+import "fmt"
+
+// apdexToleratedFactor is the standard Apdex tolerance: requests up to 4T
+// count as tolerated
+const apdexToleratedFactor = 4
+
+// WithApdex shows an Apdex score in the header, with threshold T in ms
+func WithApdex(thresholdMs int) Option {
+	return func(m *Model) {
+		m.apdexT = thresholdMs
+	}
+}
+
+// renderApdex colors the score by band: green from 0.85 (good), orange
+// from 0.70 (fair), red below
+func renderApdex(score float64) string {
+	text := fmt.Sprintf("apdex %.2f", score)
+	switch {
+	case score >= 0.85:
+		return status2xxStyle.Render(text)
+	case score >= 0.70:
+		return status4xxStyle.Render(text)
+	default:
+		return status5xxStyle.Render(text)
+	}
+}
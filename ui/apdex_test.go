@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+	"github.com/betternow/hstat/store"
+)
+
+func TestApdex_Header(t *testing.T) {
+	s := store.New(0)
+	for _, ms := range []int{50, 90, 300, 1000} {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Service: ms})
+	}
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	if strings.Contains(m.renderHeaderContent(), "apdex") {
+		t.Error("expected no apdex without WithApdex")
+	}
+
+	m = NewModel(s, time.Second, WithApdex(100))
+	m.refreshData()
+	if header := stripAnsi(m.renderHeaderContent()); !strings.Contains(header, "apdex 0.62") {
+		t.Errorf("expected apdex 0.62 in header, got:\n%s", header)
+	}
+}
+
+func TestRenderApdex_Bands(t *testing.T) {
+	for _, tc := range []struct {
+		score float64
+		style string
+	}{
+		{0.95, status2xxStyle.Render("apdex 0.95")},
+		{0.85, status2xxStyle.Render("apdex 0.85")},
+		{0.75, status4xxStyle.Render("apdex 0.75")},
+		{0.40, status5xxStyle.Render("apdex 0.40")},
+	} {
+		if got := renderApdex(tc.score); got != tc.style {
+			t.Errorf("renderApdex(%v) = %q, want %q", tc.score, got, tc.style)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ColorMode controls whether styled output uses ANSI colors
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // Detect from the terminal (lipgloss default)
+	ColorAlways                  // Force 256-color output, even when not a TTY
+	ColorNever                   // Force plain output
+)
+
+// ParseColorMode parses a --color flag value
+func ParseColorMode(s string) (ColorMode, error) {
+	switch s {
+	case "auto", "":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	}
+	return ColorAuto, fmt.Errorf("invalid color mode %q (want always, auto, or never)", s)
+}
+
+// String returns the flag value for the mode
+func (c ColorMode) String() string {
+	switch c {
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return "auto"
+	}
+}
+
+// ColorModeWithEnv turns ColorAuto into ColorNever when the NO_COLOR
+// environment variable is set (non-empty), per no-color.org. An explicit
+// mode wins over the environment.
+func ColorModeWithEnv(mode ColorMode, noColorEnv string) ColorMode {
+	if mode == ColorAuto && noColorEnv != "" {
+		return ColorNever
+	}
+	return mode
+}
+
+// ApplyColorMode overrides lipgloss's detected color profile. Detection
+// can misfire over some SSH/tmux setups, so this is the escape hatch.
+// ColorAuto leaves the detected profile alone.
+func ApplyColorMode(mode ColorMode) {
+	switch mode {
+	case ColorAlways:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case ColorNever:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/store"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestParseColorMode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ColorMode
+	}{
+		{"", ColorAuto},
+		{"auto", ColorAuto},
+		{"always", ColorAlways},
+		{"never", ColorNever},
+	}
+	for _, tc := range tests {
+		got, err := ParseColorMode(tc.in)
+		if err != nil {
+			t.Errorf("ParseColorMode(%q) unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseColorMode(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+func TestApplyColorMode(t *testing.T) {
+	prev := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(prev) })
+
+	// Test output is not a TTY, so "always" must force ANSI on
+	ApplyColorMode(ColorAlways)
+	if out := status5xxStyle.Render("500"); !strings.Contains(out, "\x1b[") {
+		t.Errorf("expected ANSI output with color=always, got %q", out)
+	}
+
+	ApplyColorMode(ColorNever)
+	if out := status5xxStyle.Render("500"); out != "500" {
+		t.Errorf("expected plain output with color=never, got %q", out)
+	}
+}
+
+func TestColorModeWithEnv(t *testing.T) {
+	if got := ColorModeWithEnv(ColorAuto, "1"); got != ColorNever {
+		t.Errorf("expected NO_COLOR to disable auto color, got %v", got)
+	}
+	if got := ColorModeWithEnv(ColorAuto, ""); got != ColorAuto {
+		t.Errorf("expected empty NO_COLOR to be ignored, got %v", got)
+	}
+	if got := ColorModeWithEnv(ColorAlways, "1"); got != ColorAlways {
+		t.Errorf("expected explicit color=always to win over NO_COLOR, got %v", got)
+	}
+}
+
+func TestView_NoColorHasNoEscapes(t *testing.T) {
+	prev := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(prev) })
+	ApplyColorMode(ColorNever)
+
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	s.Add(testEntry(404, "a.com", "2.2.2.2"))
+	s.Add(testEntry(503, "b.com", "1.1.1.1"))
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	if view := m.View(); strings.Contains(view, "\x1b[") {
+		t.Errorf("expected no ANSI sequences with color disabled, got %q", view)
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/betternow/hstat/store"
+)
+
+// focusTopN is how many IPs/paths the host inspector lists
+const focusTopN = 10
+
+// focusData is the cached host inspector data for focus mode
+type focusData struct {
+	host     string
+	stats    store.Stats
+	rates    store.ErrorRates
+	bytes    store.BytesSplit
+	statuses []store.StatusCountItem
+	ips      []store.CountItem
+	paths    []store.CountItem
+}
+
+// focusHost returns the host focus mode should inspect, or "" if none
+func (m Model) focusHost() string {
+	if !m.focus || m.section != SectionHosts {
+		return ""
+	}
+	return selectedLabel(m.topHosts, m.hostCursor)
+}
+
+// refreshFocus reloads the host inspector for the selected host. It reads
+// per-host data directly, so the global filter is left untouched.
+func (m *Model) refreshFocus() {
+	host := m.focusHost()
+	if host == "" {
+		m.focusData = focusData{}
+		return
+	}
+	m.focusData = focusData{
+		host:     host,
+		stats:    m.store.GetStatsForHost(host),
+		rates:    m.store.GetErrorRatesForHost(host),
+		bytes:    m.store.GetBytesSplitForHost(host),
+		statuses: m.store.GetStatusCounts(host, ""),
+		ips:      m.store.GetTopIPs(focusTopN, host),
+		paths:    m.store.GetTopPaths(focusTopN, host, ""),
+	}
+}
+
+// renderFocusPanel renders the expanded host inspector
+func (m Model) renderFocusPanel(height int) string {
+	d := m.focusData
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Requests: %s | 4xx %.1f%% | 5xx %.1f%%",
+		formatNumber(d.stats.TotalCount), d.rates.Rate4xx, d.rates.Rate5xx))
+	lines = append(lines, fmt.Sprintf("Response: avg %dms | p50 %dms | p95 %dms | p99 %dms | max %dms",
+		d.stats.AvgService, d.stats.P50Service, d.stats.P95Service, d.stats.P99Service, d.stats.MaxService))
+	lines = append(lines, fmt.Sprintf("Connect:  avg %dms | max %dms", d.stats.AvgConnect, d.stats.MaxConnect))
+	lines = append(lines, fmt.Sprintf("Avg size: ok %s | errors %s", formatBytes(d.bytes.AvgOK), formatBytes(d.bytes.AvgErr)))
+
+	var statuses []string
+	for _, sc := range d.statuses {
+		statuses = append(statuses, StatusStyle(sc.Status).Render(fmt.Sprintf("%d:%s", sc.Status, formatNumber(sc.Count))))
+	}
+	lines = append(lines, "Status:   "+strings.Join(statuses, " "))
+	lines = append(lines, "")
+
+	// IPs and paths side by side
+	colWidth := (m.width - 4) / 2
+	ips := renderFocusList("IPs", d.ips, colWidth-2)
+	paths := renderFocusList("Paths", d.paths, colWidth-2)
+	lines = append(lines, strings.Split(m.joinSideBySide(ips, paths, colWidth), "\n")...)
+
+	// Leave room for the panel's borders
+	if maxLines := height - 2; maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+
+	title := fmt.Sprintf("Focus: %s (f/Esc to exit)", d.host)
+	return m.renderBorderedSection(title, strings.Join(lines, "\n"), m.width, true)
+}
+
+// renderFocusList renders a titled "label  count" list
+func renderFocusList(title string, items []store.CountItem, width int) string {
+	labelWidth := width - 8
+	if labelWidth < 10 {
+		labelWidth = 10
+	}
+
+	lines := []string{tableHeaderStyle.Render(fmt.Sprintf("%-*s %7s", labelWidth, title, "Count"))}
+	if len(items) == 0 {
+		lines = append(lines, tableRowDimStyle.Render("No data"))
+	}
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%-*s %7s", labelWidth, truncateEnd(item.Label, labelWidth), formatNumber(item.Count)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/betternow/hstat/store"
+)
+
+// HealthWeights controls how the composite health score is built. The
+// three weights are relative to each other; P95Target is the p95 latency
+// (ms) that still counts as fully healthy.
+type HealthWeights struct {
+	Errors    float64
+	Latency   float64
+	Trend     float64
+	P95Target int64
+}
+
+// DefaultHealthWeights favours errors over latency over trend
+var DefaultHealthWeights = HealthWeights{
+	Errors:    50,
+	Latency:   35,
+	Trend:     15,
+	P95Target: 1000,
+}
+
+// ParseHealthWeights parses a --health-weights value such as
+// "errors=60,latency=30,trend=10,p95=800". Keys not given keep their
+// defaults.
+func ParseHealthWeights(s string) (HealthWeights, error) {
+	w := DefaultHealthWeights
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return HealthWeights{}, fmt.Errorf("invalid weight %q (want key=value)", part)
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || n < 0 {
+			return HealthWeights{}, fmt.Errorf("invalid value for %s: %q", key, val)
+		}
+		switch strings.TrimSpace(key) {
+		case "errors":
+			w.Errors = n
+		case "latency":
+			w.Latency = n
+		case "trend":
+			w.Trend = n
+		case "p95":
+			if n == 0 {
+				return HealthWeights{}, fmt.Errorf("p95 target must be positive")
+			}
+			w.P95Target = int64(n)
+		default:
+			return HealthWeights{}, fmt.Errorf("unknown weight %q (want errors, latency, trend, or p95)", key)
+		}
+	}
+	if w.Errors+w.Latency+w.Trend == 0 {
+		return HealthWeights{}, fmt.Errorf("at least one weight must be positive")
+	}
+	return w, nil
+}
+
+// WithHealthScore shows a 0-100 composite health score in the header
+func WithHealthScore(weights HealthWeights) Option {
+	return func(m *Model) {
+		m.showHealth = true
+		m.healthWeights = weights
+	}
+}
+
+// healthScore combines error rates, latency, and trend into 0 (on fire)
+// to 100 (healthy). Each input becomes a 0-1 penalty:
+//   - errors: 5xx% plus a quarter of 4xx%, maxing out at 10%
+//   - latency: p95 over target, maxing out at 4x the target (p99 is
+//     held to twice the target on the same scale)
+//   - trend: half for each of the 1m and 5m error trends going up
+func healthScore(w HealthWeights, rate4xx, rate5xx float64, stats store.Stats, trend, trend5m store.Trend) int {
+	errPenalty := clamp01((rate5xx + rate4xx/4) / 10)
+
+	target := float64(w.P95Target)
+	latPenalty := math.Max(
+		clamp01((float64(stats.P95Service)/target-1)/3),
+		clamp01((float64(stats.P99Service)/(2*target)-1)/3),
+	)
+
+	var trendPenalty float64
+	if trend == store.TrendUp {
+		trendPenalty += 0.5
+	}
+	if trend5m == store.TrendUp {
+		trendPenalty += 0.5
+	}
+
+	total := w.Errors + w.Latency + w.Trend
+	penalty := (w.Errors*errPenalty + w.Latency*latPenalty + w.Trend*trendPenalty) / total
+	return int(math.Round(100 * (1 - penalty)))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// renderHealthScore colors the score green, orange, or red
+func renderHealthScore(score int) string {
+	text := fmt.Sprintf("health %d", score)
+	switch {
+	case score >= 80:
+		return status2xxStyle.Render(text)
+	case score >= 50:
+		return status4xxStyle.Render(text)
+	default:
+		return status5xxStyle.Render(text)
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/betternow/hstat/store"
+)
+
+func TestHealthScore_Clean(t *testing.T) {
+	stats := store.Stats{P95Service: 200, P99Service: 400}
+	got := healthScore(DefaultHealthWeights, 0.5, 0, stats, store.TrendStable, store.TrendDown)
+	if got < 98 {
+		t.Errorf("expected clean state to score ~100, got %d", got)
+	}
+}
+
+func TestHealthScore_Bad(t *testing.T) {
+	stats := store.Stats{P95Service: 5000, P99Service: 12000}
+	got := healthScore(DefaultHealthWeights, 8, 15, stats, store.TrendUp, store.TrendUp)
+	if got > 10 {
+		t.Errorf("expected high-error, slow, worsening state to score low, got %d", got)
+	}
+}
+
+func TestHealthScore_Weights(t *testing.T) {
+	// Only latency counts: slow but error-free scores 0
+	w := HealthWeights{Latency: 1, P95Target: 100}
+	stats := store.Stats{P95Service: 400}
+	if got := healthScore(w, 0, 20, stats, store.TrendUp, store.TrendUp); got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+}
+
+func TestParseHealthWeights(t *testing.T) {
+	w, err := ParseHealthWeights("")
+	if err != nil || w != DefaultHealthWeights {
+		t.Errorf("expected defaults for empty value, got %+v (err %v)", w, err)
+	}
+
+	w, err = ParseHealthWeights("errors=80, p95=500")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Errors != 80 || w.P95Target != 500 || w.Latency != DefaultHealthWeights.Latency {
+		t.Errorf("unexpected weights: %+v", w)
+	}
+
+	for _, bad := range []string{"errors", "errors=x", "speed=1", "p95=0", "errors=0,latency=0,trend=0", "trend=-1"} {
+		if _, err := ParseHealthWeights(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/betternow/hstat/store"
+)
+
+// serviceHistogramBounds split the latency histogram modal's buckets, in ms
+var serviceHistogramBounds = []int{10, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// histogramBarWidth is how wide the fullest bar is drawn
+const histogramBarWidth = 40
+
+// partialBlocks draw a bar's fractional end, in eighths
+var partialBlocks = []rune(" ▏▎▍▌▋▊▉")
+
+// histogramContent renders buckets as horizontal bars scaled to the
+// fullest bucket, with counts and shares
+func histogramContent(buckets []store.HistogramBucket) string {
+	var total, peak int64
+	for _, bucket := range buckets {
+		total += bucket.Count
+		peak = max64(peak, bucket.Count)
+	}
+	if total == 0 {
+		return "No timed requests in the window"
+	}
+
+	labels := make([]string, len(buckets))
+	labelWidth := 0
+	for i, bucket := range buckets {
+		labels[i] = bucketLabel(bucket)
+		labelWidth = max(labelWidth, len(labels[i]))
+	}
+
+	var lines []string
+	for i, bucket := range buckets {
+		lines = append(lines, fmt.Sprintf("%*s %8s %5.1f%%  %s",
+			labelWidth, labels[i], formatNumber(bucket.Count),
+			float64(bucket.Count)*100/float64(total), histogramBar(bucket.Count, peak)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// histogramBar draws count as a bar of full and partial blocks, scaled so
+// peak fills histogramBarWidth. Any non-zero count shows at least a sliver.
+func histogramBar(count, peak int64) string {
+	if count <= 0 || peak <= 0 {
+		return ""
+	}
+	eighths := int(count * histogramBarWidth * 8 / peak)
+	eighths = max(eighths, 1)
+	bar := strings.Repeat("█", eighths/8)
+	if rem := eighths % 8; rem > 0 {
+		bar += string(partialBlocks[rem])
+	}
+	return bar
+}
+
+// bucketLabel names a bucket's range: "10-50ms", "1s-2.5s", "5s+"
+func bucketLabel(bucket store.HistogramBucket) string {
+	if bucket.Max == 0 {
+		return formatBound(bucket.Min) + "+"
+	}
+	if bucket.Max < 1000 {
+		return fmt.Sprintf("%d-%dms", bucket.Min, bucket.Max)
+	}
+	return formatBound(bucket.Min) + "-" + formatBound(bucket.Max)
+}
+
+// formatBound renders a bucket bound: "250ms", "1s", "2.5s"
+func formatBound(ms int) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%gs", float64(ms)/1000)
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/betternow/hstat/store"
+)
+
+func TestHistogramContent(t *testing.T) {
+	buckets := []store.HistogramBucket{
+		{Min: 0, Max: 10, Count: 80},
+		{Min: 10, Max: 1000, Count: 0},
+		{Min: 1000, Max: 2500, Count: 20},
+		{Min: 2500, Count: 1},
+	}
+	lines := strings.Split(histogramContent(buckets), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a line per bucket, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	for i, want := range []string{" 0-10ms       80  79.2%  " + strings.Repeat("█", histogramBarWidth), "10ms-1s", "1s-2.5s", "2.5s+"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d: expected %q, got %q", i, want, lines[i])
+		}
+	}
+	if !strings.HasSuffix(lines[1], "0.0%  ") {
+		t.Errorf("expected no bar for an empty bucket, got %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], strings.Repeat("█", 10)) {
+		t.Errorf("expected a quarter-width bar, got %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], "▌") {
+		t.Errorf("expected a sliver for a tiny bucket, got %q", lines[3])
+	}
+
+	if got := histogramContent([]store.HistogramBucket{{Max: 10}, {Min: 10}}); got != "No timed requests in the window" {
+		t.Errorf("expected empty message, got %q", got)
+	}
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Action is something a key can be bound to
+type Action string
+
+const (
+	ActionNone           Action = ""
+	ActionQuit           Action = "quit"
+	ActionClearFilter    Action = "clear-filter"
+	ActionHelp           Action = "help"
+	ActionWhois          Action = "whois"
+	ActionIpinfo         Action = "ipinfo"
+	ActionReverseDNS     Action = "reverse-dns"
+	ActionGeoIP          Action = "geoip"
+	ActionOpenBrowser    Action = "open-browser"
+	ActionHostDetail     Action = "host-detail"
+	ActionErrorCodes     Action = "error-codes"
+	ActionSlowPaths      Action = "slow-paths"
+	ActionHistogram      Action = "histogram"
+	ActionStatusLatency  Action = "status-latency"
+	ActionNextSection    Action = "next-section"
+	ActionPrevSection    Action = "prev-section"
+	ActionDown           Action = "down"
+	ActionUp             Action = "up"
+	ActionTop            Action = "top"
+	ActionBottom         Action = "bottom"
+	ActionFilter         Action = "filter"
+	ActionFilter4xx      Action = "filter-4xx"
+	ActionFilter5xx      Action = "filter-5xx"
+	ActionSort           Action = "sort"
+	ActionBottomHosts    Action = "bottom-hosts"
+	ActionFocus          Action = "focus"
+	ActionFilterBack     Action = "filter-back"
+	ActionFilterFwd      Action = "filter-forward"
+	ActionPause          Action = "pause"
+	ActionSearch         Action = "search"
+	ActionSnapshot       Action = "snapshot"
+	ActionExportCSV      Action = "export-csv"
+	ActionWiderWindow    Action = "wider-window"
+	ActionNarrowerWindow Action = "narrower-window"
+	ActionFasterRefresh  Action = "faster-refresh"
+	ActionSlowerRefresh  Action = "slower-refresh"
+	ActionMoreRows       Action = "more-rows"
+	ActionFewerRows      Action = "fewer-rows"
+)
+
+// defaultBindings are today's key bindings, per action
+var defaultBindings = map[Action][]string{
+	ActionQuit:           {"q", "ctrl+c"},
+	ActionClearFilter:    {"esc"},
+	ActionHelp:           {"?"},
+	ActionWhois:          {"w"},
+	ActionIpinfo:         {"i"},
+	ActionReverseDNS:     {"r"},
+	ActionGeoIP:          {"o"},
+	ActionOpenBrowser:    {"b"},
+	ActionHostDetail:     {"d"},
+	ActionErrorCodes:     {"e"},
+	ActionSlowPaths:      {"L"},
+	ActionHistogram:      {"H"},
+	ActionStatusLatency:  {"t"},
+	ActionNextSection:    {"tab", "l"},
+	ActionPrevSection:    {"shift+tab", "h"},
+	ActionDown:           {"j", "down"},
+	ActionUp:             {"k", "up"},
+	ActionTop:            {"g"},
+	ActionBottom:         {"G"},
+	ActionFilter:         {"enter"},
+	ActionFilter4xx:      {"4"},
+	ActionFilter5xx:      {"5"},
+	ActionSort:           {"s"},
+	ActionBottomHosts:    {"B"},
+	ActionFocus:          {"f"},
+	ActionFilterBack:     {"<"},
+	ActionFilterFwd:      {">"},
+	ActionPause:          {"p"},
+	ActionSearch:         {"/"},
+	ActionSnapshot:       {"S"},
+	ActionExportCSV:      {"x"},
+	ActionWiderWindow:    {"+", "="},
+	ActionNarrowerWindow: {"-"},
+	ActionFasterRefresh:  {","},
+	ActionSlowerRefresh:  {"."},
+	ActionMoreRows:       {"]"},
+	ActionFewerRows:      {"["},
+}
+
+// KeyMap maps key strings (as reported by tea.KeyMsg.String) to actions
+type KeyMap map[string]Action
+
+// DefaultKeyMap returns the built-in key bindings
+func DefaultKeyMap() KeyMap {
+	return buildKeyMap(defaultBindings)
+}
+
+func buildKeyMap(bindings map[Action][]string) KeyMap {
+	km := make(KeyMap)
+	for action, keys := range bindings {
+		for _, key := range keys {
+			km[key] = action
+		}
+	}
+	// ctrl+c always quits so a bad config can't trap the user
+	km["ctrl+c"] = ActionQuit
+	return km
+}
+
+// Action returns the action bound to key, or ActionNone
+func (km KeyMap) Action(key string) Action {
+	return km[key]
+}
+
+// DefaultKeysPath returns $XDG_CONFIG_HOME/hstat/keys.toml,
+// falling back to ~/.config/hstat/keys.toml
+func DefaultKeysPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "hstat", "keys.toml")
+}
+
+// LoadKeyMap reads key bindings from a TOML file of action = key(s):
+//
+//	quit = "x"
+//	down = ["j", "down", "ctrl+n"]
+//
+// Actions not listed keep their default keys, except any the file binds
+// to another action. A missing file yields the defaults; on any error
+// the defaults are returned along with the error, so callers can warn
+// and carry on.
+func LoadKeyMap(path string) (KeyMap, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultKeyMap(), nil
+	}
+	if err != nil {
+		return DefaultKeyMap(), err
+	}
+	defer f.Close()
+
+	bindings := make(map[Action][]string, len(defaultBindings))
+	for action, keys := range defaultBindings {
+		bindings[action] = keys
+	}
+	// Configured actions, in file order; applied over the defaults so a
+	// key taken from another action's defaults reliably moves
+	type override struct {
+		action Action
+		keys   []string
+	}
+	var overrides []override
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return DefaultKeyMap(), fmt.Errorf("%s:%d: expected action = key", path, lineNo)
+		}
+		action := Action(strings.TrimSpace(name))
+		if _, known := defaultBindings[action]; !known {
+			return DefaultKeyMap(), fmt.Errorf("%s:%d: unknown action %q", path, lineNo, action)
+		}
+		keys, err := parseKeyList(strings.TrimSpace(value))
+		if err != nil {
+			return DefaultKeyMap(), fmt.Errorf("%s:%d: %v", path, lineNo, err)
+		}
+		delete(bindings, action)
+		overrides = append(overrides, override{action, keys})
+	}
+	if err := scanner.Err(); err != nil {
+		return DefaultKeyMap(), err
+	}
+
+	km := buildKeyMap(bindings)
+	for _, o := range overrides {
+		for _, key := range o.keys {
+			km[key] = o.action
+		}
+	}
+	km["ctrl+c"] = ActionQuit
+	return km, nil
+}
+
+// parseKeyList parses a TOML string or array of strings
+func parseKeyList(value string) ([]string, error) {
+	if strings.HasPrefix(value, "[") {
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("unterminated array %s", value)
+		}
+		var keys []string
+		for _, part := range strings.Split(value[1:len(value)-1], ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			key, err := strconv.Unquote(part)
+			if err != nil || key == "" {
+				return nil, fmt.Errorf("invalid key %s", part)
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no keys given")
+		}
+		return keys, nil
+	}
+
+	key, err := strconv.Unquote(value)
+	if err != nil || key == "" {
+		return nil, fmt.Errorf("invalid key %s", value)
+	}
+	return []string{key}, nil
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func writeKeysFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKeyMap_RemappedKeyTriggersAction(t *testing.T) {
+	path := writeKeysFile(t, `
+# arrows only for movement
+down = ["down", "ctrl+n"]
+next-section = "n"
+`)
+
+	keys, err := LoadKeyMap(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	s.Add(testEntry(200, "b.com", "1.1.1.1"))
+	m := NewModel(s, time.Second, WithKeyMap(keys))
+	m.refreshData()
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyCtrlN})
+	m = newM.(Model)
+	if m.hostCursor != 1 {
+		t.Errorf("expected ctrl+n to move down, cursor at %d", m.hostCursor)
+	}
+
+	// j was replaced, not added to
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = newM.(Model)
+	if m.hostCursor != 1 {
+		t.Errorf("expected j to be unbound, cursor moved to %d", m.hostCursor)
+	}
+
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = newM.(Model)
+	if m.section != SectionIPs {
+		t.Errorf("expected n to switch section, got %v", m.section)
+	}
+
+	// Unlisted actions keep their defaults
+	if keys.Action("q") != ActionQuit || keys.Action("tab") != ActionNone {
+		t.Errorf("expected q default kept and tab replaced, got q=%q tab=%q", keys.Action("q"), keys.Action("tab"))
+	}
+}
+
+func TestLoadKeyMap_InvalidFallsBackToDefaults(t *testing.T) {
+	for _, content := range []string{
+		`explode = "x"`,
+		`quit = x`,
+		`quit = ["x"`,
+		`quit`,
+	} {
+		keys, err := LoadKeyMap(writeKeysFile(t, content))
+		if err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+		if keys.Action("j") != ActionDown || keys.Action("q") != ActionQuit {
+			t.Errorf("expected default bindings after error for %q", content)
+		}
+	}
+}
+
+func TestLoadKeyMap_Missing(t *testing.T) {
+	keys, err := LoadKeyMap(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if keys.Action("enter") != ActionFilter {
+		t.Error("expected default bindings for missing file")
+	}
+}
+
+func TestKeyMap_CtrlCAlwaysQuits(t *testing.T) {
+	keys, err := LoadKeyMap(writeKeysFile(t, `quit = "x"`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys.Action("ctrl+c") != ActionQuit || keys.Action("x") != ActionQuit {
+		t.Error("expected x and ctrl+c to quit")
+	}
+	if keys.Action("q") != ActionNone {
+		t.Error("expected q unbound after remapping quit")
+	}
+}
+
+func TestLoadKeyMap_TakesKeyFromAnotherDefault(t *testing.T) {
+	// Map order is random, so load a few times to catch a default
+	// winning back the key
+	for i := 0; i < 20; i++ {
+		keys, err := LoadKeyMap(writeKeysFile(t, `pause = "j"`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if keys.Action("j") != ActionPause {
+			t.Fatalf("expected j to pause, got %q", keys.Action("j"))
+		}
+		if keys.Action("down") != ActionDown || keys.Action("p") != ActionNone {
+			t.Errorf("expected down kept and p unbound, got down=%q p=%q", keys.Action("down"), keys.Action("p"))
+		}
+	}
+}
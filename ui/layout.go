@@ -48,6 +48,16 @@ func CalculateLayout(width, height int) *Layout {
 
 // CalculateLayoutWithActiveSection computes layout with priority for active section
 func CalculateLayoutWithActiveSection(width, height int, activeSection Section) *Layout {
+	return calculateLayout(width, height, activeSection, true)
+}
+
+// CalculateCompactLayout computes layout without the status codes section,
+// giving its height to the data sections
+func CalculateCompactLayout(width, height int, activeSection Section) *Layout {
+	return calculateLayout(width, height, activeSection, false)
+}
+
+func calculateLayout(width, height int, activeSection Section, showStatusCodes bool) *Layout {
 	if width < MinWidth || height < MinHeight {
 		return nil
 	}
@@ -57,11 +67,13 @@ func CalculateLayoutWithActiveSection(width, height int, activeSection Section)
 		Height: height,
 	}
 
-	// Calculate header height (title + 2 stats lines + borders)
-	layout.HeaderHeight = 5
+	// Calculate header height (rate, sparkline, 2 stats lines + borders)
+	layout.HeaderHeight = 6
 
 	// Calculate status codes height (header + group row + up to 3 detail rows + borders)
-	layout.StatusCodesHeight = calculateStatusCodesHeight(width)
+	if showStatusCodes {
+		layout.StatusCodesHeight = calculateStatusCodesHeight(width)
+	}
 
 	// Remaining height for data sections
 	layout.DataSectionHeight = height - layout.HeaderHeight - layout.StatusCodesHeight
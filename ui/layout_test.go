@@ -215,3 +215,23 @@ func TestCalculateLayout_MinimalHeight(t *testing.T) {
 		t.Errorf("expected at least 1 PathsRow, got %d", layout.PathsRows)
 	}
 }
+
+func TestCalculateCompactLayout_ReallocatesStatusHeight(t *testing.T) {
+	full := CalculateLayoutWithActiveSection(120, 24, SectionHosts)
+	compact := CalculateCompactLayout(120, 24, SectionHosts)
+	if full == nil || compact == nil {
+		t.Fatal("expected valid layouts")
+	}
+
+	if compact.StatusCodesHeight != 0 {
+		t.Errorf("expected no status codes height in compact layout, got %d", compact.StatusCodesHeight)
+	}
+	if compact.DataSectionHeight != full.DataSectionHeight+full.StatusCodesHeight {
+		t.Errorf("expected data sections to gain the status height (%d+%d), got %d",
+			full.DataSectionHeight, full.StatusCodesHeight, compact.DataSectionHeight)
+	}
+	if compact.HostsRows <= full.HostsRows || compact.PathsRows <= full.PathsRows {
+		t.Errorf("expected more data rows in compact layout, got hosts %d->%d paths %d->%d",
+			full.HostsRows, compact.HostsRows, full.PathsRows, compact.PathsRows)
+	}
+}
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/betternow/hstat/geoip"
 	"github.com/betternow/hstat/parser"
 	"github.com/betternow/hstat/store"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,12 +19,73 @@ type Section int
 const (
 	SectionHosts Section = iota
 	SectionIPs
+	SectionPaths
+	SectionStatus // last, so it drops out of Tab order with WithNoStatusSection
 )
 
+// sectionCount is the number of navigable sections
+const sectionCount = 4
+
+// SortMode is the order a table's rows are shown in
+type SortMode int
+
+const (
+	SortCount   SortMode = iota // Most requests first
+	Sort4xx                     // Highest 4xx rate first
+	Sort5xx                     // Highest 5xx rate first
+	Sort4xxReqs                 // Most 4xx responses first (IPs only)
+	Sort5xxReqs                 // Most 5xx responses first (IPs only)
+	sortModeCount
+)
+
+// String returns the label shown in section titles
+func (s SortMode) String() string {
+	switch s {
+	case Sort4xx:
+		return "4xx"
+	case Sort5xx:
+		return "5xx"
+	case Sort4xxReqs:
+		return "4xx reqs"
+	case Sort5xxReqs:
+		return "5xx reqs"
+	default:
+		return "count"
+	}
+}
+
+// errorClass returns 4 or 5 for the error-count sorts, else 0
+func (s SortMode) errorClass() int {
+	switch s {
+	case Sort4xxReqs:
+		return 4
+	case Sort5xxReqs:
+		return 5
+	}
+	return 0
+}
+
+// nextSortMode returns the sort after mode in section's cycle. Only the
+// IPs table ranks by error count, to find scanners.
+func nextSortMode(section Section, mode SortMode) SortMode {
+	next := (mode + 1) % sortModeCount
+	if next.errorClass() != 0 && section != SectionIPs {
+		return SortCount
+	}
+	return next
+}
+
 // Filter represents the current filter state
 type Filter struct {
 	Host string
 	IP   string
+	Path string
+
+	// StatusClass narrows everything to 4xx (4) or 5xx (5) traffic; 0 shows all
+	StatusClass int
+
+	// Status narrows everything to one status code, e.g. 422; 0 shows all
+	Status int
 }
 
 // Modal represents the current modal state
@@ -30,14 +96,52 @@ type Modal struct {
 	Loading bool
 }
 
-// Default number of items to show (will be dynamic based on layout)
-const defaultTopN = 20
+// Default number of items to fetch per table; ]/[ adjust it within
+// [minTopN, maxTopN] in steps of topNStep
+const (
+	defaultTopN = 20
+	minTopN     = 1
+	maxTopN     = 100
+	topNStep    = 5
+)
 
 // Model is the bubbletea model
 type Model struct {
-	store       *store.Store
-	startTime   time.Time
-	refreshRate time.Duration
+	store        *store.Store
+	startTime    time.Time
+	topN         int // rows fetched per table
+	refreshRate  time.Duration
+	pathTruncate TruncateMode
+	watchCodes   []int
+	keys         KeyMap
+	debug        bool
+	noStatus     bool
+	confirmQuit  bool
+	staleAfter   time.Duration // no entries for this long shows a warning
+
+	// Composite health score, opt-in via WithHealthScore
+	showHealth    bool
+	healthWeights HealthWeights
+
+	// Apdex threshold T in ms, opt-in via WithApdex; 0 hides the score
+	apdexT int
+
+	// Error rate thresholds (percent) for table cells, opt-in via
+	// WithErrorRateThresholds; 0 disables each
+	warnRate float64
+	critRate float64
+
+	// View state persistence, empty to disable
+	viewStateFile string
+
+	// Where the export key writes CSV; empty for a timestamped file
+	csvPath string
+
+	// ipinfo.io API token, empty for unauthenticated lookups
+	ipinfoToken string
+
+	// Local GeoIP database for offline lookups, nil if not configured
+	geo GeoLocator
 
 	// UI state
 	width         int
@@ -45,10 +149,51 @@ type Model struct {
 	section       Section
 	hostCursor    int
 	ipCursor      int
+	pathCursor    int
+	statusCursor  int
+	sortModes     [sectionCount]SortMode
+	bottomHosts   bool // hosts table lists the least frequent hosts
 	filter        Filter
 	streamEnded   bool
 	lastEntryTime time.Time
 	modal         Modal
+	focus         bool
+	paused        bool // ticks skip refreshData; the store keeps ingesting
+
+	// Live substring search: while searchMode is on, typed runes extend
+	// searchQuery, which narrows searchSection's rows until cleared
+	searchMode    bool
+	searchQuery   string
+	searchSection Section
+
+	// First visible row of each table, moved by Update (scrollToCursors)
+	// to keep the cursor in view
+	scrollOffsets map[Section]int
+
+	// Successful lookups by IP, so repeat w/i presses don't refetch
+	// (ipinfo.io is rate limited)
+	whoisCache  map[string]string
+	ipinfoCache map[string]string
+
+	// Brief confirmation shown in the header (e.g. after a snapshot)
+	notice      string
+	noticeUntil time.Time
+
+	// With confirmQuit, a quit key pressed before this time quits
+	quitArmedUntil time.Time
+
+	// Recently applied filters, oldest first; historyPos is the entry the
+	// current filter came from
+	filterHistory []Filter
+	historyPos    int
+
+	// Selection tracking across refreshes. A lost label is the previously
+	// selected host/IP that dropped out of its list; it stays noted until
+	// the user moves the cursor.
+	shownFilter Filter
+	lostHost    string
+	lostIP      string
+	lostPath    string
 
 	// Cached data for rendering
 	stats        store.Stats
@@ -56,6 +201,7 @@ type Model struct {
 	topHosts     []store.CountItem
 	topIPs       []store.CountItem
 	topPaths     []store.CountItem
+	pathMethods  []store.CountItem
 	otherHosts   int64
 	otherIPs     int64
 
@@ -65,22 +211,180 @@ type Model struct {
 	uniqueHosts  int
 	uniqueIPs    int
 	uniquePaths  int
+	excludedReqs int64 // requests to excluded paths, counted but not listed
 	currentRate  float64
+	rateBuckets  []float64 // req/s per second over the last rateHistory, oldest first
+	throughput   float64
+	newIPRate    float64
+	unknownHosts int64 // requests with no Host header or an IP as host
+	dataSpan     time.Duration
+	latestEntry  time.Time // log timestamp of the newest entry, zero if none
+	recentIPs    int       // distinct IPs within recentIPsSpan, updated per tick
+	health       int
+	apdex        float64
+	lifetime     int64 // entries seen since start, beyond the window
+	malformed    int64 // truncated router lines left out of the stats
+	errorCodes   []store.CountItem
+	hotPath      string
+	hotPathRate  float64
 	trend        store.Trend
 	trend5m      store.Trend
+	latencyTrend store.Trend // p95 service time, over trendWindow
 	hostErrRates map[string]store.ErrorRates
 	ipErrRates   map[string]store.ErrorRates
 	pathErrRates map[string]store.ErrorRates
+	hostP95      map[string]int     // absent when a host has no timed requests
+	hostReqRates map[string]float64 // req/s over currentRateWindow
+	ipP95        map[string]int
+	newHosts     map[string]bool
+	newIPs       map[string]bool
+	debugStats   store.DebugStats
+	focusData    focusData
+}
+
+// Option configures optional Model behavior
+type Option func(*Model)
+
+// WithPathTruncate sets how over-long paths are shortened in the paths table
+func WithPathTruncate(mode TruncateMode) Option {
+	return func(m *Model) {
+		m.pathTruncate = mode
+	}
+}
+
+// WithKeyMap replaces the default key bindings
+func WithKeyMap(keys KeyMap) Option {
+	return func(m *Model) {
+		m.keys = keys
+	}
+}
+
+// WithWatchCodes pins the given status codes as always-visible header counters
+func WithWatchCodes(codes []int) Option {
+	return func(m *Model) {
+		m.watchCodes = codes
+	}
+}
+
+// WithNoStatusSection drops the status codes section, giving its height
+// to the data sections
+func WithNoStatusSection(noStatus bool) Option {
+	return func(m *Model) {
+		m.noStatus = noStatus
+	}
+}
+
+// WithDebug shows a footer with the store's entry and label counts
+func WithDebug(debug bool) Option {
+	return func(m *Model) {
+		m.debug = debug
+	}
+}
+
+// WithErrorRateThresholds dims table 4xx/5xx rates below warn percent
+// and shows them bold red from crit percent, so a noisy baseline stays
+// quiet while a spike stands out; rows whose 5xx rate reaches crit are
+// highlighted whole. Zero disables either threshold.
+func WithErrorRateThresholds(warn, crit float64) Option {
+	return func(m *Model) {
+		m.warnRate = warn
+		m.critRate = crit
+	}
+}
+
+// WithCSVPath makes the export key write its CSV to path rather than a
+// timestamped file in the working directory
+func WithCSVPath(path string) Option {
+	return func(m *Model) {
+		m.csvPath = path
+	}
+}
+
+// WithConfirmQuit requires quitting keys to be pressed twice within
+// quitConfirmWindow, so a stray q doesn't end a session mid-incident
+func WithConfirmQuit(confirm bool) Option {
+	return func(m *Model) {
+		m.confirmQuit = confirm
+	}
+}
+
+// WithStaleAfter sets how long the stream can go quiet before the header
+// shows a "no data" warning
+func WithStaleAfter(d time.Duration) Option {
+	return func(m *Model) {
+		m.staleAfter = d
+	}
+}
+
+// WithIpinfoToken authenticates ipinfo.io lookups, which are heavily
+// throttled without a token
+func WithIpinfoToken(token string) Option {
+	return func(m *Model) {
+		m.ipinfoToken = token
+	}
+}
+
+// GeoLocator resolves an IP to a location, e.g. from a local GeoIP
+// database (see package geoip)
+type GeoLocator interface {
+	Lookup(ip string) (loc geoip.Location, found bool, err error)
+}
+
+// WithGeoIP enables offline location lookups for the selected IP
+func WithGeoIP(geo GeoLocator) Option {
+	return func(m *Model) {
+		m.geo = geo
+	}
+}
+
+// ParseWatchCodes parses a comma-separated list of status codes (e.g. "429,502")
+func ParseWatchCodes(s string) ([]int, error) {
+	var codes []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		code, err := strconv.Atoi(part)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
 }
 
 // NewModel creates a new Model
-func NewModel(s *store.Store, refreshRate time.Duration) Model {
-	return Model{
+func NewModel(s *store.Store, refreshRate time.Duration, opts ...Option) Model {
+	m := Model{
 		store:       s,
 		startTime:   time.Now(),
 		refreshRate: refreshRate,
 		section:     SectionHosts,
+		keys:        DefaultKeyMap(),
+		topN:        defaultTopN,
+		staleAfter:  DefaultStaleAfter,
+		whoisCache:  make(map[string]string),
+		ipinfoCache: make(map[string]string),
+
+		scrollOffsets: make(map[Section]int),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	if m.section >= m.navigableSections() {
+		m.section = SectionHosts
+	}
+	return m
+}
+
+// navigableSections is how many sections Tab cycles through: all of
+// them, or all but status codes when that section is hidden
+func (m Model) navigableSections() Section {
+	if m.noStatus {
+		return SectionStatus
 	}
+	return sectionCount
 }
 
 // EntryMsg is sent when a new log entry is parsed
@@ -88,6 +392,10 @@ type EntryMsg struct {
 	Entry *parser.Entry
 }
 
+// EntriesMsg carries a batch of parsed entries, so a busy stream doesn't
+// flood the message queue ahead of ticks and keys
+type EntriesMsg []*parser.Entry
+
 // TickMsg is sent on each refresh tick
 type TickMsg time.Time
 
@@ -108,6 +416,38 @@ type IpinfoResultMsg struct {
 	Err     error
 }
 
+// GeoResultMsg is sent when a GeoIP lookup completes
+type GeoResultMsg struct {
+	IP      string
+	Content string
+	Err     error
+}
+
+// ReverseDNSResultMsg is sent when a reverse DNS lookup completes
+type ReverseDNSResultMsg struct {
+	IP      string
+	Content string
+	Err     error
+}
+
+// BrowserResultMsg is sent once a browser has been launched for a URL
+type BrowserResultMsg struct {
+	URL string
+	Err error
+}
+
+// SnapshotResultMsg is sent when a view snapshot has been written
+type SnapshotResultMsg struct {
+	Path string
+	Err  error
+}
+
+// ExportResultMsg is sent when a CSV export has been written
+type ExportResultMsg struct {
+	Path string
+	Err  error
+}
+
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
@@ -122,19 +462,74 @@ func tickCmd(d time.Duration) tea.Cmd {
 }
 
 const currentRateWindow = 10 * time.Second
+
+// rateHistory is how far back the header's rate sparkline reaches, one
+// bucket per second
+const rateHistory = 60
+
+// headerErrorCodes is how many Heroku error codes the header lists
+const headerErrorCodes = 3
 const trendWindow = 60 * time.Second
+const newIPRateWindow = time.Minute
 const trendWindow5m = 5 * time.Minute
 
+// uniqueIPWindow is how far back the IPs title counts distinct IPs, capped
+// at the data window
+const uniqueIPWindow = 5 * time.Minute
+
+// recentIPsSpan returns the span the IPs title counts distinct IPs over
+func (m Model) recentIPsSpan() time.Duration {
+	if window := m.store.Window(); window > 0 && window < uniqueIPWindow {
+		return window
+	}
+	return uniqueIPWindow
+}
+
+// refreshRecentIPs recounts distinct recent IPs. It walks the entries, so
+// it runs on ticks rather than in refreshData, which key presses also call.
+func (m *Model) refreshRecentIPs() {
+	m.recentIPs = m.store.GetUniqueIPsInWindow(m.recentIPsSpan())
+}
+
+// newBadgeWindow is how long a first-seen host/IP shows the NEW badge
+const newBadgeWindow = 10 * time.Second
+
 // refreshData updates cached data from the store
 func (m *Model) refreshData() {
-	m.store.Prune()
-	m.stats = m.store.GetStats()
-	m.statusCounts = m.store.GetStatusCounts(m.filter.Host, m.filter.IP)
+	// Remember what was selected so the cursor can follow it
+	prevHost := selectedLabel(m.topHosts, m.hostCursor)
+	prevIP := selectedLabel(m.topIPs, m.ipCursor)
+	prevPath := selectedLabel(m.topPaths, m.pathCursor)
+	prevStatus := m.selectedStatus()
+	sameFilter := m.filter == m.shownFilter
+	m.shownFilter = m.filter
 
-	// Use defaultTopN for now - will be dynamic based on layout in the future
-	topN := defaultTopN
-	m.topHosts = m.store.GetTopHosts(topN, m.filter.IP)
-	m.topIPs = m.store.GetTopIPs(topN, m.filter.Host)
+	m.store.Prune()
+	// Filtered to a host, the header shows that host's own latency
+	if m.filter.Host != "" {
+		m.stats = m.store.GetStatsForHost(m.filter.Host)
+	} else {
+		m.stats = m.store.GetStats()
+	}
+	topN := m.topN
+	m.pathMethods = nil
+	if m.filter.Path != "" {
+		m.statusCounts = m.store.GetStatusCountsForPath(m.filter.Path)
+		m.pathMethods = m.store.GetMethodCountsForPath(m.filter.Path)
+		m.topHosts = m.store.GetTopHostsForPath(topN, m.filter.Path)
+		m.topIPs = m.store.GetTopIPsForPath(topN, m.filter.Path)
+	} else {
+		m.statusCounts = m.store.GetStatusCounts(m.filter.Host, m.filter.IP)
+		if m.bottomHosts {
+			m.topHosts = m.store.GetBottomHosts(topN, m.filter.IP)
+		} else {
+			m.topHosts = m.store.GetTopHosts(topN, m.filter.IP)
+		}
+		m.topIPs = m.store.GetTopIPs(topN, m.filter.Host)
+		if class := m.sortModes[SectionIPs].errorClass(); class != 0 && m.filter.Host == "" {
+			m.topIPs = m.topErrorIPs(topN, class)
+		}
+	}
 
 	// Get paths - always visible, filtered when host/IP is selected
 	if m.filter.Host != "" || m.filter.IP != "" {
@@ -143,28 +538,60 @@ func (m *Model) refreshData() {
 		m.topPaths = m.store.GetAllPaths(topN)
 	}
 
-	// Calculate "other" counts
-	if m.filter.IP == "" {
+	// Calculate "other" counts (when filtered, we don't show "other")
+	m.otherHosts, m.otherIPs = 0, 0
+	if m.filter.IP == "" && m.filter.Path == "" {
 		m.otherHosts = m.store.GetOtherCount(m.store.HostCounts, m.topHosts)
-	} else {
-		// When filtered, we don't show "other"
-		m.otherHosts = 0
 	}
-
-	if m.filter.Host == "" {
+	if m.filter.Host == "" && m.filter.Path == "" {
 		m.otherIPs = m.store.GetOtherCount(m.store.IPCounts, m.topIPs)
-	} else {
-		m.otherIPs = 0
+	}
+
+	// A status or status class filter narrows the tables and stats to
+	// that traffic, on top of any host/IP/path filter
+	if m.filter.Status != 0 || m.filter.StatusClass != 0 {
+		var view store.ClassView
+		if m.filter.Status != 0 {
+			view = m.store.GetStatusView(m.filter.Status, m.filter.Host, m.filter.IP, m.filter.Path, topN)
+		} else {
+			view = m.store.GetStatusClassView(m.filter.StatusClass, m.filter.Host, m.filter.IP, m.filter.Path, topN)
+		}
+		m.stats = view.Stats
+		m.statusCounts = view.Status
+		m.topHosts, m.topIPs, m.topPaths = view.Hosts, view.IPs, view.Paths
+		m.otherHosts, m.otherIPs = 0, 0
 	}
 
 	// Additional stats
+	m.lifetime = m.store.LifetimeCount()
+	m.malformed = m.store.GetMalformedCount()
 	m.rate4xx, m.rate5xx = m.store.GetErrorRates()
 	m.uniqueHosts, m.uniqueIPs, m.uniquePaths = m.store.GetUniqueCounts()
+	m.excludedReqs = m.store.GetExcludedCount()
 	m.currentRate = m.store.GetCurrentRate(currentRateWindow)
+	m.throughput = m.store.GetThroughput(currentRateWindow)
+	m.rateBuckets = m.store.GetRateBuckets(time.Second, rateHistory)
+	m.errorCodes = m.store.GetTopErrorCodes(headerErrorCodes)
+	m.newIPRate = m.store.GetNewUniqueIPRate(newIPRateWindow)
+	m.unknownHosts = m.store.UnknownHostCount()
+	m.latestEntry = m.store.LatestTime()
+	if !m.latestEntry.IsZero() {
+		m.dataSpan = m.latestEntry.Sub(m.store.StartTime())
+	} else {
+		m.dataSpan = 0
+	}
+	m.hotPath, m.hotPathRate = m.store.GetHottestPath(currentRateWindow)
 
 	// Update trends with hysteresis to prevent flickering
 	m.trend = updateTrendWithHysteresis(m.trend, m.store, trendWindow)
 	m.trend5m = updateTrendWithHysteresis(m.trend5m, m.store, trendWindow5m)
+	m.latencyTrend = updateLatencyTrendWithHysteresis(m.latencyTrend, m.store, trendWindow)
+	if m.showHealth {
+		m.health = healthScore(m.healthWeights, m.rate4xx, m.rate5xx, m.stats, m.trend, m.trend5m)
+	}
+	if m.apdexT > 0 {
+		m.apdex = m.store.Apdex(m.apdexT, apdexToleratedFactor*m.apdexT)
+	}
 
 	// Error rates per host/IP/path
 	m.hostErrRates = make(map[string]store.ErrorRates)
@@ -180,28 +607,237 @@ func (m *Model) refreshData() {
 		m.pathErrRates[p.Label] = m.store.GetErrorRatesForPath(p.Label)
 	}
 
-	// Clamp cursors
-	if m.hostCursor >= len(m.topHosts) {
-		m.hostCursor = max(0, len(m.topHosts)-1)
+	// Per-row p95 latency for the host and IP tables
+	m.hostP95 = make(map[string]int)
+	for _, h := range m.topHosts {
+		if p95, ok := m.store.GetP95ForHost(h.Label); ok {
+			m.hostP95[h.Label] = p95
+		}
+	}
+	m.hostReqRates = make(map[string]float64)
+	for _, h := range m.topHosts {
+		m.hostReqRates[h.Label] = m.store.GetRateForHost(h.Label, currentRateWindow)
+	}
+	m.ipP95 = make(map[string]int)
+	for _, ip := range m.topIPs {
+		if p95, ok := m.store.GetP95ForIP(ip.Label); ok {
+			m.ipP95[ip.Label] = p95
+		}
+	}
+
+	// Reorder by the chosen sort, now that error rates are known
+	// Bottom-N hosts keep the store's least-first order under the count sort
+	if !m.showingBottomHosts() || m.sortModes[SectionHosts] != SortCount {
+		sortItems(m.topHosts, m.hostErrRates, m.sortModes[SectionHosts])
+	}
+	sortItems(m.topIPs, m.ipErrRates, m.sortModes[SectionIPs])
+	sortItems(m.topPaths, m.pathErrRates, m.sortModes[SectionPaths])
+	m.applySearch()
+
+	// Hosts/IPs first seen recently get a NEW badge
+	now := m.store.Now()
+	m.newHosts = make(map[string]bool)
+	for _, h := range m.topHosts {
+		if isNew(m.store.GetHostFirstSeen(h.Label), now) {
+			m.newHosts[h.Label] = true
+		}
+	}
+	m.newIPs = make(map[string]bool)
+	for _, ip := range m.topIPs {
+		if isNew(m.store.GetIPFirstSeen(ip.Label), now) {
+			m.newIPs[ip.Label] = true
+		}
+	}
+
+	if m.debug {
+		m.debugStats = m.store.DebugStats()
 	}
-	if m.ipCursor >= len(m.topIPs) {
-		m.ipCursor = max(0, len(m.topIPs)-1)
+
+	// Keep the cursor on the selected label; if it left the list, stay
+	// in place (clamped) and note the loss rather than shifting silently
+	if sameFilter {
+		if lost := reseekCursor(&m.hostCursor, m.topHosts, prevHost); lost != "" {
+			m.lostHost = lost
+		}
+		if lost := reseekCursor(&m.ipCursor, m.topIPs, prevIP); lost != "" {
+			m.lostIP = lost
+		}
+		if lost := reseekCursor(&m.pathCursor, m.topPaths, prevPath); lost != "" {
+			m.lostPath = lost
+		}
+	} else {
+		m.lostHost, m.lostIP, m.lostPath = "", "", ""
+		reseekCursor(&m.hostCursor, m.topHosts, "")
+		reseekCursor(&m.ipCursor, m.topIPs, "")
+		reseekCursor(&m.pathCursor, m.topPaths, "")
 	}
+	m.reseekStatusCursor(prevStatus)
+	m.scrollToCursors()
+
+	if m.focus {
+		m.refreshFocus()
+	}
+}
+
+// applySearch narrows the searched section to labels containing the
+// query. The "other" count no longer makes sense there, so it is dropped.
+func (m *Model) applySearch() {
+	if m.searchQuery == "" {
+		return
+	}
+	switch m.searchSection {
+	case SectionHosts:
+		m.topHosts = matchLabels(m.topHosts, m.searchQuery)
+		m.otherHosts = 0
+	case SectionIPs:
+		m.topIPs = matchLabels(m.topIPs, m.searchQuery)
+		m.otherIPs = 0
+	case SectionPaths:
+		m.topPaths = matchLabels(m.topPaths, m.searchQuery)
+	}
+}
+
+// matchLabels returns the items whose label contains query, ignoring case
+func matchLabels(items []store.CountItem, query string) []store.CountItem {
+	query = strings.ToLower(query)
+	matched := make([]store.CountItem, 0, len(items))
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Label), query) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// showingBottomHosts reports whether the hosts table lists the least
+// frequent hosts. Path and status class views always rank by most
+// requests.
+func (m Model) showingBottomHosts() bool {
+	return m.bottomHosts && m.filter.Path == "" && m.filter.StatusClass == 0 && m.filter.Status == 0
+}
+
+// statusCodeList returns the status codes the status section shows, in
+// cursor order
+func (m Model) statusCodeList() []CodeData {
+	return StatusCodesDataFromStore(m.statusCounts).VisibleCodes(calculateStatusCodeColumns(m.width))
+}
+
+// selectedStatus returns the status code under the cursor, or 0 if none
+func (m Model) selectedStatus() int {
+	codes := m.statusCodeList()
+	if m.statusCursor < 0 || m.statusCursor >= len(codes) {
+		return 0
+	}
+	return codes[m.statusCursor].Code
+}
+
+// reseekStatusCursor keeps the status cursor on prev as codes reorder,
+// clamping it if prev is gone
+func (m *Model) reseekStatusCursor(prev int) {
+	codes := m.statusCodeList()
+	for i, c := range codes {
+		if c.Code == prev {
+			m.statusCursor = i
+			return
+		}
+	}
+	m.statusCursor = min(m.statusCursor, max(0, len(codes)-1))
+}
+
+// topErrorIPs returns the IPs with the most class errors, with their
+// total request counts so the table's columns keep their meaning
+func (m *Model) topErrorIPs(n, class int) []store.CountItem {
+	items := m.store.GetTopErrorIPs(n, class)
+	for i := range items {
+		items[i].Count = m.store.GetIPCount(items[i].Label)
+	}
+	return items
+}
+
+// sortItems orders items by mode. Ties fall back to count, then label, so
+// rows don't shuffle between refreshes.
+func sortItems(items []store.CountItem, rates map[string]store.ErrorRates, mode SortMode) {
+	key := func(item store.CountItem) float64 {
+		switch mode {
+		case Sort4xx:
+			return rates[item.Label].Rate4xx
+		case Sort5xx:
+			return rates[item.Label].Rate5xx
+		case Sort4xxReqs:
+			return rates[item.Label].Rate4xx * float64(item.Count)
+		case Sort5xxReqs:
+			return rates[item.Label].Rate5xx * float64(item.Count)
+		}
+		return 0
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if ki, kj := key(items[i]), key(items[j]); ki != kj {
+			return ki > kj
+		}
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Label < items[j].Label
+	})
+}
+
+// selectedLabel returns the label under the cursor, or "" if none
+func selectedLabel(items []store.CountItem, cursor int) string {
+	if cursor < 0 || cursor >= len(items) {
+		return ""
+	}
+	return items[cursor].Label
+}
+
+// reseekCursor moves cursor to prev's new position in items. If prev is
+// no longer present the cursor is clamped in place and prev is returned.
+func reseekCursor(cursor *int, items []store.CountItem, prev string) (lost string) {
+	if prev != "" {
+		for i, item := range items {
+			if item.Label == prev {
+				*cursor = i
+				return ""
+			}
+		}
+		lost = prev
+	}
+	if *cursor >= len(items) {
+		*cursor = max(0, len(items)-1)
+	}
+	return lost
+}
+
+// isNew reports whether a first-seen time falls within the newness window
+// before now
+func isNew(firstSeen, now time.Time) bool {
+	return !firstSeen.IsZero() && now.Sub(firstSeen) < newBadgeWindow
 }
 
 // updateTrendWithHysteresis applies hysteresis to prevent trend flickering
-// To enter a trend state requires 2% threshold, but to exit back to stable
-// requires the diff to drop below 1%
+// To enter a trend state requires the store's threshold (2 points by
+// default), but to exit back to stable the diff must drop below half that
 func updateTrendWithHysteresis(current store.Trend, s *store.Store, period time.Duration) store.Trend {
 	diff, newTrend := s.GetTrendWithDiff(period)
+	return applyHysteresis(current, diff, newTrend, s.TrendThreshold()/2)
+}
+
+// updateLatencyTrendWithHysteresis is updateTrendWithHysteresis for the
+// p95 latency trend: entering takes a 20% change, exiting a drop below 10%
+func updateLatencyTrendWithHysteresis(current store.Trend, s *store.Store, period time.Duration) store.Trend {
+	diff, newTrend := s.GetLatencyTrendWithDiff(period)
+	return applyHysteresis(current, diff, newTrend, store.LatencyTrendThreshold/2)
+}
 
+// applyHysteresis keeps a shown trend until diff falls below exitBelow,
+// even once newTrend itself has gone back to stable
+func applyHysteresis(current store.Trend, diff float64, newTrend store.Trend, exitBelow float64) store.Trend {
 	// If new calculation shows a clear trend, always follow it
 	if newTrend != store.TrendStable {
 		return newTrend
 	}
 
 	// New calculation says stable - apply hysteresis
-	// Only return to stable if diff is well within the threshold (< 1%)
+	// Only return to stable if diff is well within the threshold
 	if current == store.TrendStable {
 		return store.TrendStable
 	}
@@ -211,7 +847,7 @@ func updateTrendWithHysteresis(current store.Trend, s *store.Store, period time.
 	if absDiff < 0 {
 		absDiff = -absDiff
 	}
-	if absDiff < 0.01 {
+	if absDiff < exitBelow {
 		return store.TrendStable
 	}
 
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+	"github.com/betternow/hstat/store"
+)
+
+func TestSparkline_ScalesToPeak(t *testing.T) {
+	got := sparkline([]float64{0, 1, 4, 8})
+	if got != " ▁▄█" {
+		t.Errorf("expected %q, got %q", " ▁▄█", got)
+	}
+	if got := sparkline([]float64{0, 0}); got != "  " {
+		t.Errorf("expected blanks for no traffic, got %q", got)
+	}
+}
+
+func TestRenderRateSparkline(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 3; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com"})
+	}
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	lines := strings.Split(header, "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[1], "Rate:") || !strings.Contains(lines[1], "█ peak 3.0/s") {
+		t.Errorf("expected rate sparkline under the rate line, got: %s", header)
+	}
+}
+
+func TestRenderRateSparkline_NarrowTerminal(t *testing.T) {
+	m := NewModel(store.New(0), time.Second)
+	m.width = MinWidth
+	m.rateBuckets = make([]float64, rateHistory)
+	m.rateBuckets[rateHistory-1] = 5
+
+	line := stripAnsi(m.renderRateSparkline())
+	if len([]rune(line)) > m.width-4 {
+		t.Errorf("expected sparkline to fit in %d columns, got %d: %q", m.width-4, len([]rune(line)), line)
+	}
+	if !strings.Contains(line, "█ peak 5.0/s") {
+		t.Errorf("expected the most recent bucket to be kept, got %q", line)
+	}
+}
@@ -25,6 +25,18 @@ type CategoryData struct {
 // StatusCodesData holds all status code data for rendering
 type StatusCodesData struct {
 	Categories map[int]CategoryData // key is category number (1, 2, 3, 4, 5)
+	Selected   int                  // code under the cursor, highlighted; 0 for none
+}
+
+// VisibleCodes returns the codes RenderStatusCodesColumnar shows with
+// maxDetailRows, category by category: the list the cursor moves over
+func (d StatusCodesData) VisibleCodes(maxDetailRows int) []CodeData {
+	var codes []CodeData
+	for cat := 1; cat <= 5; cat++ {
+		catCodes := d.Categories[cat].Codes
+		codes = append(codes, catCodes[:min(len(catCodes), maxDetailRows)]...)
+	}
+	return codes
 }
 
 // StatusCodesDataFromStore converts store status counts to StatusCodesData
@@ -143,8 +155,11 @@ func RenderStatusCodesColumnar(data StatusCodesData, width int, maxDetailRows in
 						code.Code,
 						formatNumber(code.Count),
 						code.Percentage)
-					// Apply status color
+					// Apply status color, or highlight the selection
 					styledDetail := StatusCategoryStyle(cat).Render(detail)
+					if code.Code == data.Selected {
+						styledDetail = tableRowSelectedStyle.Render(detail)
+					}
 					detailParts[i] = padToWidth(styledDetail, colWidth)
 				} else {
 					detailParts[i] = strings.Repeat(" ", colWidth)
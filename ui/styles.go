@@ -53,6 +53,11 @@ var (
 	tableRowDimStyle = lipgloss.NewStyle().
 				Foreground(dimColor)
 
+	// Rows whose 5xx rate reaches the critical threshold
+	tableRowAlertStyle = lipgloss.NewStyle().
+				Foreground(errorColor).
+				Bold(true)
+
 	// Status code colors
 	status1xxStyle = lipgloss.NewStyle().Foreground(secondaryColor) // Informational
 	status2xxStyle = lipgloss.NewStyle().Foreground(successColor)
@@ -133,9 +138,23 @@ var (
 	errorRateHighStyle = lipgloss.NewStyle().
 				Foreground(errorColor)
 
+	// Table error rates at or above the critical threshold
+	errorRateCritStyle = lipgloss.NewStyle().
+				Foreground(errorColor).
+				Bold(true)
+
+	// NEW badge for first-seen hosts/IPs
+	newBadgeStyle = lipgloss.NewStyle().
+			Foreground(primaryColor).
+			Bold(true)
+
 	// Count badge style
 	countBadgeStyle = lipgloss.NewStyle().
 			Foreground(dimColor)
+
+	// Request-rate sparkline in the header
+	sparklineStyle = lipgloss.NewStyle().
+			Foreground(primaryColor)
 )
 
 // StatusStyle returns the appropriate style for a status code
@@ -0,0 +1,87 @@
+package ui
+
+import "fmt"
+
+// TruncateMode controls which part of an over-long path is dropped
+type TruncateMode int
+
+const (
+	TruncateEnd    TruncateMode = iota // Keep the prefix: "/api/v2/us..."
+	TruncateStart                      // Keep the suffix: ".../orders/123"
+	TruncateMiddle                     // Keep both ends: "/api/...123"
+)
+
+// ParseTruncateMode parses a --path-truncate flag value
+func ParseTruncateMode(s string) (TruncateMode, error) {
+	switch s {
+	case "end", "":
+		return TruncateEnd, nil
+	case "start":
+		return TruncateStart, nil
+	case "middle":
+		return TruncateMiddle, nil
+	}
+	return TruncateEnd, fmt.Errorf("invalid truncate mode %q (want end, start, or middle)", s)
+}
+
+// String returns the flag value for the mode
+func (t TruncateMode) String() string {
+	switch t {
+	case TruncateStart:
+		return "start"
+	case TruncateMiddle:
+		return "middle"
+	default:
+		return "end"
+	}
+}
+
+// truncateLabel shortens s to at most maxLen chars using the given mode
+func truncateLabel(s string, maxLen int, mode TruncateMode) string {
+	switch mode {
+	case TruncateStart:
+		return truncateStart(s, maxLen)
+	case TruncateMiddle:
+		return truncateMiddle(s, maxLen)
+	default:
+		return truncateEnd(s, maxLen)
+	}
+}
+
+// truncateEnd drops the end of s, keeping the prefix
+func truncateEnd(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return s[:maxLen]
+	}
+	return s[:maxLen-3] + "..."
+}
+
+// truncateStart drops the start of s, keeping the suffix
+func truncateStart(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return s[len(s)-maxLen:]
+	}
+	return "..." + s[len(s)-(maxLen-3):]
+}
+
+// truncateMiddle drops the middle of s, keeping both ends.
+// The extra char on odd budgets goes to the suffix, since the
+// resource at the end of a path is usually the interesting part.
+func truncateMiddle(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return s[:maxLen]
+	}
+	keep := maxLen - 3
+	head := keep / 2
+	tail := keep - head
+	return s[:head] + "..." + s[len(s)-tail:]
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/parser"
+	"github.com/betternow/hstat/store"
+)
+
+const longTestPath = "/api/v2/very/long/nested/segments/resource"
+
+func TestTruncateEnd_KeepsPrefix(t *testing.T) {
+	result := truncateLabel(longTestPath, 20, TruncateEnd)
+	if len(result) != 20 {
+		t.Errorf("expected length 20, got %d (%q)", len(result), result)
+	}
+	if !strings.HasPrefix(result, "/api/v2/very/lon") {
+		t.Errorf("expected prefix preserved, got %q", result)
+	}
+	if !strings.HasSuffix(result, "...") {
+		t.Errorf("expected trailing ellipsis, got %q", result)
+	}
+}
+
+func TestTruncateStart_KeepsSuffix(t *testing.T) {
+	result := truncateLabel(longTestPath, 20, TruncateStart)
+	if len(result) != 20 {
+		t.Errorf("expected length 20, got %d (%q)", len(result), result)
+	}
+	if !strings.HasSuffix(result, "segments/resource") {
+		t.Errorf("expected suffix preserved, got %q", result)
+	}
+	if !strings.HasPrefix(result, "...") {
+		t.Errorf("expected leading ellipsis, got %q", result)
+	}
+}
+
+func TestTruncateMiddle_KeepsBothEnds(t *testing.T) {
+	result := truncateLabel(longTestPath, 20, TruncateMiddle)
+	if len(result) != 20 {
+		t.Errorf("expected length 20, got %d (%q)", len(result), result)
+	}
+	if !strings.HasPrefix(result, "/api/v2") {
+		t.Errorf("expected prefix preserved, got %q", result)
+	}
+	if !strings.HasSuffix(result, "resource") {
+		t.Errorf("expected suffix preserved, got %q", result)
+	}
+	if !strings.Contains(result, "...") {
+		t.Errorf("expected ellipsis in the middle, got %q", result)
+	}
+}
+
+func TestTruncate_ShortLabelUnchanged(t *testing.T) {
+	for _, mode := range []TruncateMode{TruncateEnd, TruncateStart, TruncateMiddle} {
+		if result := truncateLabel("/users", 20, mode); result != "/users" {
+			t.Errorf("mode %s: expected short label unchanged, got %q", mode, result)
+		}
+	}
+}
+
+func TestParseTruncateMode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected TruncateMode
+		wantErr  bool
+	}{
+		{"end", TruncateEnd, false},
+		{"start", TruncateStart, false},
+		{"middle", TruncateMiddle, false},
+		{"", TruncateEnd, false},
+		{"sideways", TruncateEnd, true},
+	}
+
+	for _, tc := range tests {
+		mode, err := ParseTruncateMode(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseTruncateMode(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+		}
+		if mode != tc.expected {
+			t.Errorf("ParseTruncateMode(%q) = %v, expected %v", tc.input, mode, tc.expected)
+		}
+	}
+}
+
+func TestRenderPaths_UsesTruncateMode(t *testing.T) {
+	s := store.New(0)
+	longPath := "/api/v2/users/12345678/orders/87654321/items/details/extended/view/resource-name"
+	s.Add(&parser.Entry{Status: 200, Host: "api.com", Path: longPath, IP: "1.1.1.1"})
+
+	m := NewModel(s, time.Second, WithPathTruncate(TruncateStart))
+	m.width = 80
+	m.height = 50
+	m.refreshData()
+
+	paths := stripAnsi(m.renderPathsContent(10, 76))
+	if !strings.Contains(paths, "resource-name") {
+		t.Errorf("expected start truncation to keep the path suffix, got: %s", paths)
+	}
+	if strings.Contains(paths, "/api/v2/users") {
+		t.Errorf("expected start truncation to drop the path prefix, got: %s", paths)
+	}
+}
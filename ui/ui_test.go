@@ -1,13 +1,22 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/betternow/hstat/geoip"
 	"github.com/betternow/hstat/parser"
 	"github.com/betternow/hstat/store"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // testEntry creates a parser.Entry for testing
@@ -178,6 +187,54 @@ func TestHandleKey_Quit(t *testing.T) {
 	}
 }
 
+func TestHandleKey_ConfirmQuit(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second, WithConfirmQuit(true))
+	q := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}
+
+	newM, cmd := m.handleKey(q)
+	if cmd != nil {
+		t.Fatal("expected first q to ask for confirmation, not quit")
+	}
+	model := newM.(Model)
+	if model.notice != "Press q again to quit" {
+		t.Errorf("notice = %q, want confirmation prompt", model.notice)
+	}
+
+	// Any other key disarms
+	newM, _ = model.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	newM, cmd = newM.(Model).handleKey(q)
+	if cmd != nil {
+		t.Fatal("expected q after another key to ask again")
+	}
+
+	_, cmd = newM.(Model).handleKey(q)
+	if cmd == nil {
+		t.Error("expected second q to quit")
+	}
+}
+
+func TestHandleKey_ConfirmQuitInSearch(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second, WithConfirmQuit(true))
+	m.searchMode = true
+	ctrlC := tea.KeyMsg{Type: tea.KeyCtrlC}
+
+	newM, cmd := m.handleKey(ctrlC)
+	if cmd != nil {
+		t.Fatal("expected first ctrl+c in search to ask for confirmation, not quit")
+	}
+	model := newM.(Model)
+	if model.notice != "Press ctrl+c again to quit" {
+		t.Errorf("notice = %q, want confirmation prompt", model.notice)
+	}
+
+	_, cmd = model.handleKey(ctrlC)
+	if cmd == nil {
+		t.Error("expected second ctrl+c to quit")
+	}
+}
+
 func TestHandleKey_Help(t *testing.T) {
 	s := store.New(0)
 	m := NewModel(s, time.Second)
@@ -218,12 +275,128 @@ func TestHandleKey_SectionNavigation(t *testing.T) {
 		t.Error("expected SectionIPs after Tab")
 	}
 
+	// Tab to paths
+	newM, _ = model.handleKey(tea.KeyMsg{Type: tea.KeyTab})
+	model = newM.(Model)
+	if model.section != SectionPaths {
+		t.Error("expected SectionPaths after Tab")
+	}
+
+	// Tab to status codes
+	newM, _ = model.handleKey(tea.KeyMsg{Type: tea.KeyTab})
+	model = newM.(Model)
+	if model.section != SectionStatus {
+		t.Error("expected SectionStatus after Tab")
+	}
+
 	// Tab back to hosts (wraps)
 	newM, _ = model.handleKey(tea.KeyMsg{Type: tea.KeyTab})
 	model = newM.(Model)
 	if model.section != SectionHosts {
 		t.Error("expected SectionHosts after Tab (wrap)")
 	}
+
+	// Shift+Tab wraps back to status codes
+	newM, _ = model.handleKey(tea.KeyMsg{Type: tea.KeyShiftTab})
+	model = newM.(Model)
+	if model.section != SectionStatus {
+		t.Error("expected SectionStatus after Shift+Tab (wrap)")
+	}
+
+	// Without the status section, Tab skips it
+	m = NewModel(s, time.Second, WithNoStatusSection(true))
+	m.section = SectionPaths
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyTab})
+	if section := newM.(Model).section; section != SectionHosts {
+		t.Errorf("expected Tab to skip the hidden status section, got %v", section)
+	}
+}
+
+func TestStatusSection_FilterByCode(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/"})
+	s.Add(&parser.Entry{Status: 422, Host: "a.com", IP: "6.6.6.6", Path: "/signup"})
+	s.Add(&parser.Entry{Status: 422, Host: "a.com", IP: "6.6.6.6", Path: "/signup"})
+	s.Add(&parser.Entry{Status: 404, Host: "b.com", IP: "2.2.2.2", Path: "/missing"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+	m.section = SectionStatus
+
+	// Codes run 2xx, then 4xx by count: 200, 422, 404
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = newM.(Model)
+	if got := m.selectedStatus(); got != 422 {
+		t.Fatalf("expected 422 selected, got %d", got)
+	}
+
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+	if m.filter.Status != 422 {
+		t.Fatalf("expected status filter 422, got %+v", m.filter)
+	}
+	if m.stats.TotalCount != 2 || len(m.topIPs) != 1 || m.topIPs[0].Label != "6.6.6.6" {
+		t.Errorf("expected the dashboard narrowed to the 422s, got %d requests, IPs %v", m.stats.TotalCount, m.topIPs)
+	}
+	if header := stripAnsi(m.renderHeaderContent()); !strings.Contains(header, "[422 only]") {
+		t.Errorf("expected status filter in header, got: %s", header)
+	}
+
+	// A class toggle replaces the exact code
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'4'}})
+	if f := newM.(Model).filter; f.Status != 0 || f.StatusClass != 4 {
+		t.Errorf("expected 4xx class filter without a code, got %+v", f)
+	}
+}
+
+func TestHandleKey_PathFilter(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "api.com", IP: "1.1.1.1", Path: "/api/orders"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 500, Host: "api.com", IP: "2.2.2.2", Path: "/api/orders"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "web.com", IP: "3.3.3.3", Path: "/home"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "web.com", IP: "3.3.3.3", Path: "/home"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "web.com", IP: "3.3.3.3", Path: "/home"})
+
+	m := NewModel(s, time.Second)
+	m.width = 100
+	m.height = 50
+	m.refreshData()
+
+	// Select the second path (/api/orders) and filter on it
+	m.section = SectionPaths
+	m.moveCursor(1)
+	if m.topPaths[m.pathCursor].Label != "/api/orders" {
+		t.Fatalf("expected cursor on /api/orders, got %s", m.topPaths[m.pathCursor].Label)
+	}
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+
+	if m.filter != (Filter{Path: "/api/orders"}) {
+		t.Fatalf("expected path filter, got %+v", m.filter)
+	}
+	if len(m.topHosts) != 1 || m.topHosts[0].Label != "api.com" {
+		t.Errorf("expected only api.com for /api/orders, got %v", m.topHosts)
+	}
+	if len(m.topIPs) != 2 {
+		t.Errorf("expected 2 IPs for /api/orders, got %v", m.topIPs)
+	}
+	if len(m.statusCounts) != 2 {
+		t.Errorf("expected 200 and 500 for /api/orders, got %v", m.statusCounts)
+	}
+
+	view := stripAnsi(m.View())
+	if !strings.Contains(view, "Path: /api/orders") {
+		t.Error("expected path filter in paths section title")
+	}
+
+	// Esc clears the path filter
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newM.(Model)
+	if m.filter != (Filter{}) {
+		t.Errorf("expected filter cleared, got %+v", m.filter)
+	}
 }
 
 func TestHandleKey_CursorMovement(t *testing.T) {
@@ -269,6 +442,56 @@ func TestHandleKey_Filter(t *testing.T) {
 	}
 }
 
+func TestHandleKey_FilterHistory(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "api.com", "1.1.1.1"))
+	s.Add(testEntry(200, "api.com", "1.1.1.1"))
+	s.Add(testEntry(200, "web.com", "2.2.2.2"))
+
+	m := NewModel(s, time.Second)
+	m.width = 100
+	m.height = 50
+	m.refreshData()
+
+	press := func(r rune) {
+		t.Helper()
+		newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newM.(Model)
+	}
+
+	// Filter to api.com, then to the IP 1.1.1.1
+	m.applyFilter()
+	m.section = SectionIPs
+	m.applyFilter()
+	if m.filter != (Filter{IP: "1.1.1.1"}) {
+		t.Fatalf("expected IP filter, got %+v", m.filter)
+	}
+
+	press('<')
+	if m.filter != (Filter{Host: "api.com"}) {
+		t.Errorf("expected back to restore api.com, got %+v", m.filter)
+	}
+
+	// Already at the oldest entry
+	press('<')
+	if m.filter != (Filter{Host: "api.com"}) {
+		t.Errorf("expected to stay on api.com, got %+v", m.filter)
+	}
+
+	press('>')
+	if m.filter != (Filter{IP: "1.1.1.1"}) {
+		t.Errorf("expected forward to restore 1.1.1.1, got %+v", m.filter)
+	}
+
+	// Back after clearing brings back the filter that was cleared
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newM.(Model)
+	press('<')
+	if m.filter != (Filter{IP: "1.1.1.1"}) {
+		t.Errorf("expected back after clear to restore 1.1.1.1, got %+v", m.filter)
+	}
+}
+
 func TestHandleKey_ModalDismissal(t *testing.T) {
 	s := store.New(0)
 	m := NewModel(s, time.Second)
@@ -459,6 +682,40 @@ func TestEntryMsg_UpdatesLastEntryTime(t *testing.T) {
 	}
 }
 
+func TestEntriesMsg_AddsBatch(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second)
+
+	newM, _ := m.Update(EntriesMsg{
+		testEntry(200, "a.com", "1.1.1.1"),
+		testEntry(500, "b.com", "2.2.2.2"),
+	})
+
+	if s.TotalCount != 2 {
+		t.Errorf("expected both entries added, got %d", s.TotalCount)
+	}
+	if newM.(Model).lastEntryTime.IsZero() {
+		t.Error("expected lastEntryTime set")
+	}
+}
+
+func TestApplyHysteresis_LatencyTrend(t *testing.T) {
+	exit := store.LatencyTrendThreshold / 2
+
+	// Still 15% slower: below the entry threshold, but the arrow stays
+	if got := applyHysteresis(store.TrendUp, 0.15, store.TrendStable, exit); got != store.TrendUp {
+		t.Errorf("expected TrendUp to stick at 15%%, got %v", got)
+	}
+	// Back within 10%: cleared
+	if got := applyHysteresis(store.TrendUp, 0.05, store.TrendStable, exit); got != store.TrendStable {
+		t.Errorf("expected TrendStable at 5%%, got %v", got)
+	}
+	// Never shown at 15% from stable
+	if got := applyHysteresis(store.TrendStable, 0.15, store.TrendStable, exit); got != store.TrendStable {
+		t.Errorf("expected TrendStable to hold at 15%%, got %v", got)
+	}
+}
+
 func TestRenderHeader_ShowsNoDataWarning(t *testing.T) {
 	s := store.New(0)
 	m := NewModel(s, time.Second)
@@ -474,6 +731,23 @@ func TestRenderHeader_ShowsNoDataWarning(t *testing.T) {
 	}
 }
 
+func TestRenderHeader_StaleAfter(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second, WithStaleAfter(5*time.Minute))
+	m.width = 100
+	m.height = 50
+
+	m.lastEntryTime = time.Now().Add(-45 * time.Second)
+	if header := m.renderHeader(); strings.Contains(header, "no data") {
+		t.Errorf("expected no warning 45s in with -stale-after 5m, got: %s", header)
+	}
+
+	m.lastEntryTime = time.Now().Add(-6 * time.Minute)
+	if header := m.renderHeader(); !strings.Contains(header, "no data") {
+		t.Errorf("expected a warning after 6m quiet, got: %s", header)
+	}
+}
+
 func TestRenderHeader_NoWarningWhenRecentData(t *testing.T) {
 	s := store.New(0)
 	m := NewModel(s, time.Second)
@@ -872,3 +1146,1319 @@ var errTest = testError{}
 type testError struct{}
 
 func (testError) Error() string { return "test error" }
+
+func TestRenderHosts_NewBadgeForRecentlySeenHost(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now().Add(-time.Minute), Status: 200, Host: "old.com", IP: "1.1.1.1"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "fresh.com", IP: "1.1.1.1"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	content := stripAnsi(m.renderHostsContent(10, 60))
+	var freshLine, oldLine string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.Contains(line, "fresh.com") {
+			freshLine = line
+		}
+		if strings.Contains(line, "old.com") {
+			oldLine = line
+		}
+	}
+
+	if !strings.Contains(freshLine, "NEW") {
+		t.Errorf("expected NEW badge for host first seen within newness window, got: %q", freshLine)
+	}
+	if strings.Contains(oldLine, "NEW") {
+		t.Errorf("expected no NEW badge for host first seen a minute ago, got: %q", oldLine)
+	}
+}
+
+func TestRenderHeader_WatchCodes(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 8; i++ {
+		s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	}
+	s.Add(testEntry(429, "a.com", "1.1.1.1"))
+	s.Add(testEntry(429, "a.com", "1.1.1.1"))
+
+	m := NewModel(s, time.Second, WithWatchCodes([]int{429, 502}))
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	if !strings.Contains(header, "429:2 (20.0%)") {
+		t.Errorf("expected watched 429 counter in header, got: %s", header)
+	}
+	// Watched codes show even when they haven't occurred
+	if !strings.Contains(header, "502:0 (0.0%)") {
+		t.Errorf("expected zero counter for watched 502, got: %s", header)
+	}
+}
+
+func TestParseWatchCodes(t *testing.T) {
+	codes, err := ParseWatchCodes("429, 499,502")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != 3 || codes[0] != 429 || codes[1] != 499 || codes[2] != 502 {
+		t.Errorf("expected [429 499 502], got %v", codes)
+	}
+
+	if _, err := ParseWatchCodes("429,abc"); err == nil {
+		t.Error("expected error for non-numeric code")
+	}
+	if _, err := ParseWatchCodes("42"); err == nil {
+		t.Error("expected error for out-of-range code")
+	}
+	if codes, err := ParseWatchCodes(""); err != nil || len(codes) != 0 {
+		t.Errorf("expected no codes for empty input, got %v, %v", codes, err)
+	}
+}
+
+func TestHandleKey_HostDetail(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Method: "GET", Host: "a.com", IP: "1.1.1.1", Bytes: 20480})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 500, Method: "GET", Host: "a.com", IP: "1.1.1.1", Bytes: 512})
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model := newM.(Model)
+	if !model.modal.Visible || model.modal.Title != "host a.com" {
+		t.Fatalf("expected host detail modal for a.com, got %+v", model.modal)
+	}
+	if !strings.Contains(model.modal.Content, "Methods:  GET 100%") {
+		t.Errorf("expected method split in detail, got:\n%s", model.modal.Content)
+	}
+	if !strings.Contains(model.modal.Content, "ok        20.0KB") {
+		t.Errorf("expected ok avg bytes in detail, got:\n%s", model.modal.Content)
+	}
+	if !strings.Contains(model.modal.Content, "errors      512B") {
+		t.Errorf("expected error avg bytes in detail, got:\n%s", model.modal.Content)
+	}
+	if !strings.Contains(model.modal.Content, "Latency:  p50 ") {
+		t.Errorf("expected latency percentiles in detail, got:\n%s", model.modal.Content)
+	}
+	if !strings.Contains(model.modal.Content, "Top IPs:\n         2  1.1.1.1") {
+		t.Errorf("expected top IPs in detail, got:\n%s", model.modal.Content)
+	}
+}
+
+func TestView_DebugFooter(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/a"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "b.com", IP: "1.1.1.1", Path: "/b"})
+
+	m := NewModel(s, time.Second, WithDebug(true))
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	view := stripAnsi(m.View())
+	if !strings.Contains(view, "entries: 2/100,000 · hosts: 2 · ips: 1 · paths: 2") {
+		t.Errorf("expected debug footer, got:\n%s", view)
+	}
+	if lines := strings.Count(view, "\n") + 1; lines > m.height {
+		t.Errorf("expected view to fit %d lines with footer, got %d", m.height, lines)
+	}
+}
+
+func TestFormatThousands(t *testing.T) {
+	tests := map[int64]string{0: "0", 999: "999", 1000: "1,000", 84201: "84,201", 1234567: "1,234,567"}
+	for n, want := range tests {
+		if got := formatThousands(n); got != want {
+			t.Errorf("formatThousands(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestRefreshData_SelectedHostPruned(t *testing.T) {
+	s := store.New(time.Minute)
+	old := time.Now().Add(-2 * time.Minute)
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", IP: "1.1.1.1"})
+	}
+	for i := 0; i < 3; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "b.com", IP: "1.1.1.1"})
+	}
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "c.com", IP: "1.1.1.1"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+
+	// Build the list without pruning, then select b.com
+	m.topHosts = s.GetTopHosts(defaultTopN, "")
+	m.hostCursor = 1
+	if got := selectedLabel(m.topHosts, m.hostCursor); got != "b.com" {
+		t.Fatalf("expected b.com selected, got %s", got)
+	}
+
+	// Followed by label when it moves: c.com overtakes b.com
+	for i := 0; i < 4; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "c.com", IP: "1.1.1.1"})
+	}
+	m.refreshData()
+	if got := selectedLabel(m.topHosts, m.hostCursor); got != "b.com" {
+		t.Errorf("expected cursor to follow b.com after reorder, got %s", got)
+	}
+	if m.lostHost != "" {
+		t.Errorf("expected no lost selection, got %s", m.lostHost)
+	}
+
+	// Now b.com ages out of the window entirely
+	s2 := store.New(time.Minute)
+	for i := 0; i < 3; i++ {
+		s2.Add(&parser.Entry{Timestamp: old, Status: 200, Host: "b.com", IP: "1.1.1.1"})
+	}
+	for i := 0; i < 5; i++ {
+		s2.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", IP: "1.1.1.1"})
+	}
+	m = NewModel(s2, time.Second)
+	m.width = 120
+	m.height = 40
+	m.topHosts = s2.GetTopHosts(defaultTopN, "")
+	m.hostCursor = 1 // b.com
+
+	m.refreshData()
+	if m.lostHost != "b.com" {
+		t.Errorf("expected b.com to be noted as lost, got %q", m.lostHost)
+	}
+	if m.hostCursor != 0 {
+		t.Errorf("expected cursor clamped to 0, got %d", m.hostCursor)
+	}
+	if view := stripAnsi(m.View()); !strings.Contains(view, "selection lost: b.com") {
+		t.Errorf("expected selection lost note in view, got:\n%s", view)
+	}
+
+	// The note persists across ticks until the user moves
+	m.refreshData()
+	if m.lostHost != "b.com" {
+		t.Errorf("expected lost note to persist across refresh, got %q", m.lostHost)
+	}
+	m.moveCursor(1)
+	if m.lostHost != "" {
+		t.Errorf("expected lost note cleared on cursor move, got %q", m.lostHost)
+	}
+}
+
+func TestRenderHeader_HotPath(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 30; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/api/feed"})
+	}
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/api/users"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	if !strings.Contains(header, "hot: /api/feed 3.0/s") {
+		t.Errorf("expected hot path callout, got: %s", header)
+	}
+}
+
+func TestRenderHeader_NewIPRate(t *testing.T) {
+	s := store.New(0)
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		s.Add(testEntry(200, "a.com", ip))
+	}
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	if !strings.Contains(header, "new IPs: 3/min") {
+		t.Errorf("expected new IP rate in header, got: %s", header)
+	}
+}
+
+func TestFocusMode_RendersHostInspector(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", IP: "1.1.1.1", Path: "/feed", Service: 40})
+	}
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 503, Host: "b.com", IP: "2.2.2.2", Path: "/slow", Service: 900})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "b.com", IP: "3.3.3.3", Path: "/ok", Service: 100})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+	m.moveCursor(1) // b.com
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	m = newM.(Model)
+
+	view := stripAnsi(m.View())
+	if !strings.Contains(view, "Focus: b.com") {
+		t.Fatalf("expected host inspector for b.com, got:\n%s", view)
+	}
+	for _, want := range []string{"5xx 50.0%", "max 900ms", "2.2.2.2", "3.3.3.3", "/slow", "503:1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected inspector to show %q, got:\n%s", want, view)
+		}
+	}
+	panel := view[strings.Index(view, "Focus: b.com"):]
+	if strings.Contains(panel, "1.1.1.1") || strings.Contains(panel, "/feed") {
+		t.Errorf("expected unrelated host data hidden, got:\n%s", panel)
+	}
+	if m.filter != (Filter{}) {
+		t.Errorf("expected global filter untouched, got %+v", m.filter)
+	}
+	if lines := strings.Count(view, "\n") + 1; lines > m.height {
+		t.Errorf("expected view to fit %d lines, got %d", m.height, lines)
+	}
+
+	// Esc pops back out without quitting
+	newM, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newM.(Model)
+	if m.focus || cmd != nil {
+		t.Error("expected Esc to leave focus mode without quitting")
+	}
+	if strings.Contains(stripAnsi(m.View()), "Focus:") {
+		t.Error("expected normal view after leaving focus mode")
+	}
+}
+
+func TestRenderHeader_WindowNote(t *testing.T) {
+	s := store.New(10 * time.Minute)
+	now := time.Now()
+	s.Add(&parser.Entry{Timestamp: now.Add(-5 * time.Minute), Status: 200, Host: "a.com"})
+	s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "a.com"})
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	if !strings.Contains(header, "window 10m (only 5m of data)") {
+		t.Errorf("expected short-span note, got: %s", header)
+	}
+
+	// Once the data spans (nearly) the whole window, the note goes away
+	s = store.New(10 * time.Minute)
+	s.Add(&parser.Entry{Timestamp: now.Add(-9*time.Minute - 50*time.Second), Status: 200, Host: "a.com"})
+	s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "a.com"})
+
+	m = NewModel(s, time.Second)
+	m.refreshData()
+
+	header = stripAnsi(m.renderHeaderContent())
+	if strings.Contains(header, "of data") {
+		t.Errorf("expected no note once data covers the window, got: %s", header)
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		45 * time.Second:               "45s",
+		5 * time.Minute:                "5m",
+		5*time.Minute + 20*time.Second: "5m",
+		time.Hour:                      "1h",
+		90 * time.Minute:               "1h30m",
+	}
+	for d, want := range tests {
+		if got := shortDuration(d); got != want {
+			t.Errorf("shortDuration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
+
+func TestHandleKey_ErrorCodes(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 503, Host: "a.com", Code: "H12", Desc: "Request timeout"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 503, Host: "a.com", Code: "H12", Desc: "Request timeout"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 503, Host: "a.com", Code: "H18", Desc: "Server Request Interrupted"})
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	header := stripAnsi(m.renderHeaderContent())
+	if !strings.Contains(header, "Codes: H12:2 H18:1") {
+		t.Errorf("expected error codes in header, got: %s", header)
+	}
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	model := newM.(Model)
+	if !model.modal.Visible {
+		t.Fatal("expected error codes modal")
+	}
+	if !strings.Contains(model.modal.Content, "H12          2  Request timeout") {
+		t.Errorf("expected H12 row in modal, got:\n%s", model.modal.Content)
+	}
+}
+
+func TestRefreshData_StatsFollowHostFilter(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "slow.com", Service: 900})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "fast.com", Service: 10})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "fast.com", Service: 20})
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	if m.stats.MaxService != 900 {
+		t.Errorf("expected global max 900ms, got %d", m.stats.MaxService)
+	}
+
+	m.filter = Filter{Host: "fast.com"}
+	m.refreshData()
+	if m.stats.TotalCount != 2 || m.stats.MaxService != 20 {
+		t.Errorf("expected fast.com stats (2 reqs, max 20ms), got %+v", m.stats)
+	}
+}
+
+func TestHandleKey_SortCycles(t *testing.T) {
+	s := store.New(0)
+	now := time.Now()
+	// busy.com: most requests, no errors; flaky.com: 4xx; broken.com: 5xx
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "busy.com"})
+	}
+	s.Add(&parser.Entry{Timestamp: now, Status: 404, Host: "flaky.com"})
+	s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "flaky.com"})
+	s.Add(&parser.Entry{Timestamp: now, Status: 502, Host: "broken.com"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	press := func() {
+		newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		m = newM.(Model)
+	}
+	first := func() string { return m.topHosts[0].Label }
+
+	if first() != "busy.com" {
+		t.Fatalf("expected busy.com first by count, got %s", first())
+	}
+
+	press()
+	if m.sortModes[SectionHosts] != Sort4xx || first() != "flaky.com" {
+		t.Errorf("expected flaky.com first by 4xx rate, got %s", first())
+	}
+	if !strings.Contains(stripAnsi(m.View()), "sort: 4xx") {
+		t.Error("expected sort mode in hosts title")
+	}
+
+	press()
+	if first() != "broken.com" {
+		t.Errorf("expected broken.com first by 5xx rate, got %s", first())
+	}
+	// Ties (0% 5xx) fall back to count
+	if m.topHosts[1].Label != "busy.com" {
+		t.Errorf("expected busy.com second on count tie-break, got %s", m.topHosts[1].Label)
+	}
+
+	press()
+	if m.sortModes[SectionHosts] != SortCount || first() != "busy.com" {
+		t.Errorf("expected sort to wrap back to count, got %s", first())
+	}
+
+	// Other sections keep their own sort
+	if m.sortModes[SectionIPs] != SortCount {
+		t.Error("expected IPs sort to be unaffected")
+	}
+}
+
+func TestHandleKey_SortIPsByErrorCount(t *testing.T) {
+	s := store.New(0)
+	now := time.Now()
+	// Plenty of busy clients, and a quiet scanner that is all 404s
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 20; j++ {
+			s.Add(&parser.Entry{Timestamp: now, Status: 200, IP: fmt.Sprintf("10.0.0.%d", i)})
+		}
+	}
+	for i := 0; i < 3; i++ {
+		s.Add(&parser.Entry{Timestamp: now, Status: 404, IP: "6.6.6.6"})
+	}
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.topN = 3
+	m.section = SectionIPs
+	m.refreshData()
+
+	for _, ip := range m.topIPs {
+		if ip.Label == "6.6.6.6" {
+			t.Fatal("expected the scanner outside the top IPs by count")
+		}
+	}
+
+	for range 3 {
+		newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		m = newM.(Model)
+	}
+	if m.sortModes[SectionIPs] != Sort4xxReqs {
+		t.Fatalf("expected the 4xx request sort, got %v", m.sortModes[SectionIPs])
+	}
+	if len(m.topIPs) == 0 || m.topIPs[0].Label != "6.6.6.6" || m.topIPs[0].Count != 3 {
+		t.Errorf("expected the scanner first with its 3 requests, got %v", m.topIPs)
+	}
+
+	// Other tables skip the IP-only sorts
+	if next := nextSortMode(SectionHosts, Sort5xx); next != SortCount {
+		t.Errorf("expected hosts to wrap after 5xx, got %v", next)
+	}
+}
+
+func TestPause_FreezesSnapshotButKeepsIngesting(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	update := func(msg tea.Msg) {
+		newM, _ := m.Update(msg)
+		m = newM.(Model)
+	}
+	pause := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+
+	update(pause)
+	if !strings.Contains(stripAnsi(m.renderHeaderContent()), "PAUSED") {
+		t.Error("expected PAUSED indicator in header")
+	}
+
+	update(EntryMsg{Entry: testEntry(500, "b.com", "2.2.2.2")})
+	update(TickMsg(time.Now()))
+	if m.stats.TotalCount != 1 || len(m.topHosts) != 1 {
+		t.Errorf("expected snapshot to hold while paused, got %d reqs, %d hosts", m.stats.TotalCount, len(m.topHosts))
+	}
+	if s.TotalCount != 2 {
+		t.Errorf("expected store to keep ingesting, got %d", s.TotalCount)
+	}
+
+	// Filtering still applies while paused
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filter.Host != "a.com" {
+		t.Errorf("expected filter to apply while paused, got %+v", m.filter)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	update(pause)
+	update(TickMsg(time.Now()))
+	if m.stats.TotalCount != 2 || len(m.topHosts) != 2 {
+		t.Errorf("expected catch-up after unpause, got %d reqs, %d hosts", m.stats.TotalCount, len(m.topHosts))
+	}
+}
+
+func TestSearch_NarrowsActiveSection(t *testing.T) {
+	s := store.New(0)
+	for _, host := range []string{"api.example.com", "www.example.com", "API-staging.test"} {
+		s.Add(testEntry(200, host, "1.1.1.1"))
+	}
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	key := func(msg tea.KeyMsg) {
+		newM, _ := m.handleKey(msg)
+		m = newM.(Model)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	typeText("apx")
+	if len(m.topHosts) != 0 {
+		t.Errorf("expected no hosts matching apx, got %v", m.topHosts)
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyBackspace})
+	if len(m.topHosts) != 2 {
+		t.Errorf("expected 2 hosts matching ap (case-insensitive), got %v", m.topHosts)
+	}
+	if m.otherHosts != 0 {
+		t.Errorf("expected no other count while searching, got %d", m.otherHosts)
+	}
+	if !strings.Contains(stripAnsi(m.View()), "/ap_") {
+		t.Error("expected search query in hosts title")
+	}
+	if len(m.topIPs) != 1 {
+		t.Errorf("expected IPs to be unaffected, got %v", m.topIPs)
+	}
+
+	// Enter keeps the search; keys work normally again
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if m.searchMode || m.hostCursor != 1 || len(m.topHosts) != 2 {
+		t.Errorf("expected search kept after enter, cursor %d, hosts %v", m.hostCursor, m.topHosts)
+	}
+
+	// Esc restores the full list
+	key(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.searchQuery != "" || len(m.topHosts) != 3 {
+		t.Errorf("expected full list after esc, got %v", m.topHosts)
+	}
+}
+
+func TestExportCSVKey(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	path := t.TempDir() + "/out.csv"
+	m := NewModel(s, time.Second, WithCSVPath(path))
+
+	_, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if cmd == nil {
+		t.Fatal("expected a command to write the CSV")
+	}
+	msg, ok := cmd().(ExportResultMsg)
+	if !ok || msg.Err != nil || msg.Path != path {
+		t.Fatalf("expected successful ExportResultMsg for %s, got %#v", path, msg)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "kind,label,count,pct,rate4xx,rate5xx\nhost,a.com,1,") {
+		t.Errorf("unexpected CSV:\n%s", data)
+	}
+
+	newM, _ := m.Update(msg)
+	if header := stripAnsi(newM.(Model).renderHeaderContent()); !strings.Contains(header, "CSV saved: "+path) {
+		t.Errorf("expected export confirmation in header, got: %s", header)
+	}
+}
+
+func TestSnapshot_WritesPlainView(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	_, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	if cmd == nil {
+		t.Fatal("expected a command to write the snapshot")
+	}
+	msg, ok := cmd().(SnapshotResultMsg)
+	if !ok || msg.Err != nil {
+		t.Fatalf("expected successful SnapshotResultMsg, got %#v", msg)
+	}
+	if !strings.HasPrefix(msg.Path, "hstat-snapshot-") || !strings.HasSuffix(msg.Path, ".txt") {
+		t.Errorf("unexpected snapshot path %q", msg.Path)
+	}
+
+	data, err := os.ReadFile(msg.Path)
+	if err != nil {
+		t.Fatalf("reading snapshot: %v", err)
+	}
+	content := string(data)
+	if !strings.HasPrefix(content, "hstat snapshot ") || !strings.Contains(content, "a.com") {
+		t.Errorf("expected timestamped view in snapshot, got %q", content)
+	}
+	if strings.Contains(content, "\x1b[") {
+		t.Error("expected ANSI sequences stripped from snapshot")
+	}
+
+	newM, _ := m.Update(msg)
+	m = newM.(Model)
+	if !strings.Contains(stripAnsi(m.renderHeaderContent()), "snapshot saved: "+msg.Path) {
+		t.Error("expected snapshot confirmation in header")
+	}
+}
+
+func TestStepWindow(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		dir  int
+		want time.Duration
+	}{
+		{10 * time.Minute, 1, 15 * time.Minute},
+		{10 * time.Minute, -1, 5 * time.Minute},
+		{7 * time.Minute, 1, 10 * time.Minute},
+		{7 * time.Minute, -1, 5 * time.Minute},
+		{time.Minute, -1, time.Minute},
+		{24 * time.Hour, 1, 0},
+		{0, 1, 0},
+		{0, -1, 24 * time.Hour},
+	}
+	for _, tc := range tests {
+		if got := stepWindow(tc.in, tc.dir); got != tc.want {
+			t.Errorf("stepWindow(%v, %d) = %v, want %v", tc.in, tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestStepRefresh(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		dir  int
+		want time.Duration
+	}{
+		{time.Second, 1, 2 * time.Second},
+		{time.Second, -1, 500 * time.Millisecond},
+		{1500 * time.Millisecond, 1, 2 * time.Second},
+		{1500 * time.Millisecond, -1, time.Second},
+		{200 * time.Millisecond, -1, 200 * time.Millisecond},
+		{10 * time.Second, 1, 10 * time.Second},
+		{time.Minute, -1, 10 * time.Second},
+		{50 * time.Millisecond, 1, 200 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		if got := stepRefresh(tc.in, tc.dir); got != tc.want {
+			t.Errorf("stepRefresh(%v, %d) = %v, want %v", tc.in, tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestRefreshKeys_ChangeRate(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second)
+	m.width = 120
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'.'}})
+	m = newM.(Model)
+	if m.refreshRate != 2*time.Second {
+		t.Errorf("expected refresh 2s, got %v", m.refreshRate)
+	}
+	if header := stripAnsi(m.renderHeaderContent()); !strings.Contains(header, "refresh 2s") {
+		t.Errorf("expected refresh rate in header, got: %s", header)
+	}
+
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{','}})
+	newM, _ = newM.(Model).handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{','}})
+	if rate := newM.(Model).refreshRate; rate != 500*time.Millisecond {
+		t.Errorf("expected refresh 500ms, got %v", rate)
+	}
+}
+
+func TestWindowKeys_NarrowAndPrune(t *testing.T) {
+	s := store.New(10 * time.Minute)
+	s.Add(&parser.Entry{Timestamp: time.Now().Add(-7 * time.Minute), Status: 200, Host: "old.com"})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "new.com"})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+	if !strings.Contains(stripAnsi(m.renderHeaderContent()), "window 10m") {
+		t.Errorf("expected current window in header, got: %s", stripAnsi(m.renderHeaderContent()))
+	}
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}})
+	m = newM.(Model)
+	if s.Window() != 5*time.Minute {
+		t.Errorf("expected window 5m, got %v", s.Window())
+	}
+	if m.stats.TotalCount != 1 {
+		t.Errorf("expected the 7m-old entry pruned, got %d entries", m.stats.TotalCount)
+	}
+
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	m = newM.(Model)
+	if s.Window() != 10*time.Minute {
+		t.Errorf("expected window back to 10m, got %v", s.Window())
+	}
+}
+
+func TestBottomHostsKey_FlipsHostsTable(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "busy.com", "1.1.1.1"))
+	s.Add(testEntry(200, "busy.com", "1.1.1.1"))
+	s.Add(testEntry(200, "rare.com", "2.2.2.2"))
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+	if m.topHosts[0].Label != "busy.com" {
+		t.Fatalf("expected busy.com first, got %v", m.topHosts)
+	}
+
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	m = newM.(Model)
+	if m.topHosts[0].Label != "rare.com" {
+		t.Errorf("expected rare.com first, got %v", m.topHosts)
+	}
+	if view := stripAnsi(m.View()); !strings.Contains(view, "least frequent") {
+		t.Errorf("expected least frequent note in hosts title, got:\n%s", view)
+	}
+
+	newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	if hosts := newM.(Model).topHosts; hosts[0].Label != "busy.com" {
+		t.Errorf("expected busy.com first after toggling back, got %v", hosts)
+	}
+}
+
+func TestTopNKeys_AdjustRowsFetched(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 30; i++ {
+		s.Add(testEntry(200, fmt.Sprintf("host%02d.com", i), "1.1.1.1"))
+	}
+
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	if len(m.topHosts) != defaultTopN {
+		t.Fatalf("expected %d hosts by default, got %d", defaultTopN, len(m.topHosts))
+	}
+
+	key := func(r rune) {
+		newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newM.(Model)
+	}
+
+	key(']')
+	if m.topN != defaultTopN+topNStep || len(m.topHosts) != 25 {
+		t.Errorf("expected 25 hosts after ], got topN %d, %d hosts", m.topN, len(m.topHosts))
+	}
+
+	for i := 0; i < 30; i++ {
+		key(']')
+	}
+	if m.topN != maxTopN {
+		t.Errorf("expected topN capped at %d, got %d", maxTopN, m.topN)
+	}
+
+	for i := 0; i < 30; i++ {
+		key('[')
+	}
+	if m.topN != minTopN || len(m.topHosts) != 1 {
+		t.Errorf("expected topN clamped to %d, got %d (%d hosts)", minTopN, m.topN, len(m.topHosts))
+	}
+}
+
+func TestRenderTable_P95Column(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "slow.com", IP: "1.1.1.1", Service: 1234})
+	s.Add(&parser.Entry{Timestamp: time.Now(), Status: 101, Host: "ws.com", IP: "2.2.2.2", Service: 60000})
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	// The IPs table ends in p95; hosts add req/s after it
+	lines := strings.Split(stripAnsi(m.renderIPsContent(10, 56)), "\n")
+	if !strings.HasSuffix(strings.TrimSpace(lines[0]), "p95") {
+		t.Errorf("expected p95 column header, got %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if len([]rune(line)) != len([]rune(lines[0])) {
+			t.Errorf("expected row width to match header, got %q vs %q", line, lines[0])
+		}
+		switch {
+		case strings.Contains(line, "1.1.1.1") && !strings.HasSuffix(line, "1234ms"):
+			t.Errorf("expected slow.com's IP p95 1234ms, got %q", line)
+		case strings.Contains(line, "2.2.2.2") && !strings.HasSuffix(line, "-"):
+			t.Errorf("expected - for ws.com's IP with only 101s, got %q", line)
+		}
+	}
+}
+
+func TestRenderTable_HostReqRateColumn(t *testing.T) {
+	s := store.New(0)
+	now := time.Now()
+	// old.com was busy an hour ago; hot.com is busy now
+	for i := 0; i < 50; i++ {
+		s.Add(&parser.Entry{Timestamp: now.Add(-time.Hour), Status: 200, Host: "old.com"})
+	}
+	for i := 0; i < 20; i++ {
+		s.Add(&parser.Entry{Timestamp: now, Status: 200, Host: "hot.com"})
+	}
+
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+	m.refreshData()
+
+	lines := strings.Split(stripAnsi(m.renderHostsContent(10, 60)), "\n")
+	if !strings.HasSuffix(strings.TrimSpace(lines[0]), "req/s") {
+		t.Errorf("expected req/s column header, got %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if len([]rune(line)) != len([]rune(lines[0])) {
+			t.Errorf("expected row width to match header, got %q vs %q", line, lines[0])
+		}
+		switch {
+		case strings.Contains(line, "old.com") && !strings.HasSuffix(line, "0.0"):
+			t.Errorf("expected old.com idle now, got %q", line)
+		case strings.Contains(line, "hot.com") && !strings.HasSuffix(line, "2.0"):
+			t.Errorf("expected hot.com at 2.0 req/s, got %q", line)
+		}
+	}
+}
+
+func TestRenderTable_ScrollsToCursor(t *testing.T) {
+	s := store.New(0)
+	for i := range 12 {
+		// Distinct counts so the order is fixed: h00 busiest
+		for range 20 - i {
+			s.Add(testEntry(200, fmt.Sprintf("h%02d.com", i), "1.1.1.1"))
+		}
+	}
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	// Tall enough for 4 rows per table
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 28})
+	m = updated.(Model)
+	if got := m.tableRows(); got != 4 {
+		t.Fatalf("expected 4 table rows at 80x28, got %d", got)
+	}
+
+	rows := func() []string {
+		return strings.Split(stripAnsi(m.renderHostsContent(m.tableRows()+1, 60)), "\n")
+	}
+
+	// Header, 3 hosts, and the note
+	lines := rows()
+	if len(lines) != 5 || !strings.Contains(lines[1], "h00.com") || strings.TrimSpace(lines[4]) != "[9 more]" {
+		t.Fatalf("expected the top 3 hosts and [9 more], got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Moving past the last visible row scrolls by one
+	m.moveCursor(3)
+	lines = rows()
+	if !strings.Contains(lines[1], "h01.com") || !strings.HasPrefix(lines[3], "> h03.com") || strings.TrimSpace(lines[4]) != "[8 more]" {
+		t.Errorf("expected h01-h03 with h03 selected, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Moving back up within the window doesn't scroll
+	m.moveCursor(-1)
+	if lines = rows(); !strings.Contains(lines[1], "h01.com") || !strings.HasPrefix(lines[2], "> h02.com") {
+		t.Errorf("expected the window to stay put, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// The bottom of the list shows what's above instead. The offset is
+	// set by the move itself, before anything renders.
+	m.moveCursorToEnd()
+	if got := m.scrollOffsets[SectionHosts]; got != 9 {
+		t.Errorf("expected offset 9 after moving to the end, got %d", got)
+	}
+	if lines = rows(); !strings.HasPrefix(lines[3], "> h11.com") || strings.TrimSpace(lines[4]) != "[9 above]" {
+		t.Errorf("expected the last hosts and [9 above], got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestRequestCounts_WindowedVsLifetime(t *testing.T) {
+	s := store.New(5 * time.Minute)
+	s.Add(&parser.Entry{Timestamp: time.Now().Add(-time.Hour), Status: 200})
+	for range 3 {
+		s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	}
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	if got := m.requestCounts(); got != "4 total / 3 in 5m" {
+		t.Errorf("expected lifetime and windowed counts, got %q", got)
+	}
+
+	s = store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	m = NewModel(s, time.Second)
+	m.refreshData()
+	if got := m.requestCounts(); got != "1 reqs" {
+		t.Errorf("expected a single count without a window, got %q", got)
+	}
+}
+
+func TestIPsTitle_UniqueInWindow(t *testing.T) {
+	s := store.New(2 * time.Minute)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	s.Add(testEntry(200, "a.com", "2.2.2.2"))
+	m := NewModel(s, time.Second)
+	m.width = 120
+	m.height = 40
+
+	newM, _ := m.Update(TickMsg{})
+	m = newM.(Model)
+	if title := stripAnsi(m.renderIPsSectionBordered(60, 5, false)); !strings.Contains(title, "IPs (2 unique / 2m)") {
+		t.Errorf("expected unique IPs capped to the window, got:\n%s", title)
+	}
+}
+
+func TestHeader_LastEntryTime(t *testing.T) {
+	s := store.New(0)
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	if strings.Contains(m.renderHeaderContent(), "last ") {
+		t.Error("expected no last entry time without data")
+	}
+
+	logged := time.Date(2024, 1, 15, 10, 30, 5, 0, time.Local)
+	s.Add(&parser.Entry{Timestamp: logged, Status: 200, Host: "a.com"})
+	m.refreshData()
+	if header := stripAnsi(m.renderHeaderContent()); !strings.Contains(header, "| last 10:30:05") {
+		t.Errorf("expected last entry time in header, got:\n%s", header)
+	}
+}
+
+func TestStatusClassFilter(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "ok.com", "1.1.1.1"))
+	s.Add(testEntry(200, "ok.com", "1.1.1.1"))
+	s.Add(testEntry(500, "bad.com", "2.2.2.2"))
+	m := NewModel(s, time.Second)
+	m.refreshData()
+
+	key := func(r rune) {
+		newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newM.(Model)
+	}
+
+	key('5')
+	if m.filter.StatusClass != 5 {
+		t.Fatalf("expected 5xx filter, got %+v", m.filter)
+	}
+	if len(m.topHosts) != 1 || m.topHosts[0].Label != "bad.com" || m.stats.TotalCount != 1 {
+		t.Errorf("expected only bad.com's 5xx, got %v (%d reqs)", m.topHosts, m.stats.TotalCount)
+	}
+	if !strings.Contains(stripAnsi(m.renderHeaderContent()), "[5xx only]") {
+		t.Error("expected the class filter in the header")
+	}
+
+	// A host filter keeps the class
+	m.applyFilter()
+	if m.filter != (Filter{Host: "bad.com", StatusClass: 5}) {
+		t.Errorf("expected host and class filter, got %+v", m.filter)
+	}
+
+	// 4 switches class, pressing it again clears it
+	key('4')
+	if m.filter.StatusClass != 4 || len(m.topHosts) != 0 {
+		t.Errorf("expected an empty 4xx view, got %+v %v", m.filter, m.topHosts)
+	}
+	key('4')
+	if m.filter.StatusClass != 0 {
+		t.Errorf("expected class filter toggled off, got %+v", m.filter)
+	}
+
+	// Esc clears it like the other filters
+	key('5')
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newM.(Model)
+	if m.filter != (Filter{}) {
+		t.Errorf("expected Esc to clear the filter, got %+v", m.filter)
+	}
+}
+
+func TestSlowPathsModal(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 5; i++ {
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/reports", Service: 2500})
+		s.Add(&parser.Entry{Timestamp: time.Now(), Status: 200, Host: "a.com", Path: "/health", Service: 5})
+	}
+
+	m := NewModel(s, time.Second)
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	m = newM.(Model)
+
+	if !m.modal.Visible || m.modal.Title != "Slowest paths (p95)" {
+		t.Fatalf("expected slowest paths modal, got %+v", m.modal)
+	}
+	lines := strings.Split(m.modal.Content, "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "/reports") || !strings.Contains(lines[1], "2500ms") {
+		t.Errorf("expected /reports ranked first at 2500ms, got:\n%s", m.modal.Content)
+	}
+}
+
+func TestReverseDNS_ShowsPTRRecords(t *testing.T) {
+	orig := lookupAddr
+	t.Cleanup(func() { lookupAddr = orig })
+	lookupAddr = func(_ context.Context, addr string) ([]string, error) {
+		if addr == "1.1.1.1" {
+			return []string{"one.one.one.one."}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	m.section = SectionIPs
+
+	newM, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = newM.(Model)
+	if cmd == nil || !m.modal.Visible || !m.modal.Loading {
+		t.Fatalf("expected a loading modal and lookup command, got %+v", m.modal)
+	}
+
+	newM, _ = m.Update(cmd())
+	m = newM.(Model)
+	if m.modal.Loading || m.modal.Content != "one.one.one.one" {
+		t.Errorf("expected PTR record in modal, got %q", m.modal.Content)
+	}
+
+	msg := runReverseDNS("2.2.2.2")()
+	newM, _ = m.Update(msg)
+	m = newM.(Model)
+	if !strings.HasPrefix(m.modal.Content, "Error: ") {
+		t.Errorf("expected error in modal, got %q", m.modal.Content)
+	}
+}
+
+type stubLocator map[string]geoip.Location
+
+func (l stubLocator) Lookup(ip string) (geoip.Location, bool, error) {
+	loc, ok := l[ip]
+	return loc, ok, nil
+}
+
+func TestGeoIP_ShowsLocation(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	s.Add(testEntry(200, "a.com", "2.2.2.2"))
+
+	// Without a database the key does nothing
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	m.section = SectionIPs
+	if _, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}}); cmd != nil {
+		t.Fatal("expected no lookup without --geoip")
+	}
+
+	m = NewModel(s, time.Second, WithGeoIP(stubLocator{
+		"1.1.1.1": {Country: "Australia", CountryCode: "AU", City: "Sydney"},
+	}))
+	m.refreshData()
+	m.section = SectionIPs
+	for i, row := range m.topIPs {
+		if row.Label == "1.1.1.1" {
+			m.ipCursor = i
+		}
+	}
+
+	newM, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m = newM.(Model)
+	if cmd == nil || !m.modal.Visible {
+		t.Fatalf("expected a geoip modal and lookup command, got %+v", m.modal)
+	}
+	newM, _ = m.Update(cmd())
+	m = newM.(Model)
+	if want := "Country: Australia / AU\nCity:    Sydney"; m.modal.Content != want {
+		t.Errorf("expected %q, got %q", want, m.modal.Content)
+	}
+
+	newM, _ = m.Update(runGeoLookup(m.geo, "2.2.2.2")())
+	m = newM.(Model)
+	if m.modal.Content != "Not in the GeoIP database" {
+		t.Errorf("expected not-found message, got %q", m.modal.Content)
+	}
+}
+
+func TestOpenBrowser_IpinfoPage(t *testing.T) {
+	orig := startCommand
+	t.Cleanup(func() { startCommand = orig })
+	var started []string
+	startCommand = func(name string, args ...string) error {
+		started = append([]string{name}, args...)
+		return nil
+	}
+
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	m.section = SectionIPs
+
+	newM, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	m = newM.(Model)
+	if cmd == nil {
+		t.Fatal("expected a browser command")
+	}
+	newM, _ = m.Update(cmd())
+	m = newM.(Model)
+	if len(started) == 0 || started[len(started)-1] != "https://ipinfo.io/1.1.1.1" {
+		t.Errorf("expected the ipinfo page to be opened, got %v", started)
+	}
+	if m.notice != "opened https://ipinfo.io/1.1.1.1" {
+		t.Errorf("expected an opened notice, got %q", m.notice)
+	}
+
+	// A missing opener is reported, not fatal
+	startCommand = func(string, ...string) error { return exec.ErrNotFound }
+	newM, _ = m.Update(openBrowser("https://ipinfo.io/1.1.1.1")())
+	m = newM.(Model)
+	if !strings.HasPrefix(m.notice, "couldn't open browser: ") {
+		t.Errorf("expected an error notice, got %q", m.notice)
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	for goos, want := range map[string]string{"linux": "xdg-open", "darwin": "open", "windows": "rundll32"} {
+		if name, args := browserCommand(goos, "https://x"); name != want || args[len(args)-1] != "https://x" {
+			t.Errorf("browserCommand(%q) = %s %v", goos, name, args)
+		}
+	}
+}
+
+func TestLookupCache_SecondLookupIsImmediate(t *testing.T) {
+	s := store.New(0)
+	s.Add(testEntry(200, "a.com", "1.1.1.1"))
+	m := NewModel(s, time.Second)
+	m.refreshData()
+	m.section = SectionIPs
+
+	for _, tc := range []struct {
+		key    rune
+		result tea.Msg
+	}{
+		{'w', WhoisResultMsg{IP: "1.1.1.1", Content: "NetName: EXAMPLE"}},
+		{'i', IpinfoResultMsg{IP: "1.1.1.1", Content: "Org: Example"}},
+	} {
+		press := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{tc.key}}
+
+		newM, cmd := m.handleKey(press)
+		m = newM.(Model)
+		if cmd == nil {
+			t.Fatalf("%c: expected first lookup to issue a command", tc.key)
+		}
+		// Stand in for the command's result, then close the modal
+		newM, _ = m.Update(tc.result)
+		m = newM.(Model)
+		newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+		m = newM.(Model)
+
+		newM, cmd = m.handleKey(press)
+		m = newM.(Model)
+		if cmd != nil {
+			t.Errorf("%c: expected cached lookup without a command", tc.key)
+		}
+		if m.modal.Loading || !m.modal.Visible || m.modal.Content == "" {
+			t.Errorf("%c: expected cached content shown immediately, got %+v", tc.key, m.modal)
+		}
+		newM, _ = m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+		m = newM.(Model)
+	}
+
+	// Failures aren't cached
+	m.Update(WhoisResultMsg{IP: "2.2.2.2", Err: errors.New("timeout")})
+	if _, ok := m.whoisCache["2.2.2.2"]; ok {
+		t.Error("expected failed lookup not to be cached")
+	}
+}
+
+func TestRunIpinfo_Token(t *testing.T) {
+	var gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.URL.Query().Get("token")
+		fmt.Fprint(w, `{"ip":"1.1.1.1","org":"Example"}`)
+	}))
+	orig := ipinfoBaseURL
+	t.Cleanup(func() { ipinfoBaseURL = orig })
+	ipinfoBaseURL = srv.URL
+
+	msg := runIpinfo("1.1.1.1", "s3cret")().(IpinfoResultMsg)
+	if msg.Err != nil || gotToken != "s3cret" {
+		t.Errorf("expected token sent, got %q (err %v)", gotToken, msg.Err)
+	}
+
+	runIpinfo("1.1.1.1", "")()
+	if gotToken != "" {
+		t.Errorf("expected no token without one configured, got %q", gotToken)
+	}
+
+	// Errors quote the request URL; the token must not reach the modal
+	srv.Close()
+	msg = runIpinfo("1.1.1.1", "s3cret")().(IpinfoResultMsg)
+	if msg.Err == nil {
+		t.Fatal("expected error from closed server")
+	}
+	if strings.Contains(msg.Err.Error(), "s3cret") || !strings.Contains(msg.Err.Error(), "token=***") {
+		t.Errorf("expected token masked, got %q", msg.Err)
+	}
+}
+
+func TestStatusLatencyKey(t *testing.T) {
+	s := store.New(0)
+	s.Add(&parser.Entry{Status: 200, Service: 40, Host: "a.com"})
+	s.Add(&parser.Entry{Status: 503, Service: 30000, Host: "a.com"})
+
+	m := NewModel(s, time.Second)
+	newM, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = newM.(Model)
+	if !m.modal.Visible || m.modal.Title != "Latency by status" {
+		t.Fatalf("expected status latency modal, got %+v", m.modal)
+	}
+	lines := strings.Split(m.modal.Content, "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "200") || !strings.Contains(lines[2], "30s") {
+		t.Errorf("unexpected content:\n%s", m.modal.Content)
+	}
+}
+
+func TestErrRateCell_Thresholds(t *testing.T) {
+	prev := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(prev) })
+	ApplyColorMode(ColorAlways)
+
+	m := NewModel(store.New(0), time.Second, WithErrorRateThresholds(5, 20))
+	tests := []struct {
+		rate  float64
+		plain bool
+		want  string
+	}{
+		{0, false, "    -"},
+		{0.5, false, tableRowDimStyle.Render("  0.5")},
+		{7, false, status5xxStyle.Render("  7.0")},
+		{30, false, errorRateCritStyle.Render(" 30.0")},
+		{30, true, " 30.0"},
+	}
+	for _, tc := range tests {
+		if got := m.errRateCell(tc.rate, status5xxStyle, tc.plain); got != tc.want {
+			t.Errorf("errRateCell(%v, plain=%v) = %q, want %q", tc.rate, tc.plain, got, tc.want)
+		}
+	}
+
+	// Without thresholds every non-zero rate keeps its class color
+	m = NewModel(store.New(0), time.Second)
+	if got := m.errRateCell(0.5, status4xxStyle, false); got != status4xxStyle.Render("  0.5") {
+		t.Errorf("expected class color without thresholds, got %q", got)
+	}
+}
+
+func TestRenderTableContent_AlertRows(t *testing.T) {
+	prev := lipgloss.ColorProfile()
+	t.Cleanup(func() { lipgloss.SetColorProfile(prev) })
+	ApplyColorMode(ColorAlways)
+
+	m := NewModel(store.New(0), time.Second, WithErrorRateThresholds(0, 20))
+	items := []store.CountItem{{Label: "bad.com", Count: 10}, {Label: "ok.com", Count: 5}}
+	rates := map[string]store.ErrorRates{"bad.com": {Rate5xx: 40}, "ok.com": {Rate5xx: 2}}
+
+	lines := strings.Split(m.renderTableContent(SectionHosts, items, 1, true, false, rates, nil, nil, nil, 10, 80), "\n")
+	var bad, ok string
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "bad.com"):
+			bad = line
+		case strings.Contains(line, "ok.com"):
+			ok = line
+		}
+	}
+	// The whole row is one alert span, with no nested cell styling
+	if bad == "" || bad != tableRowAlertStyle.Render(stripAnsi(bad)) {
+		t.Errorf("expected bad.com as a single alert-styled row, got %q", bad)
+	}
+	// Selection wins over the alert style
+	if !strings.Contains(stripAnsi(ok), "> ok.com") {
+		t.Errorf("expected ok.com selected, got %q", ok)
+	}
+
+	lines = strings.Split(m.renderTableContent(SectionHosts, items, 0, true, false, rates, nil, nil, nil, 10, 80), "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "bad.com") && !strings.Contains(stripAnsi(line), "> bad.com") {
+			t.Errorf("expected selected style to take precedence, got %q", line)
+		}
+	}
+}
@@ -1,14 +1,21 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/betternow/hstat/store"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -16,11 +23,18 @@ import (
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		prev := m.viewState()
+		newM, cmd := m.handleKey(msg)
+		if next := newM.(Model); next.viewStateFile != "" && next.viewState() != prev {
+			// Best effort: a failed save shouldn't interrupt monitoring
+			_ = SaveViewState(next.viewStateFile, next.viewState())
+		}
+		return newM, cmd
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.scrollToCursors()
 		return m, nil
 
 	case EntryMsg:
@@ -28,13 +42,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.lastEntryTime = time.Now()
 		return m, nil
 
+	case EntriesMsg:
+		m.store.AddBatch(msg)
+		m.lastEntryTime = time.Now()
+		return m, nil
+
 	case TickMsg:
-		m.refreshData()
+		// Paused, the snapshot holds still; entries still reach the store
+		if !m.paused {
+			m.refreshData()
+			m.refreshRecentIPs()
+		}
 		return m, tickCmd(m.refreshRate)
 
 	case StreamEndedMsg:
 		m.streamEnded = true
-		m.refreshData()
+		if !m.paused {
+			m.refreshData()
+			m.refreshRecentIPs()
+		}
 		return m, nil
 
 	case WhoisResultMsg:
@@ -43,7 +69,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.modal.Content = fmt.Sprintf("Error: %v", msg.Err)
 		} else {
 			m.modal.Content = msg.Content
+			m.whoisCache[msg.IP] = msg.Content
+		}
+		return m, nil
+
+	case GeoResultMsg:
+		m.modal.Loading = false
+		if msg.Err != nil {
+			m.modal.Content = fmt.Sprintf("Error: %v", msg.Err)
+		} else {
+			m.modal.Content = msg.Content
+		}
+		return m, nil
+
+	case ReverseDNSResultMsg:
+		m.modal.Loading = false
+		if msg.Err != nil {
+			m.modal.Content = fmt.Sprintf("Error: %v", msg.Err)
+		} else {
+			m.modal.Content = msg.Content
+		}
+		return m, nil
+
+	case BrowserResultMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("couldn't open browser: %v", msg.Err)
+		} else {
+			m.notice = "opened " + msg.URL
+		}
+		m.noticeUntil = time.Now().Add(noticeDuration)
+		return m, nil
+
+	case SnapshotResultMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("snapshot failed: %v", msg.Err)
+		} else {
+			m.notice = "snapshot saved: " + msg.Path
+		}
+		m.noticeUntil = time.Now().Add(noticeDuration)
+		return m, nil
+
+	case ExportResultMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("export failed: %v", msg.Err)
+		} else {
+			m.notice = "CSV saved: " + msg.Path
 		}
+		m.noticeUntil = time.Now().Add(noticeDuration)
 		return m, nil
 
 	case IpinfoResultMsg:
@@ -52,6 +124,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.modal.Content = fmt.Sprintf("Error: %v", msg.Err)
 		} else {
 			m.modal.Content = msg.Content
+			m.ipinfoCache[msg.IP] = msg.Content
 		}
 		return m, nil
 	}
@@ -71,36 +144,58 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.searchMode {
+		return m.handleSearchKey(msg)
+	}
+
+	action := m.keys.Action(msg.String())
+	if action != ActionQuit && action != ActionClearFilter {
+		m.quitArmedUntil = time.Time{}
+	}
+
+	switch action {
 	// Help as modal
-	if msg.String() == "?" {
+	case ActionHelp:
 		m.modal.Visible = true
 		m.modal.Title = "hstat - Heroku Router Log Monitor"
 		m.modal.Content = helpContent()
 		m.modal.Loading = false
 		return m, nil
-	}
 
-	switch msg.String() {
 	// Quit
-	case "q", "ctrl+c":
-		return m, tea.Quit
+	case ActionQuit:
+		return m.requestQuit(msg.String())
 
-	// Clear filter or quit
-	case "esc":
-		if m.filter.Host != "" || m.filter.IP != "" {
+	// Leave focus mode, clear filter, or quit
+	case ActionClearFilter:
+		if m.focus {
+			m.focus = false
+			return m, nil
+		}
+		if m.searchQuery != "" {
+			m.searchQuery = ""
+			m.refreshData()
+			return m, nil
+		}
+		if m.filter != (Filter{}) {
 			m.filter = Filter{}
 			m.refreshData()
 			return m, nil
 		}
-		return m, tea.Quit
+		return m.requestQuit(msg.String())
 
 	// Whois lookup
-	case "w":
+	case ActionWhois:
 		if m.section == SectionIPs && m.ipCursor < len(m.topIPs) {
 			ip := m.topIPs[m.ipCursor].Label
 			if ip != "" && ip != "(unknown)" {
 				m.modal.Visible = true
 				m.modal.Title = fmt.Sprintf("whois %s", ip)
+				if content, ok := m.whoisCache[ip]; ok {
+					m.modal.Loading = false
+					m.modal.Content = content
+					return m, nil
+				}
 				m.modal.Loading = true
 				m.modal.Content = "Loading..."
 				return m, runWhois(ip)
@@ -109,59 +204,281 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	// IP info lookup (via ipinfo.io API)
-	case "i":
+	case ActionIpinfo:
 		if m.section == SectionIPs && m.ipCursor < len(m.topIPs) {
 			ip := m.topIPs[m.ipCursor].Label
 			if ip != "" && ip != "(unknown)" {
 				m.modal.Visible = true
 				m.modal.Title = fmt.Sprintf("ipinfo %s", ip)
+				if content, ok := m.ipinfoCache[ip]; ok {
+					m.modal.Loading = false
+					m.modal.Content = content
+					return m, nil
+				}
 				m.modal.Loading = true
 				m.modal.Content = "Loading..."
-				return m, runIpinfo(ip)
+				return m, runIpinfo(ip, m.ipinfoToken)
 			}
 		}
 		return m, nil
 
-	// Section navigation
-	case "tab", "l":
-		m.section = (m.section + 1) % 2
+	// Reverse DNS (PTR) lookup
+	case ActionReverseDNS:
+		if m.section == SectionIPs && m.ipCursor < len(m.topIPs) {
+			ip := m.topIPs[m.ipCursor].Label
+			if ip != "" && ip != "(unknown)" {
+				m.modal.Visible = true
+				m.modal.Title = fmt.Sprintf("reverse DNS %s", ip)
+				m.modal.Loading = true
+				m.modal.Content = "Loading..."
+				return m, runReverseDNS(ip)
+			}
+		}
 		return m, nil
 
-	case "shift+tab", "h":
-		if m.section == 0 {
-			m.section = 1
-		} else {
-			m.section--
+	// Full ipinfo.io page in the default browser
+	case ActionOpenBrowser:
+		if m.section == SectionIPs && m.ipCursor < len(m.topIPs) {
+			ip := m.topIPs[m.ipCursor].Label
+			if ip != "" && ip != "(unknown)" {
+				return m, openBrowser(ipinfoBaseURL + "/" + url.PathEscape(ip))
+			}
+		}
+		return m, nil
+
+	// Offline location from the GeoIP database, if one is configured
+	case ActionGeoIP:
+		if m.geo != nil && m.section == SectionIPs && m.ipCursor < len(m.topIPs) {
+			ip := m.topIPs[m.ipCursor].Label
+			if ip != "" && ip != "(unknown)" {
+				m.modal.Visible = true
+				m.modal.Title = fmt.Sprintf("geoip %s", ip)
+				m.modal.Loading = true
+				m.modal.Content = "Loading..."
+				return m, runGeoLookup(m.geo, ip)
+			}
+		}
+		return m, nil
+
+	// Host detail
+	case ActionHostDetail:
+		if m.section == SectionHosts && m.hostCursor < len(m.topHosts) {
+			host := m.topHosts[m.hostCursor].Label
+			m.modal.Visible = true
+			m.modal.Title = fmt.Sprintf("host %s", host)
+			m.modal.Loading = false
+			m.modal.Content = m.hostDetailContent(host)
 		}
 		return m, nil
 
+	// Heroku error code breakdown
+	case ActionErrorCodes:
+		m.modal.Visible = true
+		m.modal.Title = "Heroku error codes"
+		m.modal.Loading = false
+		m.modal.Content = m.errorCodesContent()
+		return m, nil
+
+	// Slowest paths by p95
+	case ActionSlowPaths:
+		m.modal.Visible = true
+		m.modal.Title = "Slowest paths (p95)"
+		m.modal.Loading = false
+		m.modal.Content = m.slowPathsContent()
+		return m, nil
+
+	// Show only 4xx or 5xx traffic (toggle)
+	case ActionFilter4xx, ActionFilter5xx:
+		class := 4
+		if action == ActionFilter5xx {
+			class = 5
+		}
+		if m.filter.StatusClass == class {
+			class = 0
+		}
+		m.filter.StatusClass = class
+		m.filter.Status = 0
+		m.pushFilterHistory()
+		m.refreshData()
+		return m, nil
+
+	// Response time distribution
+	case ActionHistogram:
+		m.modal.Visible = true
+		m.modal.Title = "Response time distribution"
+		m.modal.Loading = false
+		m.modal.Content = histogramContent(m.store.GetServiceHistogram(serviceHistogramBounds))
+		return m, nil
+
+	// Latency per status code: fast failures vs slow timeouts
+	case ActionStatusLatency:
+		m.modal.Visible = true
+		m.modal.Title = "Latency by status"
+		m.modal.Loading = false
+		m.modal.Content = m.statusLatencyContent()
+		return m, nil
+
+	// Focus mode: inspect the selected host without filtering
+	case ActionFocus:
+		m.focus = !m.focus && m.section == SectionHosts
+		m.refreshFocus()
+		return m, nil
+
+	// Section navigation
+	case ActionNextSection:
+		m.section = (m.section + 1) % m.navigableSections()
+		return m, nil
+
+	case ActionPrevSection:
+		n := m.navigableSections()
+		m.section = (m.section + n - 1) % n
+		return m, nil
+
 	// Cursor movement
-	case "j", "down":
+	case ActionDown:
 		m.moveCursor(1)
 		return m, nil
 
-	case "k", "up":
+	case ActionUp:
 		m.moveCursor(-1)
 		return m, nil
 
-	case "g":
+	case ActionTop:
 		m.moveCursorTo(0)
 		return m, nil
 
-	case "G":
+	case ActionBottom:
 		m.moveCursorToEnd()
 		return m, nil
 
 	// Filter
-	case "enter":
+	case ActionFilter:
 		m.applyFilter()
 		return m, nil
+
+	// Cycle the active table's sort: count, 4xx rate, 5xx rate
+	case ActionSort:
+		if m.section == SectionStatus {
+			return m, nil
+		}
+		m.sortModes[m.section] = nextSortMode(m.section, m.sortModes[m.section])
+		m.refreshData()
+		return m, nil
+
+	// Flip the hosts table between most and least frequent
+	case ActionBottomHosts:
+		m.bottomHosts = !m.bottomHosts
+		m.refreshData()
+		return m, nil
+
+	// Filter history
+	case ActionFilterBack:
+		m.stepFilterHistory(-1)
+		return m, nil
+
+	case ActionFilterFwd:
+		m.stepFilterHistory(1)
+		return m, nil
+
+	// Freeze the display; the next tick after unpausing catches up
+	case ActionPause:
+		m.paused = !m.paused
+		return m, nil
+
+	// Save the screen as plain text for incident notes
+	case ActionSnapshot:
+		return m, writeSnapshot(stripAnsi(m.View()), time.Now())
+
+	// Export hosts, IPs, and paths for a spreadsheet
+	case ActionExportCSV:
+		return m, writeCSV(m.store, m.csvPath, time.Now())
+
+	// Widen or narrow the data window, then re-prune
+	case ActionWiderWindow, ActionNarrowerWindow:
+		dir := 1
+		if action == ActionNarrowerWindow {
+			dir = -1
+		}
+		m.store.SetWindow(stepWindow(m.store.Window(), dir))
+		m.refreshData()
+		return m, nil
+
+	// Redraw more or less often; the next tick picks up the new rate
+	case ActionFasterRefresh, ActionSlowerRefresh:
+		dir := 1
+		if action == ActionFasterRefresh {
+			dir = -1
+		}
+		m.refreshRate = stepRefresh(m.refreshRate, dir)
+		m.notice = fmt.Sprintf("refresh every %s", m.refreshRate)
+		m.noticeUntil = time.Now().Add(noticeDuration)
+		return m, nil
+
+	// Fetch more or fewer rows per table
+	case ActionMoreRows, ActionFewerRows:
+		step := topNStep
+		if action == ActionFewerRows {
+			step = -topNStep
+		}
+		m.topN = min(max(m.topN+step, minTopN), maxTopN)
+		m.notice = fmt.Sprintf("showing top %d", m.topN)
+		m.noticeUntil = time.Now().Add(noticeDuration)
+		m.refreshData()
+		return m, nil
+
+	// Start a live search of the active section
+	case ActionSearch:
+		if m.section == SectionStatus {
+			return m, nil
+		}
+		m.searchMode = true
+		m.searchSection = m.section
+		m.searchQuery = ""
+		m.refreshData()
+		return m, nil
 	}
 
 	return m, nil
 }
 
+// handleSearchKey edits the search query as it is typed. Enter keeps the
+// query and returns the keys to normal use; Esc drops it.
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m.requestQuit(msg.String())
+	}
+	m.quitArmedUntil = time.Time{}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searchMode = false
+		m.searchQuery = ""
+	case tea.KeyEnter:
+		m.searchMode = false
+		return m, nil
+	case tea.KeyBackspace:
+		if query := []rune(m.searchQuery); len(query) > 0 {
+			m.searchQuery = string(query[:len(query)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.searchQuery += string(msg.Runes)
+	case tea.KeyDown:
+		m.moveCursor(1)
+		return m, nil
+	case tea.KeyUp:
+		m.moveCursor(-1)
+		return m, nil
+	default:
+		return m, nil
+	}
+	m.refreshData()
+	return m, nil
+}
+
 func (m *Model) moveCursor(delta int) {
+	defer m.refreshFocus()
+	defer m.scrollToCursors()
+	m.clearLostSelection()
 	switch m.section {
 	case SectionHosts:
 		m.hostCursor += delta
@@ -179,39 +496,461 @@ func (m *Model) moveCursor(delta int) {
 		if m.ipCursor >= len(m.topIPs) {
 			m.ipCursor = max(0, len(m.topIPs)-1)
 		}
+	case SectionPaths:
+		m.pathCursor += delta
+		if m.pathCursor < 0 {
+			m.pathCursor = 0
+		}
+		if m.pathCursor >= len(m.topPaths) {
+			m.pathCursor = max(0, len(m.topPaths)-1)
+		}
+	case SectionStatus:
+		m.statusCursor = min(max(m.statusCursor+delta, 0), max(0, len(m.statusCodeList())-1))
 	}
 }
 
+// scrollToCursors scrolls the hosts, IPs, and paths lists just enough to
+// keep their cursors in view
+func (m *Model) scrollToCursors() {
+	rows := m.tableRows()
+	if rows <= 0 {
+		return
+	}
+	m.scrollOffsets[SectionHosts] = scrollOffset(m.scrollOffsets[SectionHosts], m.hostCursor, len(m.topHosts), rows)
+	m.scrollOffsets[SectionIPs] = scrollOffset(m.scrollOffsets[SectionIPs], m.ipCursor, len(m.topIPs), rows)
+	m.scrollOffsets[SectionPaths] = scrollOffset(m.scrollOffsets[SectionPaths], m.pathCursor, len(m.topPaths), rows)
+}
+
 func (m *Model) moveCursorTo(pos int) {
+	defer m.refreshFocus()
+	defer m.scrollToCursors()
+	m.clearLostSelection()
 	switch m.section {
 	case SectionHosts:
 		m.hostCursor = pos
 	case SectionIPs:
 		m.ipCursor = pos
+	case SectionPaths:
+		m.pathCursor = pos
+	case SectionStatus:
+		m.statusCursor = pos
 	}
 }
 
 func (m *Model) moveCursorToEnd() {
+	defer m.refreshFocus()
+	defer m.scrollToCursors()
+	m.clearLostSelection()
 	switch m.section {
 	case SectionHosts:
 		m.hostCursor = max(0, len(m.topHosts)-1)
 	case SectionIPs:
 		m.ipCursor = max(0, len(m.topIPs)-1)
+	case SectionPaths:
+		m.pathCursor = max(0, len(m.topPaths)-1)
+	case SectionStatus:
+		m.statusCursor = max(0, len(m.statusCodeList())-1)
 	}
 }
 
+// clearLostSelection drops the "selection lost" note for the active section
+func (m *Model) clearLostSelection() {
+	switch m.section {
+	case SectionHosts:
+		m.lostHost = ""
+	case SectionIPs:
+		m.lostIP = ""
+	case SectionPaths:
+		m.lostPath = ""
+	}
+}
+
+// quitConfirmWindow is how long a first quit press waits for the second
+// with confirmQuit
+const quitConfirmWindow = 2 * time.Second
+
+// requestQuit quits, or with confirmQuit arms quitting and asks for key
+// to be pressed again within quitConfirmWindow
+func (m Model) requestQuit(key string) (tea.Model, tea.Cmd) {
+	now := time.Now()
+	if !m.confirmQuit || now.Before(m.quitArmedUntil) {
+		return m, tea.Quit
+	}
+	m.quitArmedUntil = now.Add(quitConfirmWindow)
+	m.notice = fmt.Sprintf("Press %s again to quit", key)
+	m.noticeUntil = m.quitArmedUntil
+	return m, nil
+}
+
 func (m *Model) applyFilter() {
 	switch m.section {
 	case SectionHosts:
 		if m.hostCursor < len(m.topHosts) {
-			m.filter = Filter{Host: m.topHosts[m.hostCursor].Label}
+			m.filter = Filter{Host: m.topHosts[m.hostCursor].Label, StatusClass: m.filter.StatusClass, Status: m.filter.Status}
+			m.pushFilterHistory()
 			m.refreshData()
 		}
 	case SectionIPs:
 		if m.ipCursor < len(m.topIPs) {
-			m.filter = Filter{IP: m.topIPs[m.ipCursor].Label}
+			m.filter = Filter{IP: m.topIPs[m.ipCursor].Label, StatusClass: m.filter.StatusClass, Status: m.filter.Status}
+			m.pushFilterHistory()
 			m.refreshData()
 		}
+	case SectionPaths:
+		if m.pathCursor < len(m.topPaths) {
+			m.filter = Filter{Path: m.topPaths[m.pathCursor].Label, StatusClass: m.filter.StatusClass, Status: m.filter.Status}
+			m.pushFilterHistory()
+			m.refreshData()
+		}
+	case SectionStatus:
+		// An exact code supersedes a class and keeps any host/IP/path
+		if status := m.selectedStatus(); status != 0 {
+			m.filter.Status = status
+			m.filter.StatusClass = 0
+			m.pushFilterHistory()
+			m.refreshData()
+		}
+	}
+}
+
+// filterHistoryMax bounds how many applied filters are remembered
+const filterHistoryMax = 20
+
+// pushFilterHistory records the current filter. Like browser history,
+// applying a filter after stepping back drops the forward entries.
+func (m *Model) pushFilterHistory() {
+	if len(m.filterHistory) > 0 {
+		m.filterHistory = m.filterHistory[:m.historyPos+1]
+		if m.filterHistory[m.historyPos] == m.filter {
+			return
+		}
+	}
+	m.filterHistory = append(m.filterHistory, m.filter)
+	if len(m.filterHistory) > filterHistoryMax {
+		m.filterHistory = m.filterHistory[1:]
+	}
+	m.historyPos = len(m.filterHistory) - 1
+}
+
+// stepFilterHistory moves back (-1) or forward (+1) through applied
+// filters. Stepping back after the filter was cleared restores the
+// filter that was last in effect.
+func (m *Model) stepFilterHistory(delta int) {
+	if len(m.filterHistory) == 0 {
+		return
+	}
+	pos := m.historyPos
+	if m.filter == m.filterHistory[pos] || delta > 0 {
+		pos += delta
+	}
+	if pos < 0 || pos >= len(m.filterHistory) {
+		return
+	}
+	m.historyPos = pos
+	m.filter = m.filterHistory[pos]
+	m.refreshData()
+}
+
+// hostDetailTopN is how many paths and IPs the host detail modal lists
+const hostDetailTopN = 5
+
+// hostDetailContent formats request, latency, error, and response size
+// stats for a host, plus its top paths and client IPs
+func (m Model) hostDetailContent(host string) string {
+	stats := m.store.GetStatsForHost(host)
+	rates := m.store.GetErrorRatesForHost(host)
+	split := m.store.GetBytesSplitForHost(host)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Requests: %s\n", formatNumber(split.OKCount+split.ErrCount)))
+	b.WriteString(fmt.Sprintf("Latency:  p50 %dms | p95 %dms | p99 %dms\n", stats.P50Service, stats.P95Service, stats.P99Service))
+	b.WriteString(fmt.Sprintf("Errors:   4xx %.1f%% | 5xx %.1f%%\n", rates.Rate4xx, rates.Rate5xx))
+	if methods := m.store.GetMethodCounts(host, ""); len(methods) > 0 {
+		b.WriteString(fmt.Sprintf("Methods:  %s\n", methodSplit(methods)))
+	}
+	b.WriteString("\nAvg response size:\n")
+	b.WriteString(fmt.Sprintf("  ok      %8s  (%s reqs)\n", formatBytes(split.AvgOK), formatNumber(split.OKCount)))
+	if split.ErrCount > 0 {
+		b.WriteString(fmt.Sprintf("  errors  %8s  (%s reqs)\n", formatBytes(split.AvgErr), formatNumber(split.ErrCount)))
+	} else {
+		b.WriteString("  errors         -\n")
+	}
+
+	writeDetailList(&b, "Top paths", m.store.GetTopPaths(hostDetailTopN, host, ""))
+	writeDetailList(&b, "Top IPs", m.store.GetTopIPs(hostDetailTopN, host))
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// methodSplit formats method counts as shares of their total, e.g.
+// "GET 80% | POST 20%"
+func methodSplit(methods []store.CountItem) string {
+	var total int64
+	for _, mc := range methods {
+		total += mc.Count
+	}
+	if total == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(methods))
+	for _, mc := range methods {
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", mc.Label, float64(mc.Count)*100/float64(total)))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// writeDetailList writes a titled list of labels with counts for a
+// detail modal
+func writeDetailList(b *strings.Builder, title string, items []store.CountItem) {
+	b.WriteString(fmt.Sprintf("\n%s:\n", title))
+	if len(items) == 0 {
+		b.WriteString("  (none)\n")
+		return
+	}
+	for _, item := range items {
+		b.WriteString(fmt.Sprintf("  %8s  %s\n", formatNumber(item.Count), truncateEnd(item.Label, slowPathsLabelLen)))
+	}
+}
+
+// errorCodesModalN is how many error codes the error codes modal lists
+const errorCodesModalN = 20
+
+// errorCodesContent lists the top Heroku error codes with their
+// descriptions and counts
+func (m Model) errorCodesContent() string {
+	codes := m.store.GetTopErrorCodes(errorCodesModalN)
+	if len(codes) == 0 {
+		return "No router errors in the window"
+	}
+
+	var b strings.Builder
+	for i, c := range codes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%-5s %8s  %s", c.Label, formatNumber(c.Count), m.store.ErrorCodeDesc(c.Label)))
+	}
+	return b.String()
+}
+
+// slowPathsModalN is how many paths the slowest paths modal lists, and
+// slowPathsLabelLen how wide their labels may be
+const (
+	slowPathsModalN   = 20
+	slowPathsLabelLen = 50
+)
+
+func (m Model) slowPathsContent() string {
+	paths := m.store.GetSlowestPaths(slowPathsModalN)
+	if len(paths) == 0 {
+		return "No path has enough timed requests yet"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s %6s %8s", slowPathsLabelLen, "Path", "p95", "Requests"))
+	for _, p := range paths {
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("%-*s %6s %8s", slowPathsLabelLen,
+			truncateLabel(p.Label, slowPathsLabelLen, m.pathTruncate), formatLatency(p.P95), formatNumber(p.Count)))
+	}
+	return b.String()
+}
+
+// statusLatencyContent lists response time percentiles for each status
+// code in the window, ignoring any filter
+func (m Model) statusLatencyContent() string {
+	statuses := m.store.GetStatusCounts("", "")
+	if len(statuses) == 0 {
+		return "No requests yet"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-6s %8s %8s %8s %8s %8s", "Status", "Requests", "p50", "p95", "p99", "max"))
+	for _, sc := range statuses {
+		stats := m.store.GetStatsForStatus(sc.Status)
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("%-6d %8s %8s %8s %8s %8s", sc.Status, formatNumber(stats.TotalCount),
+			formatLatency(stats.P50Service), formatLatency(stats.P95Service), formatLatency(stats.P99Service), formatLatency(stats.MaxService)))
+	}
+	return b.String()
+}
+
+// windowSteps are the windows +/- move between. Stepping up past the
+// last one means no windowing ("all"); stepping down from "all" lands on
+// the last one.
+var windowSteps = []time.Duration{
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute,
+	15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour,
+	6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// stepWindow returns the next wider (dir > 0) or narrower window. A
+// window between steps moves to the neighbouring step.
+func stepWindow(window time.Duration, dir int) time.Duration {
+	if dir > 0 {
+		if window == 0 {
+			return 0
+		}
+		for _, w := range windowSteps {
+			if w > window {
+				return w
+			}
+		}
+		return 0
+	}
+
+	if window == 0 {
+		return windowSteps[len(windowSteps)-1]
+	}
+	for i := len(windowSteps) - 1; i >= 0; i-- {
+		if windowSteps[i] < window {
+			return windowSteps[i]
+		}
+	}
+	return window // already at or below the smallest step
+}
+
+// refreshSteps are the refresh intervals ,/. move between, bounded so
+// redraws neither swamp a slow link nor go stale
+var refreshSteps = []time.Duration{
+	200 * time.Millisecond, 500 * time.Millisecond, time.Second,
+	2 * time.Second, 3 * time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// stepRefresh returns the next slower (dir > 0) or faster refresh
+// interval. A rate between steps, or outside them, moves to the
+// neighbouring step.
+func stepRefresh(rate time.Duration, dir int) time.Duration {
+	if dir > 0 {
+		for _, r := range refreshSteps {
+			if r > rate {
+				return r
+			}
+		}
+		return refreshSteps[len(refreshSteps)-1]
+	}
+	for i := len(refreshSteps) - 1; i >= 0; i-- {
+		if refreshSteps[i] < rate {
+			return refreshSteps[i]
+		}
+	}
+	return refreshSteps[0]
+}
+
+// noticeDuration is how long a header notice stays up
+const noticeDuration = 3 * time.Second
+
+// writeSnapshot saves a rendered view, headed by a timestamp, to
+// hstat-snapshot-<unixtime>.txt in the working directory
+func writeSnapshot(view string, at time.Time) tea.Cmd {
+	return func() tea.Msg {
+		path := fmt.Sprintf("hstat-snapshot-%d.txt", at.Unix())
+		content := fmt.Sprintf("hstat snapshot %s\n\n%s\n", at.Format(time.RFC3339), view)
+		err := os.WriteFile(path, []byte(content), 0o644)
+		return SnapshotResultMsg{Path: path, Err: err}
+	}
+}
+
+// writeCSV exports the store's aggregates to path, or to
+// hstat-export-<unixtime>.csv in the working directory if path is empty
+func writeCSV(s *store.Store, path string, at time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if path == "" {
+			path = fmt.Sprintf("hstat-export-%d.csv", at.Unix())
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return ExportResultMsg{Path: path, Err: err}
+		}
+		err = s.ExportCSV(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return ExportResultMsg{Path: path, Err: err}
+	}
+}
+
+// runGeoLookup resolves ip against the local GeoIP database
+func runGeoLookup(geo GeoLocator, ip string) tea.Cmd {
+	return func() tea.Msg {
+		loc, found, err := geo.Lookup(ip)
+		if err != nil {
+			return GeoResultMsg{IP: ip, Err: err}
+		}
+		if !found {
+			return GeoResultMsg{IP: ip, Content: "Not in the GeoIP database"}
+		}
+
+		var b strings.Builder
+		if loc.Country != "" || loc.CountryCode != "" {
+			b.WriteString(fmt.Sprintf("Country: %s\n", strings.Join(nonEmpty(loc.Country, loc.CountryCode), " / ")))
+		}
+		if loc.City != "" {
+			b.WriteString(fmt.Sprintf("City:    %s\n", loc.City))
+		}
+		if b.Len() == 0 {
+			return GeoResultMsg{IP: ip, Content: "No location data"}
+		}
+		return GeoResultMsg{IP: ip, Content: strings.TrimSpace(b.String())}
+	}
+}
+
+// reverseDNSTimeout bounds a PTR lookup, so a dead resolver can't leave
+// the modal loading forever
+const reverseDNSTimeout = 5 * time.Second
+
+// lookupAddr resolves PTR records; a variable so tests can stub it
+var lookupAddr = net.DefaultResolver.LookupAddr
+
+// runReverseDNS looks up the PTR records for ip
+func runReverseDNS(ip string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), reverseDNSTimeout)
+		defer cancel()
+
+		names, err := lookupAddr(ctx, ip)
+		if err != nil {
+			return ReverseDNSResultMsg{IP: ip, Err: err}
+		}
+		if len(names) == 0 {
+			return ReverseDNSResultMsg{IP: ip, Content: "No PTR records"}
+		}
+		for i, name := range names {
+			names[i] = strings.TrimSuffix(name, ".")
+		}
+		return ReverseDNSResultMsg{IP: ip, Content: strings.Join(names, "\n")}
+	}
+}
+
+// startCommand launches a program without waiting for it to finish,
+// reaping it in the background; a var so tests can stub it
+var startCommand = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// browserCommand returns the command that opens a URL in the default
+// browser on goos
+func browserCommand(goos, target string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{target}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", target}
+	default:
+		return "xdg-open", []string{target}
+	}
+}
+
+// openBrowser opens target in the default browser. A missing opener
+// (e.g. no xdg-open on a server) comes back as an error, not a crash.
+func openBrowser(target string) tea.Cmd {
+	return func() tea.Msg {
+		name, args := browserCommand(runtime.GOOS, target)
+		return BrowserResultMsg{URL: target, Err: startCommand(name, args...)}
 	}
 }
 
@@ -251,16 +990,31 @@ type IpinfoResponse struct {
 	Timezone string `json:"timezone"`
 }
 
-// runIpinfo queries ipinfo.io API and returns result
-func runIpinfo(ip string) tea.Cmd {
+// ipinfoBaseURL is the ipinfo.io API root; a variable so tests can point
+// it at a local server
+var ipinfoBaseURL = "https://ipinfo.io"
+
+// runIpinfo queries ipinfo.io API and returns result. The token, if any,
+// is masked in errors since they end up on screen.
+func runIpinfo(ip, token string) tea.Cmd {
 	return func() tea.Msg {
+		reqURL := fmt.Sprintf("%s/%s/json", ipinfoBaseURL, ip)
+		if token != "" {
+			reqURL += "?token=" + url.QueryEscape(token)
+		}
+
 		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Get(fmt.Sprintf("https://ipinfo.io/%s/json", ip))
+		resp, err := client.Get(reqURL)
 		if err != nil {
-			return IpinfoResultMsg{IP: ip, Err: err}
+			return IpinfoResultMsg{IP: ip, Err: maskToken(err, token)}
 		}
 		defer resp.Body.Close()
 
+		// Throttling (429) and bad tokens (403) don't return the usual JSON
+		if resp.StatusCode != http.StatusOK {
+			return IpinfoResultMsg{IP: ip, Err: fmt.Errorf("ipinfo.io returned %s", resp.Status)}
+		}
+
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return IpinfoResultMsg{IP: ip, Err: err}
@@ -298,6 +1052,15 @@ func runIpinfo(ip string) tea.Cmd {
 	}
 }
 
+// maskToken replaces token in err's message with "***"
+func maskToken(err error, token string) error {
+	if token == "" {
+		return err
+	}
+	msg := strings.ReplaceAll(err.Error(), url.QueryEscape(token), "***")
+	return errors.New(strings.ReplaceAll(msg, token, "***"))
+}
+
 // nonEmpty filters out empty strings
 func nonEmpty(strs ...string) []string {
 	var result []string
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -20,7 +21,7 @@ func (m Model) View() string {
 	}
 
 	// Calculate layout based on terminal dimensions
-	layout := CalculateLayoutWithActiveSection(m.width, m.height, m.section)
+	layout := m.layout()
 	if layout == nil {
 		return "Terminal too small"
 	}
@@ -28,27 +29,99 @@ func (m Model) View() string {
 	var sections []string
 
 	// Header section with border
-	headerContent := m.renderHeaderContent()
-	headerSection := m.renderBorderedSection("hstat", headerContent, m.width, false)
+	headerSection := m.renderHeaderSection()
+
+	// Focus mode: dim the header and give the rest to the host inspector
+	if host := m.focusHost(); host != "" && host == m.focusData.host {
+		headerSection = tableRowDimStyle.Render(stripAnsi(headerSection))
+		panel := m.renderFocusPanel(m.height - countLines(headerSection))
+		return m.fitAndOverlay(headerSection + "\n" + panel)
+	}
 	sections = append(sections, headerSection)
 
 	// Status codes section with border (columnar layout)
+	statusSection := m.renderStatusSection(layout)
+	if statusSection != "" {
+		sections = append(sections, statusSection)
+	}
+
+	// Data sections
+	dataContent := m.renderDataSections(layout, m.dataRows(layout, headerSection, statusSection))
+	sections = append(sections, dataContent)
+
+	if m.debug {
+		sections = append(sections, m.renderDebugFooter())
+	}
+
+	return m.fitAndOverlay(strings.Join(sections, "\n"))
+}
+
+// layout returns the section layout for the terminal size, or nil if it's
+// too small
+func (m Model) layout() *Layout {
+	if m.noStatus {
+		return CalculateCompactLayout(m.width, m.height, m.section)
+	}
+	return CalculateLayoutWithActiveSection(m.width, m.height, m.section)
+}
+
+// renderHeaderSection renders the bordered header
+func (m Model) renderHeaderSection() string {
+	return m.renderBorderedSection("hstat", m.renderHeaderContent(), m.width, false)
+}
+
+// renderStatusSection renders the bordered status codes section, or ""
+// when it is hidden
+func (m Model) renderStatusSection(layout *Layout) string {
+	if m.noStatus {
+		return ""
+	}
 	statusData := StatusCodesDataFromStore(m.statusCounts)
+	active := m.section == SectionStatus
+	if active {
+		statusData.Selected = m.selectedStatus()
+	}
 	statusContent := RenderStatusCodesColumnar(statusData, m.width-4, layout.StatusCodeColumns)
-	statusSection := m.renderBorderedSection("Status Codes", statusContent, m.width, false)
-	sections = append(sections, statusSection)
+	return m.renderBorderedSection("Status Codes", statusContent, m.width, active)
+}
 
-	// Calculate remaining height for data sections
+// dataRows returns how many rows each of the hosts, IPs, and paths
+// sections gets (header row included) below the header and status
+// sections
+func (m Model) dataRows(layout *Layout, headerSection, statusSection string) int {
 	usedHeight := countLines(headerSection) + countLines(statusSection)
-	remainingHeight := m.height - usedHeight
+	if m.debug {
+		usedHeight++ // footer
+	}
+	availableHeight := m.height - usedHeight
 
-	// Data sections
-	dataContent := m.renderDataSections(layout, remainingHeight)
-	sections = append(sections, dataContent)
+	// Reserve lines for headers and borders
+	sectionOverhead := 3 // title border + header row + bottom border
 
-	// Join all sections
-	content := strings.Join(sections, "\n")
+	// Stacked, or hosts and IPs side by side above paths
+	stacked := 2
+	if layout.DataColumns == 1 {
+		stacked = 3
+	}
+	return max((availableHeight-sectionOverhead*stacked)/stacked, 1)
+}
 
+// tableRows returns how many item rows the hosts, IPs, and paths tables
+// show at the current size (at least one), or 0 before the first resize
+// or when the terminal is too small to draw them
+func (m Model) tableRows() int {
+	if m.width < MinWidth || m.height < MinHeight {
+		return 0
+	}
+	layout := m.layout()
+	if layout == nil {
+		return 0
+	}
+	return max(m.dataRows(layout, m.renderHeaderSection(), m.renderStatusSection(layout))-1, 1)
+}
+
+// fitAndOverlay clips content to the terminal height and draws any modal
+func (m Model) fitAndOverlay(content string) string {
 	// Ensure we don't exceed terminal height
 	lines := strings.Split(content, "\n")
 	if len(lines) > m.height {
@@ -68,10 +141,11 @@ func (m Model) View() string {
 func (m Model) renderHeaderContent() string {
 	elapsed := time.Since(m.startTime).Round(time.Second)
 
-	line1 := fmt.Sprintf("%s | %s reqs | %.1f/s",
+	line1 := fmt.Sprintf("%s | %s | %.1f/s | %s/s",
 		elapsed,
-		formatNumber(m.stats.TotalCount),
+		m.requestCounts(),
 		m.currentRate,
+		formatBytes(int64(m.throughput)),
 	)
 
 	// Add error rates and trend
@@ -98,24 +172,175 @@ func (m Model) renderHeaderContent() string {
 		}
 	}
 
+	// When the newest entry was logged, to tell live data from a backlog
+	if !m.latestEntry.IsZero() {
+		line1 += " | last " + m.latestEntry.Local().Format("15:04:05")
+	}
+	if m.malformed > 0 {
+		line1 += " | " + warningStyle.Render(formatNumber(m.malformed)+" malformed")
+	}
+
+	if m.showHealth && m.stats.TotalCount > 0 {
+		line1 += " | " + renderHealthScore(m.health)
+	}
+	if m.apdexT > 0 && m.stats.TotalCount > 0 {
+		line1 += " | " + renderApdex(m.apdex)
+	}
+
+	// Busiest path right now
+	if m.hotPath != "" {
+		line1 += fmt.Sprintf(" | hot: %s %.1f/s",
+			truncateLabel(m.hotPath, hotPathMaxLen, m.pathTruncate), m.hotPathRate)
+	}
+
+	if m.paused {
+		line1 += "  " + warningStyle.Render("PAUSED")
+	}
+	if m.filter.Status != 0 {
+		line1 += "  " + filterStyle.Render(fmt.Sprintf("[%d only] Esc to clear", m.filter.Status))
+	} else if m.filter.StatusClass != 0 {
+		line1 += "  " + filterStyle.Render(fmt.Sprintf("[%dxx only] Esc to clear", m.filter.StatusClass))
+	}
+	if m.notice != "" && time.Now().Before(m.noticeUntil) {
+		line1 += "  " + filterStyle.Render(m.notice)
+	}
+
 	// Stream status
 	if m.streamEnded {
 		line1 += "  " + streamEndedStyle.Render("⚠ STREAM ENDED")
 	} else if !m.lastEntryTime.IsZero() {
 		sinceLastEntry := time.Since(m.lastEntryTime)
-		if sinceLastEntry > noDataWarningThreshold {
+		if sinceLastEntry > m.staleAfter {
 			secs := int(sinceLastEntry.Seconds())
 			line1 += "  " + warningStyle.Render(fmt.Sprintf("⚠ no data for %ds", secs))
 		}
 	}
 
 	// Stats lines
+	rateLine := m.renderRateSparkline()
 	line2 := fmt.Sprintf("Response: avg %dms | p50 %dms | p95 %dms | p99 %dms | max %dms",
 		m.stats.AvgService, m.stats.P50Service, m.stats.P95Service, m.stats.P99Service, m.stats.MaxService)
-	line3 := fmt.Sprintf("Connect:  avg %dms | max %dms",
-		m.stats.AvgConnect, m.stats.MaxConnect)
 
-	return line1 + "\n" + line2 + "\n" + line3
+	// Creeping p95, before it shows up as errors
+	switch m.latencyTrend {
+	case store.TrendUp:
+		line2 += " " + trendUpStyle.Render("1m↑")
+	case store.TrendDown:
+		line2 += " " + trendDownStyle.Render("1m↓")
+	}
+
+	// Stats cover less than the window until enough data has accrued
+	if note := m.windowNote(); note != "" {
+		line2 += " | " + warningStyle.Render(note)
+	} else if window := m.store.Window(); window > 0 {
+		line2 += " | window " + shortDuration(window)
+	} else {
+		line2 += " | window all"
+	}
+	line2 += " | refresh " + m.refreshRate.String()
+	line3 := fmt.Sprintf("Connect:  avg %dms | p50 %dms | p95 %dms | p99 %dms | max %dms  |  new IPs: %.0f/min",
+		m.stats.AvgConnect, m.stats.P50Connect, m.stats.P95Connect, m.stats.P99Connect, m.stats.MaxConnect, m.newIPRate)
+
+	// Traffic without a proper Host is often scanners
+	if m.unknownHosts > 0 {
+		line3 += "  |  " + warningStyle.Render("no host: "+formatNumber(m.unknownHosts))
+	}
+
+	// Most frequent Heroku error codes (H12, H18, ...)
+	if len(m.errorCodes) > 0 {
+		parts := make([]string, 0, len(m.errorCodes))
+		for _, c := range m.errorCodes {
+			parts = append(parts, fmt.Sprintf("%s:%s", c.Label, formatNumber(c.Count)))
+		}
+		line3 += "  |  Codes: " + status5xxStyle.Render(strings.Join(parts, " "))
+	}
+
+	// Watched status codes, always shown even at zero
+	if watch := m.renderWatchCodes(); watch != "" {
+		line3 += "  |  Watch: " + watch
+	}
+
+	return line1 + "\n" + rateLine + "\n" + line2 + "\n" + line3
+}
+
+// requestCounts contrasts everything seen since start with what the
+// window holds, e.g. "1.2M total / 45.0k in 5m", since elapsed time
+// counts from start but the stats only cover the window
+func (m Model) requestCounts() string {
+	inWindow := formatNumber(m.stats.TotalCount)
+	if window := m.store.Window(); window > 0 {
+		return fmt.Sprintf("%s total / %s in %s", formatNumber(m.lifetime), inWindow, shortDuration(window))
+	}
+	// Without a window only the entry cap drops anything
+	if m.lifetime > m.stats.TotalCount {
+		return fmt.Sprintf("%s total / %s kept", formatNumber(m.lifetime), inWindow)
+	}
+	return inWindow + " reqs"
+}
+
+// renderRateSparkline renders the last minute of request rate, dropping
+// the oldest seconds when the terminal is too narrow to fit them all
+func (m Model) renderRateSparkline() string {
+	var peak float64
+	for _, r := range m.rateBuckets {
+		peak = math.Max(peak, r)
+	}
+	label := "Rate:     "
+	suffix := fmt.Sprintf(" peak %.1f/s", peak)
+
+	buckets := m.rateBuckets
+	room := m.width - 4 - len(label) - len(suffix) // borders + padding
+	if room < len(buckets) {
+		buckets = buckets[len(buckets)-max(room, 0):]
+	}
+	if len(buckets) == 0 {
+		return label + suffix[1:]
+	}
+	return label + sparklineStyle.Render(sparkline(buckets)) + suffix
+}
+
+// windowNote returns "window 1h (only 5m of data)" while the retained data
+// spans noticeably less than the configured window, or "" otherwise
+func (m Model) windowNote() string {
+	window := m.store.Window()
+	if window == 0 || m.stats.TotalCount == 0 {
+		return ""
+	}
+	// Pruning keeps the span just under the window, so allow some slack
+	if m.dataSpan >= window*windowCoverage/100 {
+		return ""
+	}
+	return fmt.Sprintf("window %s (only %s of data)", shortDuration(window), shortDuration(m.dataSpan))
+}
+
+// renderWatchCodes renders "429:120 (1.2%) 502:3 (0.0%)" for the watched codes
+func (m Model) renderWatchCodes() string {
+	if len(m.watchCodes) == 0 {
+		return ""
+	}
+
+	var total int64
+	counts := make(map[int]int64)
+	for _, sc := range m.statusCounts {
+		total += sc.Count
+		counts[sc.Status] = sc.Count
+	}
+
+	parts := make([]string, 0, len(m.watchCodes))
+	for _, code := range m.watchCodes {
+		pct := float64(counts[code]) * 100 / float64(max64(1, total))
+		parts = append(parts, StatusStyle(code).Render(
+			fmt.Sprintf("%d:%s (%.1f%%)", code, formatNumber(counts[code]), pct)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// renderDebugFooter renders "entries: 84,201/100,000 · hosts: 1,204 · ..."
+func (m Model) renderDebugFooter() string {
+	d := m.debugStats
+	return tableRowDimStyle.Render(fmt.Sprintf(" entries: %s/%s · hosts: %s · ips: %s · paths: %s",
+		formatThousands(int64(d.Entries)), formatThousands(int64(d.MaxEntries)),
+		formatThousands(int64(d.Hosts)), formatThousands(int64(d.IPs)), formatThousands(int64(d.Paths))))
 }
 
 // renderBorderedSection renders content within a bordered box
@@ -148,35 +373,22 @@ func (m Model) renderBorderedSection(title, content string, width int, active bo
 	return strings.Join(lines, "\n")
 }
 
-// renderDataSections renders hosts, IPs, and paths sections
-func (m Model) renderDataSections(layout *Layout, availableHeight int) string {
-	// Calculate how many rows each section can have
-	// Reserve lines for headers and borders
-	sectionOverhead := 3 // title border + header row + bottom border
-
+// renderDataSections renders hosts, IPs, and paths sections, each with
+// perSection rows
+func (m Model) renderDataSections(layout *Layout, perSection int) string {
 	var sections []string
 
 	switch layout.DataColumns {
 	case 1:
 		// Stacked layout
-		perSection := (availableHeight - sectionOverhead*3) / 3
-		if perSection < 1 {
-			perSection = 1
-		}
-
 		hostSection := m.renderHostsSectionBordered(m.width, perSection, m.section == SectionHosts)
 		ipSection := m.renderIPsSectionBordered(m.width, perSection, m.section == SectionIPs)
-		pathSection := m.renderPathsSectionBordered(m.width, perSection, false)
+		pathSection := m.renderPathsSectionBordered(m.width, perSection, m.section == SectionPaths)
 
 		sections = append(sections, hostSection, ipSection, pathSection)
 
 	default:
 		// Side by side (2 or 3 columns)
-		perSection := (availableHeight - sectionOverhead*2) / 2
-		if perSection < 1 {
-			perSection = 1
-		}
-
 		// Hosts and IPs side by side
 		colWidth := (m.width - 2) / 2
 		hostSection := m.renderHostsSectionBordered(colWidth, perSection, m.section == SectionHosts)
@@ -186,7 +398,7 @@ func (m Model) renderDataSections(layout *Layout, availableHeight int) string {
 		sections = append(sections, sideBySide)
 
 		// Paths below
-		pathSection := m.renderPathsSectionBordered(m.width, perSection, false)
+		pathSection := m.renderPathsSectionBordered(m.width, perSection, m.section == SectionPaths)
 		sections = append(sections, pathSection)
 	}
 
@@ -201,6 +413,13 @@ func (m Model) renderHostsSectionBordered(width, maxRows int, active bool) strin
 	if m.filter.Host != "" {
 		title = fmt.Sprintf("Host: %s", m.filter.Host)
 	}
+	if m.showingBottomHosts() {
+		title += " | least frequent"
+	}
+	title += m.sortNote(SectionHosts) + m.searchNote(SectionHosts)
+	if m.lostHost != "" {
+		title += fmt.Sprintf(" | selection lost: %s", m.lostHost)
+	}
 	return m.renderBorderedSection(title, content, width, active)
 }
 
@@ -208,10 +427,14 @@ func (m Model) renderHostsSectionBordered(width, maxRows int, active bool) strin
 func (m Model) renderIPsSectionBordered(width, maxRows int, active bool) string {
 	innerWidth := width - 4 // account for borders
 	content := m.renderIPsContent(maxRows, innerWidth)
-	title := fmt.Sprintf("IPs (%d)", m.uniqueIPs)
+	title := fmt.Sprintf("IPs (%d unique / %s)", m.recentIPs, shortDuration(m.recentIPsSpan()))
 	if m.filter.IP != "" {
 		title = fmt.Sprintf("IP: %s", m.filter.IP)
 	}
+	title += m.sortNote(SectionIPs) + m.searchNote(SectionIPs)
+	if m.lostIP != "" {
+		title += fmt.Sprintf(" | selection lost: %s", m.lostIP)
+	}
 	return m.renderBorderedSection(title, content, width, active)
 }
 
@@ -219,25 +442,90 @@ func (m Model) renderIPsSectionBordered(width, maxRows int, active bool) string
 func (m Model) renderPathsSectionBordered(width, maxRows int, active bool) string {
 	content := m.renderPathsContent(maxRows, width-4)
 	title := fmt.Sprintf("Paths (%d)", m.uniquePaths)
+	// Why the rows sum to less than the header total
+	if m.excludedReqs > 0 {
+		title += fmt.Sprintf(" | %s reqs excluded", formatNumber(m.excludedReqs))
+	}
+	if m.filter.Path != "" {
+		title = fmt.Sprintf("Path: %s", m.filter.Path)
+		if len(m.pathMethods) > 0 {
+			title += " | " + methodSplit(m.pathMethods)
+		}
+	}
+	title += m.sortNote(SectionPaths) + m.searchNote(SectionPaths)
+	if m.lostPath != "" {
+		title += fmt.Sprintf(" | selection lost: %s", m.lostPath)
+	}
 	return m.renderBorderedSection(title, content, width, active)
 }
 
+// sortNote returns " | sort: 5xx" for a section not sorted by count
+func (m Model) sortNote(section Section) string {
+	if m.sortModes[section] == SortCount {
+		return ""
+	}
+	return fmt.Sprintf(" | sort: %s", m.sortModes[section])
+}
+
+// searchNote returns " | /query" for the searched section, with a cursor
+// while the query is still being typed
+func (m Model) searchNote(section Section) string {
+	if section != m.searchSection || (!m.searchMode && m.searchQuery == "") {
+		return ""
+	}
+	note := " | /" + m.searchQuery
+	if m.searchMode {
+		note += "_"
+	}
+	return note
+}
+
 // renderHostsContent renders hosts table content (no border)
 func (m Model) renderHostsContent(maxRows, width int) string {
-	return m.renderTableContent(m.topHosts, m.hostCursor, m.section == SectionHosts, m.filter.Host != "", m.hostErrRates, maxRows, width)
+	return m.renderTableContent(SectionHosts, m.topHosts, m.hostCursor, m.section == SectionHosts, m.filter.Host != "", m.hostErrRates, m.hostP95, m.hostReqRates, m.newHosts, maxRows, width)
 }
 
 // renderIPsContent renders IPs table content (no border)
 func (m Model) renderIPsContent(maxRows, width int) string {
-	return m.renderTableContent(m.topIPs, m.ipCursor, m.section == SectionIPs, m.filter.IP != "", m.ipErrRates, maxRows, width)
+	return m.renderTableContent(SectionIPs, m.topIPs, m.ipCursor, m.section == SectionIPs, m.filter.IP != "", m.ipErrRates, m.ipP95, nil, m.newIPs, maxRows, width)
 }
 
-// renderTableContent renders a data table with header row
-func (m Model) renderTableContent(items []store.CountItem, cursor int, active, dimmed bool, errRates map[string]store.ErrorRates, maxRows, width int) string {
+// errRateCell formats a 4xx/5xx rate for a table row. Plain (dimmed or
+// selected) rows stay unstyled so the row style applies; otherwise the
+// class style applies, dimmed below warnRate and bold red from critRate.
+func (m Model) errRateCell(rate float64, style lipgloss.Style, plain bool) string {
+	if rate <= 0 {
+		return "    -"
+	}
+	cell := fmt.Sprintf("%5.1f", rate)
+	switch {
+	case plain:
+		return cell
+	case m.critRate > 0 && rate >= m.critRate:
+		return errorRateCritStyle.Render(cell)
+	case rate < m.warnRate:
+		return tableRowDimStyle.Render(cell)
+	}
+	return style.Render(cell)
+}
+
+// alertRow reports whether a row's 5xx rate reaches critRate, so the
+// whole row is highlighted
+func (m Model) alertRow(rate5xx float64) bool {
+	return m.critRate > 0 && rate5xx >= m.critRate
+}
+
+// renderTableContent renders a data table with header row. A nil reqRates
+// leaves out the req/s column.
+func (m Model) renderTableContent(section Section, items []store.CountItem, cursor int, active, dimmed bool, errRates map[string]store.ErrorRates, p95s map[string]int, reqRates map[string]float64, newLabels map[string]bool, maxRows, width int) string {
 	// Calculate dynamic label length based on available width
-	// Format: "  <label>  <count>  <pct>%  <4xx>  <5xx>"
-	// Fixed parts: 2 (cursor) + 8 (count) + 7 (pct) + 6 (4xx) + 6 (5xx) + 4 (spacing) = 33 chars
-	fixedWidth := 33
+	// Format: "  <label>  <count>  <pct>%  <4xx>  <5xx>  <p95>  [<req/s>]"
+	// Fixed parts: 2 (cursor) + 8 (count) + 7 (pct) + 6 (4xx) + 6 (5xx) + 7 (p95) + 4 (spacing) = 40 chars,
+	// plus 7 for req/s
+	fixedWidth := 40
+	if reqRates != nil {
+		fixedWidth += 7
+	}
 	maxLabelLen := width - fixedWidth
 	if maxLabelLen < 15 {
 		maxLabelLen = 15
@@ -249,8 +537,11 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 	var lines []string
 
 	// Header row
-	header := fmt.Sprintf("  %-*s %7s %6s %5s %5s",
-		maxLabelLen, "Name", "Count", "%", "4xx", "5xx")
+	header := fmt.Sprintf("  %-*s %7s %6s %5s %5s %6s",
+		maxLabelLen, "Name", "Count", "%", "4xx", "5xx", "p95")
+	if reqRates != nil {
+		header += fmt.Sprintf(" %6s", "req/s")
+	}
 	lines = append(lines, tableHeaderStyle.Render(header))
 
 	if len(items) == 0 {
@@ -258,15 +549,8 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 		return strings.Join(lines, "\n")
 	}
 
-	// Limit items to maxRows (subtract 1 for header)
-	displayItems := items
-	displayMax := maxRows - 1
-	if displayMax < 1 {
-		displayMax = 1
-	}
-	if len(displayItems) > displayMax {
-		displayItems = displayItems[:displayMax]
-	}
+	// Limit items to maxRows (subtract 1 for header), scrolled to the cursor
+	displayItems, offset, more := m.scrollRows(section, items, cursor, maxRows-1)
 
 	// Calculate total for percentages
 	var total int64
@@ -275,11 +559,6 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 	}
 
 	for i, item := range displayItems {
-		label := item.Label
-		if len(label) > maxLabelLen {
-			label = label[:maxLabelLen-3] + "..."
-		}
-
 		pct := float64(item.Count) * 100 / float64(max64(1, total))
 
 		var rate4xx, rate5xx float64
@@ -288,27 +567,37 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 			rate5xx = rates.Rate5xx
 		}
 
-		isSelected := active && i == cursor
+		isSelected := active && offset+i == cursor
 
-		rate4xxStr := "    -"
-		rate5xxStr := "    -"
-		if rate4xx > 0 {
-			if dimmed || isSelected {
-				rate4xxStr = fmt.Sprintf("%5.1f", rate4xx)
-			} else {
-				rate4xxStr = status4xxStyle.Render(fmt.Sprintf("%5.1f", rate4xx))
+		// Label cell, with a NEW badge for recently first-seen labels
+		labelCell := fmt.Sprintf("%-*s", maxLabelLen, truncateEnd(item.Label, maxLabelLen))
+		if newLabels[item.Label] {
+			badge := newBadge
+			if !dimmed && !isSelected {
+				badge = newBadgeStyle.Render(newBadge)
 			}
+			label := truncateEnd(item.Label, maxLabelLen-len(newBadge)-1)
+			labelCell = padRight(label+" "+badge, maxLabelLen)
 		}
-		if rate5xx > 0 {
-			if dimmed || isSelected {
-				rate5xxStr = fmt.Sprintf("%5.1f", rate5xx)
-			} else {
-				rate5xxStr = status5xxStyle.Render(fmt.Sprintf("%5.1f", rate5xx))
-			}
+
+		// Dimmed and selected rows take precedence over the alert style;
+		// styled rows render their cells plain to avoid nested ANSI
+		alert := !dimmed && !isSelected && m.alertRow(rate5xx)
+		plain := dimmed || isSelected || alert
+		rate4xxStr := m.errRateCell(rate4xx, status4xxStyle, plain)
+		rate5xxStr := m.errRateCell(rate5xx, status5xxStyle, plain)
+
+		// No timing data (e.g. only 101s) shows as "-"
+		p95Str := "-"
+		if p95, ok := p95s[item.Label]; ok {
+			p95Str = formatLatency(p95)
 		}
 
-		line := fmt.Sprintf("%-*s %7s %5.1f%% %s %s",
-			maxLabelLen, label, formatNumber(item.Count), pct, rate4xxStr, rate5xxStr)
+		line := fmt.Sprintf("%s %7s %5.1f%% %s %s %6s",
+			labelCell, formatNumber(item.Count), pct, rate4xxStr, rate5xxStr, p95Str)
+		if reqRates != nil {
+			line += fmt.Sprintf(" %6s", formatReqRate(reqRates[item.Label]))
+		}
 
 		var style lipgloss.Style
 		if dimmed {
@@ -316,6 +605,9 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 		} else if isSelected {
 			line = "> " + line
 			style = tableRowSelectedStyle
+		} else if alert {
+			line = "  " + line
+			style = tableRowAlertStyle
 		} else {
 			line = "  " + line
 			style = tableRowStyle
@@ -323,10 +615,63 @@ func (m Model) renderTableContent(items []store.CountItem, cursor int, active, d
 
 		lines = append(lines, style.Render(line))
 	}
+	if more != "" {
+		lines = append(lines, tableRowDimStyle.Render(more))
+	}
 
 	return strings.Join(lines, "\n")
 }
 
+// scrollRows returns the slice of items that fits in rows from the
+// section's scroll offset, which Update keeps on the cursor. When items
+// don't all fit, the last row is given to a "[n more]" note (returned as
+// more).
+func (m Model) scrollRows(section Section, items []store.CountItem, cursor, rows int) (visible []store.CountItem, offset int, more string) {
+	rows = max(rows, 1)
+	if len(items) <= rows {
+		return items, 0, ""
+	}
+
+	// Already in view unless the size changed since the last Update
+	offset = scrollOffset(m.scrollOffsets[section], cursor, len(items), rows)
+	note := rows > 1
+	if note {
+		rows--
+	}
+	visible = items[offset : offset+rows]
+	if !note {
+		return visible, offset, ""
+	}
+	if below := len(items) - offset - rows; below > 0 {
+		more = fmt.Sprintf("  [%d more]", below)
+	} else {
+		more = fmt.Sprintf("  [%d above]", offset)
+	}
+	return visible, offset, more
+}
+
+// scrollOffset moves a list's scroll offset from prev just enough to keep
+// cursor visible, with rows rows for its n items. Like scrollRows, it
+// keeps a row for the note when they don't all fit.
+func scrollOffset(prev, cursor, n, rows int) int {
+	rows = max(rows, 1)
+	if n <= rows {
+		return 0
+	}
+	// Keep a row for the note, unless that would leave none for items
+	if rows > 1 {
+		rows--
+	}
+	offset := prev
+	if cursor < offset {
+		offset = cursor
+	}
+	if cursor >= offset+rows {
+		offset = cursor - rows + 1
+	}
+	return max(0, min(offset, n-rows))
+}
+
 // renderPathsContent renders paths table content
 func (m Model) renderPathsContent(maxRows, width int) string {
 	// Calculate max path length dynamically
@@ -353,26 +698,20 @@ func (m Model) renderPathsContent(maxRows, width int) string {
 		return strings.Join(lines, "\n")
 	}
 
-	// Limit items (subtract 1 for header)
-	displayItems := m.topPaths
-	displayMax := maxRows - 1
-	if displayMax < 1 {
-		displayMax = 1
-	}
-	if len(displayItems) > displayMax {
-		displayItems = displayItems[:displayMax]
-	}
+	// Limit items (subtract 1 for header), scrolled to the cursor
+	displayItems, offset, more := m.scrollRows(SectionPaths, m.topPaths, m.pathCursor, maxRows-1)
 
 	var total int64
 	for _, item := range m.topPaths {
 		total += item.Count
 	}
 
-	for _, item := range displayItems {
-		label := item.Label
-		if len(label) > maxPathLen {
-			label = label[:maxPathLen-3] + "..."
-		}
+	// Dim paths when filtering BY path (path is the filter source)
+	active := m.section == SectionPaths
+	dimmed := m.filter.Path != ""
+
+	for i, item := range displayItems {
+		label := truncateLabel(item.Label, maxPathLen, m.pathTruncate)
 
 		pct := float64(item.Count) * 100 / float64(max64(1, total))
 
@@ -382,18 +721,29 @@ func (m Model) renderPathsContent(maxRows, width int) string {
 			rate5xx = rates.Rate5xx
 		}
 
-		rate4xxStr := "    -"
-		rate5xxStr := "    -"
-		if rate4xx > 0 {
-			rate4xxStr = status4xxStyle.Render(fmt.Sprintf("%5.1f", rate4xx))
-		}
-		if rate5xx > 0 {
-			rate5xxStr = status5xxStyle.Render(fmt.Sprintf("%5.1f", rate5xx))
-		}
+		isSelected := active && offset+i == m.pathCursor
+		alert := !dimmed && !isSelected && m.alertRow(rate5xx)
+		plain := dimmed || isSelected || alert
 
-		line := fmt.Sprintf("  %-*s %7s %5.1f%% %s %s",
+		rate4xxStr := m.errRateCell(rate4xx, status4xxStyle, plain)
+		rate5xxStr := m.errRateCell(rate5xx, status5xxStyle, plain)
+
+		line := fmt.Sprintf("%-*s %7s %5.1f%% %s %s",
 			maxPathLen, label, formatNumber(item.Count), pct, rate4xxStr, rate5xxStr)
-		lines = append(lines, tableRowStyle.Render(line))
+
+		switch {
+		case dimmed:
+			lines = append(lines, tableRowDimStyle.Render("  "+line))
+		case isSelected:
+			lines = append(lines, tableRowSelectedStyle.Render("> "+line))
+		case alert:
+			lines = append(lines, tableRowAlertStyle.Render("  "+line))
+		default:
+			lines = append(lines, tableRowStyle.Render("  "+line))
+		}
+	}
+	if more != "" {
+		lines = append(lines, tableRowDimStyle.Render(more))
 	}
 
 	return strings.Join(lines, "\n")
@@ -434,7 +784,19 @@ func countLines(s string) int {
 	return strings.Count(s, "\n") + 1
 }
 
-const noDataWarningThreshold = 30 * time.Second
+// DefaultStaleAfter is how long without entries before the header warns,
+// unless WithStaleAfter says otherwise
+const DefaultStaleAfter = 30 * time.Second
+
+// windowCoverage is the percentage of the window the data must span
+// before the "only N of data" note is dropped
+const windowCoverage = 95
+
+// hotPathMaxLen caps the header's hot path callout
+const hotPathMaxLen = 40
+
+// newBadge marks hosts/IPs first seen within newBadgeWindow
+const newBadge = "NEW"
 
 func (m Model) renderHeader() string {
 	elapsed := time.Since(m.startTime).Round(time.Second)
@@ -486,7 +848,7 @@ func (m Model) renderHeader() string {
 		result += "  " + streamEndedStyle.Render("⚠ STREAM ENDED")
 	} else if !m.lastEntryTime.IsZero() {
 		sinceLastEntry := time.Since(m.lastEntryTime)
-		if sinceLastEntry > noDataWarningThreshold {
+		if sinceLastEntry > m.staleAfter {
 			secs := int(sinceLastEntry.Seconds())
 			result += "  " + warningStyle.Render(fmt.Sprintf("⚠ no data for %ds", secs))
 		}
@@ -497,6 +859,8 @@ func (m Model) renderHeader() string {
 		result += "  " + filterStyle.Render(fmt.Sprintf("[host=%s] Esc to clear", m.filter.Host))
 	} else if m.filter.IP != "" {
 		result += "  " + filterStyle.Render(fmt.Sprintf("[ip=%s] Esc to clear", m.filter.IP))
+	} else if m.filter.Path != "" {
+		result += "  " + filterStyle.Render(fmt.Sprintf("[path=%s] Esc to clear", m.filter.Path))
 	}
 
 	return result
@@ -598,10 +962,7 @@ func (m Model) renderPaths() string {
 	}
 
 	for _, item := range m.topPaths {
-		label := item.Label
-		if len(label) > maxPathLen {
-			label = label[:maxPathLen-3] + "..."
-		}
+		label := truncateLabel(item.Label, maxPathLen, m.pathTruncate)
 
 		pct := float64(item.Count) * 100 / float64(max64(1, total))
 
@@ -784,17 +1145,40 @@ func helpContent() string {
   G              Jump to bottom
 
 Actions:
-  Enter          Filter by selected host/IP
+  Enter          Filter by selected host/IP/path/status code
+  4 / 5          Show only 4xx / 5xx traffic (toggle)
+  /              Search the active table as you type
+  < / >          Previous / next filter in history
+  s              Sort table by count / 4xx / 5xx rate
+                 (IPs also by 4xx / 5xx request count)
+  B              Hosts: most / least frequent (toggle)
+  p              Pause display (data keeps arriving)
+  S              Save a snapshot of the screen to a file
+  x              Export hosts/IPs/paths as CSV
+  + / -          Widen / narrow the data window
+  ] / [          More / fewer rows per table
+  , / .          Refresh faster / slower (200ms to 10s)
+  f              Focus: inspect selected host (toggle)
+  d              Host details (when host selected)
+  e              Heroku error codes (H12, H18, ...)
+  L              Slowest paths by p95
+  H              Response time histogram
+  t              Latency by status code
   w              Whois lookup (when IP selected)
   i              ipinfo.io lookup (when IP selected)
+  r              Reverse DNS lookup (when IP selected)
+  o              GeoIP location (with --geoip)
+  b              Open ipinfo.io page in browser (when IP selected)
   Esc            Clear filter (or close modal)
   q / Ctrl+C     Quit`
 }
 
 func (m Model) renderWithModal(background string) string {
 	// Calculate modal dimensions
+	// Height isn't capped like width: long content such as help uses
+	// whatever the terminal offers before truncating
 	modalWidth := min(m.width-4, 80)
-	modalHeight := min(m.height-4, 30)
+	modalHeight := m.height - 4
 
 	// Build modal content
 	var content strings.Builder
@@ -934,6 +1318,62 @@ func formatNumber(n int64) string {
 	return fmt.Sprintf("%.1fM", float64(n)/1000000)
 }
 
+// formatLatency renders a millisecond latency in at most 6 characters:
+// "850ms", "9999ms", then whole seconds ("12s")
+func formatLatency(ms int) string {
+	if ms < 10000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%ds", ms/1000)
+}
+
+// formatReqRate renders a request rate in at most 6 characters: "12.5",
+// then whole ("850"), then thousands ("1.2k")
+func formatReqRate(r float64) string {
+	switch {
+	case r < 100:
+		return fmt.Sprintf("%.1f", r)
+	case r < 1000:
+		return fmt.Sprintf("%.0f", r)
+	}
+	return formatNumber(int64(r))
+}
+
+// shortDuration formats d compactly: "45s", "5m", "1h30m"
+func shortDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	s := d.Round(time.Minute).String()
+	s = strings.TrimSuffix(s, "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// formatThousands formats n with comma separators (e.g. 84,201)
+func formatThousands(n int64) string {
+	if n < 0 {
+		return "-" + formatThousands(-n)
+	}
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	if n < 1024*1024 {
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
+}
+
 func max64(a, b int64) int64 {
 	if a > b {
 		return a
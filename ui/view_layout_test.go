@@ -252,3 +252,23 @@ func TestView_DynamicHostnameTruncation(t *testing.T) {
 		t.Errorf("expected wide terminal to show more of hostname (wide: %d chars, narrow: %d chars)", wideVisible, narrowVisible)
 	}
 }
+
+func TestView_NoStatusSection(t *testing.T) {
+	s := store.New(0)
+	for i := 0; i < 50; i++ {
+		s.Add(&parser.Entry{Status: 200, Host: "example.com", Path: "/api", IP: "1.2.3.4"})
+	}
+
+	m := NewModel(s, time.Second, WithNoStatusSection(true))
+	m.width = 120
+	m.height = MinHeight
+	m.refreshData()
+
+	view := m.View()
+	if strings.Contains(view, "Status Codes") {
+		t.Error("expected status codes section to be omitted")
+	}
+	if lines := strings.Split(view, "\n"); len(lines) > m.height {
+		t.Errorf("View has %d lines but terminal height is %d", len(lines), m.height)
+	}
+}
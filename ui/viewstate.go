@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ViewState is the persisted part of the UI state: where the user was
+// looking, not the data they were looking at
+type ViewState struct {
+	Section Section `json:"section"`
+	Host    string  `json:"host,omitempty"`
+	IP      string  `json:"ip,omitempty"`
+	Path    string  `json:"path,omitempty"`
+}
+
+// viewState captures the model's current view state
+func (m Model) viewState() ViewState {
+	return ViewState{
+		Section: m.section,
+		Host:    m.filter.Host,
+		IP:      m.filter.IP,
+		Path:    m.filter.Path,
+	}
+}
+
+// WithViewState restores a saved view state and saves later changes to path
+func WithViewState(path string, vs ViewState) Option {
+	return func(m *Model) {
+		m.viewStateFile = path
+		if vs.Section >= 0 && vs.Section < sectionCount {
+			m.section = vs.Section
+		}
+		m.filter = Filter{Host: vs.Host, IP: vs.IP, Path: vs.Path}
+	}
+}
+
+// LoadViewState reads a view state file. A missing file is not an error;
+// it yields the default view.
+func LoadViewState(path string) (ViewState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ViewState{}, nil
+	}
+	if err != nil {
+		return ViewState{}, err
+	}
+
+	var vs ViewState
+	if err := json.Unmarshal(data, &vs); err != nil {
+		return ViewState{}, err
+	}
+	return vs, nil
+}
+
+// SaveViewState writes a view state file
+func SaveViewState(path string, vs ViewState) error {
+	data, err := json.MarshalIndent(vs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
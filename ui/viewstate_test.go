@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/betternow/hstat/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestViewState_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "view.json")
+
+	want := ViewState{Section: SectionIPs, IP: "1.2.3.4"}
+	if err := SaveViewState(path, want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := LoadViewState(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected %+v after round trip, got %+v", want, got)
+	}
+
+	m := NewModel(store.New(0), time.Second, WithViewState(path, got))
+	if m.section != SectionIPs || m.filter.IP != "1.2.3.4" || m.filter.Host != "" {
+		t.Errorf("expected restored section/filter, got section %v filter %+v", m.section, m.filter)
+	}
+}
+
+func TestLoadViewState_Missing(t *testing.T) {
+	vs, err := LoadViewState(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if vs != (ViewState{}) {
+		t.Errorf("expected default view state, got %+v", vs)
+	}
+}
+
+func TestLoadViewState_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "view.json")
+	os.WriteFile(path, []byte("{not json"), 0o644)
+
+	if _, err := LoadViewState(path); err == nil {
+		t.Error("expected error for corrupt view state file")
+	}
+}
+
+func TestUpdate_SavesViewStateOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "view.json")
+	m := NewModel(store.New(0), time.Second, WithViewState(path, ViewState{}))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	vs, err := LoadViewState(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if vs.Section != SectionIPs {
+		t.Errorf("expected saved section IPs after Tab, got %v", vs.Section)
+	}
+}